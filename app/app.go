@@ -0,0 +1,298 @@
+// Package app wires the application together. An App owns the database, the logger, the
+// repositories and the controllers built from one config.Config, so nothing is reached
+// through package globals and several instances can live in one process.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/config"
+	"github.com/metabbe3/go-backend/controllers"
+	"github.com/metabbe3/go-backend/health"
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/metrics"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/migrations"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/routes"
+	"github.com/metabbe3/go-backend/tracing"
+	"github.com/metabbe3/go-backend/utils"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"gorm.io/gorm"
+)
+
+// tracerShutdownTimeout bounds how long Close waits to export the remaining spans
+const tracerShutdownTimeout = 5 * time.Second
+
+// App is a fully wired application instance
+type App struct {
+	Config config.Config
+	DB     *gorm.DB
+	Logger *utils.Logger
+
+	Users         *repositories.UserRepository
+	Customers     *repositories.CustomerRepository
+	RefreshTokens *repositories.RefreshTokenRepository
+	Roles         *repositories.RoleRepository
+	Organizations *repositories.OrganizationRepository
+	Messages      *repositories.MessageRepository
+	Revocations   utils.RevocationStore
+	Policy        *utils.PolicyEngine
+	Sender        messaging.MessageSender
+	Health        *health.Registry
+	Metrics       *metrics.Metrics         // Nil when metrics are disabled
+	Tracer        *sdktrace.TracerProvider // Nil when tracing is disabled
+
+	Routes routes.Dependencies // Controllers and middleware dependencies the routes are bound to
+
+	draining  atomic.Bool         // Set once shutdown starts
+	drainWait func(time.Duration) // Waits out the drain delay; time.Sleep unless a test replaces it
+}
+
+// New validates the configuration, opens the log file and the database, migrates and seeds
+// the schema, and wires the application
+func New(cfg config.Config) (*App, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	logger, err := utils.OpenLogger(cfg.Log.File, logOptions(cfg.Log))
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	utils.SetLogger(logger)
+
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		logger.Close()
+		return nil, err
+	}
+
+	app, err := NewWithDB(cfg, db, logger)
+	if err != nil {
+		closeDB(db)
+		logger.Close()
+		return nil, err
+	}
+	return app, nil
+}
+
+// NewWithDB wires the application around an open database, migrating and seeding it per cfg.
+// Tests use it to run a full router against an in-memory database; cfg is not validated.
+//
+// Logging through the package-level utils functions, the default phone region, the token
+// settings and the error response format are process-wide, so they follow the most recently created App.
+func NewWithDB(cfg config.Config, db *gorm.DB, logger *utils.Logger) (*App, error) {
+	utils.SetLogger(logger)
+	utils.SetJWTSecret(cfg.Auth.JWTSecret)
+	utils.SetCursorSecret(cfg.Auth.CursorSecret)
+	utils.SetErrorFormat(cfg.Server.ErrorFormat)
+	utils.AccessTokenTTL = cfg.Auth.AccessTokenTTL
+	utils.RefreshTokenTTL = cfg.Auth.RefreshTokenTTL
+
+	if err := utils.SetDefaultPhoneRegion(cfg.PhoneRegion); err != nil {
+		return nil, fmt.Errorf("invalid PHONE_DEFAULT_REGION: %w", err)
+	}
+	if err := config.MigrateDB(db, cfg.Database); err != nil {
+		return nil, err
+	}
+	if err := config.SeedDB(db, cfg.AdminEmail); err != nil {
+		return nil, err
+	}
+
+	app := &App{
+		Config:        cfg,
+		DB:            db,
+		Logger:        logger,
+		Users:         repositories.NewUserRepository(db),
+		Customers:     repositories.NewCustomerRepository(db),
+		RefreshTokens: repositories.NewRefreshTokenRepository(db),
+		Roles:         repositories.NewRoleRepository(db),
+		Organizations: repositories.NewOrganizationRepository(db),
+		Messages:      repositories.NewMessageRepository(db),
+		Sender:        newMessageSender(cfg.WhatsApp),
+	}
+	app.Revocations = app.newRevocationStore()
+	app.Policy = utils.NewPolicyEngine(app.Roles, time.Minute)
+	if cfg.Metrics.Enabled {
+		app.Metrics = metrics.New()
+		if err := app.Metrics.InstrumentDB(db); err != nil {
+			return nil, fmt.Errorf("instrument database: %w", err)
+		}
+	}
+	// Statement spans cost nothing outside a traced request, so the hooks are always installed
+	if err := tracing.InstrumentDB(db); err != nil {
+		return nil, fmt.Errorf("instrument database: %w", err)
+	}
+	if cfg.Tracing.Exporter == tracing.ExporterOTLP {
+		tracer, err := tracing.NewProvider(tracing.Options{
+			ServiceName: cfg.Tracing.ServiceName,
+			Environment: cfg.Env,
+			Endpoint:    cfg.Tracing.Endpoint,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			return nil, err
+		}
+		app.Tracer = tracer
+	}
+	registry, err := app.newHealthRegistry()
+	if err != nil {
+		return nil, err
+	}
+	app.Health = registry
+
+	auth := controllers.NewAuthController(app.Users, app.Organizations, app.RefreshTokens, app.Revocations, utils.BcryptHasher{})
+	auth.Metrics = app.Metrics
+	customer := controllers.NewCustomerController(app.Customers)
+	customer.Metrics = app.Metrics
+	webhook := controllers.NewWebhookController(app.Customers, app.Messages, newWebhookConfig(cfg.WhatsApp))
+	webhook.Metrics = app.Metrics
+
+	app.Routes = routes.Dependencies{
+		Auth:         auth,
+		User:         controllers.NewUserController(app.Users, utils.BcryptHasher{}),
+		Customer:     customer,
+		Role:         controllers.NewRoleController(app.Roles, app.Users, app.Policy, app.Revocations),
+		Organization: controllers.NewOrganizationController(app.Organizations, app.Users, app.Roles, app.Revocations),
+		Message:      controllers.NewMessageController(app.Customers, app.Messages, app.Sender),
+		Webhook:      webhook,
+		Policy:       app.Policy,
+		Revocations:  app.Revocations,
+		Health:       controllers.NewHealthController(app.Health, app.Ready),
+
+		DBTimeout:     cfg.Database.RequestTimeout,
+		BulkDBTimeout: cfg.Database.BulkRequestTimeout,
+	}
+	return app, nil
+}
+
+// Router builds a gin engine with every route of the application
+func (a *App) Router() *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	if a.Tracer != nil {
+		router.Use(middleware.Tracing(a.Tracer))
+	}
+	router.Use(middleware.RequestLogger())
+	if a.Metrics != nil {
+		router.Use(middleware.Metrics(a.Metrics))
+	}
+	router.Use(gin.Recovery())            // Inside the logger and metrics, so panics are logged and counted as 500s
+	router.Use(middleware.ErrorHandler()) // Renders the errors handlers report with c.Error
+
+	// Trust only localhost as a proxy (adjust if needed)
+	router.SetTrustedProxies([]string{"127.0.0.1"})
+
+	routes.SetupRoutes(router, a.Routes)
+	return router
+}
+
+// Close releases the resources the application owns: background workers, the database
+// pool, the tracer, whose pending spans are exported, and the log file, which is flushed to disk
+func (a *App) Close() error {
+	if store, ok := a.Revocations.(*utils.MemoryRevocationStore); ok {
+		store.Close()
+	}
+	dbErr := closeDB(a.DB)
+	if dbErr == nil {
+		utils.Info("Database connections closed")
+	}
+
+	var tracerErr error
+	if a.Tracer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracerShutdownTimeout)
+		defer cancel()
+		tracerErr = a.Tracer.Shutdown(ctx)
+	}
+	return errors.Join(dbErr, tracerErr, a.Logger.Close())
+}
+
+// logOptions translates the log configuration, which Validate has checked
+func logOptions(cfg config.LogConfig) utils.LogOptions {
+	level, _ := utils.ParseLogLevel(cfg.Level)
+	return utils.LogOptions{
+		Format: cfg.Format,
+		Level:  level,
+		Rotation: utils.RotationOptions{
+			MaxSize:    int64(cfg.MaxSizeMB) << 20,
+			Interval:   cfg.RotateInterval,
+			Compress:   cfg.Compress,
+			MaxBackups: int(cfg.MaxBackups),
+			MaxAge:     cfg.MaxAge,
+		},
+	}
+}
+
+// newRevocationStore picks the token revocation backend.
+// "db" (default) is shared across instances; "memory" only suits a single instance.
+func (a *App) newRevocationStore() utils.RevocationStore {
+	if a.Config.RevocationStore == config.RevocationStoreMemory {
+		return utils.NewMemoryRevocationStore(time.Minute)
+	}
+	return repositories.NewRevocationRepository(a.DB)
+}
+
+// newHealthRegistry registers the readiness checks: the database, its schema version and,
+// when configured, the messaging provider. The provider is not critical: its outage
+// degrades messaging but must not take every instance out of the load balancer.
+func (a *App) newHealthRegistry() (*health.Registry, error) {
+	cfg := a.Config.Health
+	registry := health.NewRegistry(cfg.CacheTTL)
+	registry.Register(health.Check{Name: "database", Timeout: cfg.CheckTimeout, Critical: true, Run: health.Database(a.DB)})
+
+	if !a.Config.Database.AutoMigrate {
+		migrator, err := migrations.NewMigrator(a.DB)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(health.Check{Name: "migrations", Timeout: cfg.CheckTimeout, Critical: true, Run: health.Migrations(migrator)})
+	}
+
+	if pinger, ok := a.Sender.(messaging.Pinger); ok {
+		registry.Register(health.Check{Name: "whatsapp", Timeout: cfg.CheckTimeout, CacheTTL: cfg.ProviderTTL, Run: pinger.Ping})
+	}
+	return registry, nil
+}
+
+// newMessageSender configures the WhatsApp Cloud API sender.
+// Without credentials, sending is disabled and the endpoints answer 503.
+func newMessageSender(cfg config.WhatsAppConfig) messaging.MessageSender {
+	if cfg.PhoneNumberID == "" || cfg.AccessToken == "" {
+		utils.Warning("WhatsApp credentials not set, messaging is disabled")
+		return messaging.DisabledSender{}
+	}
+
+	return messaging.NewWhatsAppSender(messaging.WhatsAppConfig{
+		BaseURL:       cfg.APIURL,
+		APIVersion:    cfg.APIVersion,
+		PhoneNumberID: cfg.PhoneNumberID,
+		AccessToken:   cfg.AccessToken,
+	})
+}
+
+// newWebhookConfig configures the inbound WhatsApp webhook.
+// Inbound messages are filed under cfg.OrganizationID.
+func newWebhookConfig(cfg config.WhatsAppConfig) messaging.WebhookConfig {
+	return messaging.WebhookConfig{
+		AppSecret:       cfg.AppSecret,
+		VerifyToken:     cfg.VerifyToken,
+		PhoneNumberID:   cfg.PhoneNumberID,
+		OrganizationID:  cfg.OrganizationID,
+		AutoCreateLeads: cfg.AutoCreateLeads,
+	}
+}
+
+// closeDB closes the connection pool behind db
+func closeDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,568 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/app"
+	"github.com/metabbe3/go-backend/config"
+	"github.com/metabbe3/go-backend/health"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/tracing"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestApp builds an application on its own in-memory database
+func newTestApp(t *testing.T) *app.App {
+	return newTestAppWithLogger(t, utils.NewLogger(io.Discard, utils.LogOptions{}))
+}
+
+// newTestAppWithLogger builds an application on its own in-memory database that logs to logger
+func newTestAppWithLogger(t *testing.T, logger *utils.Logger) *app.App {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	cfg.Env = config.EnvTest
+	cfg.Auth.JWTSecret = "test-secret"
+	cfg.Database = config.DatabaseConfig{Driver: config.DriverSQLite}
+	application, err := app.NewWithDB(cfg, test.NewTestDB(t), logger)
+	require.NoError(t, err)
+	return application
+}
+
+// do sends a JSON request and decodes the JSON response
+func do(t *testing.T, router *gin.Engine, method, path, token string, body interface{}) (int, utils.Response) {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	return w.Code, response
+}
+
+func TestApp_FullRouter(t *testing.T) {
+	router := newTestApp(t).Router()
+
+	credentials := gin.H{"email": "owner@example.com", "password": "Password123"}
+	status, _ := do(t, router, http.MethodPost, "/auth/register", "", gin.H{
+		"email": "owner@example.com", "password": "Password123", "organization_name": "Acme",
+	})
+	require.Equal(t, http.StatusCreated, status)
+
+	status, response := do(t, router, http.MethodPost, "/auth/login", "", credentials)
+	require.Equal(t, http.StatusOK, status)
+	token, _ := response.Data.(map[string]interface{})["token"].(string)
+	require.NotEmpty(t, token)
+
+	status, _ = do(t, router, http.MethodGet, "/api/customers", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = do(t, router, http.MethodPost, "/api/customer", token, gin.H{
+		"name": "Budi", "email": "budi@example.com", "phone": "0812-3456-7890",
+	})
+	require.Equal(t, http.StatusCreated, status)
+
+	status, response = do(t, router, http.MethodGet, "/api/customers", token, nil)
+	require.Equal(t, http.StatusOK, status)
+	customers := response.Data.(map[string]interface{})["data"].([]interface{})
+	require.Len(t, customers, 1)
+	assert.Equal(t, "+6281234567890", customers[0].(map[string]interface{})["phone"])
+}
+
+func TestApp_InstancesAreIsolated(t *testing.T) {
+	first, second := newTestApp(t).Router(), newTestApp(t).Router()
+
+	status, _ := do(t, first, http.MethodPost, "/auth/register", "", gin.H{"email": "owner@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusCreated, status)
+
+	credentials := gin.H{"email": "owner@example.com", "password": "Password123"}
+	status, _ = do(t, first, http.MethodPost, "/auth/login", "", credentials)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = do(t, second, http.MethodPost, "/auth/login", "", credentials)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestApp_RegisterBackToBack(t *testing.T) {
+	router := newTestApp(t).Router()
+
+	// Nobody logs in between, so no column is left for the second account to collide on
+	for _, registration := range []gin.H{
+		{"email": "first@example.com", "password": "Password123"},
+		{"email": "second@example.com", "password": "Password123"},
+		{"email": "third@example.com", "password": "Password123", "organization_name": "Acme"},
+		{"email": "fourth@example.com", "password": "Password123", "organization_name": "Globex"},
+	} {
+		status, response := do(t, router, http.MethodPost, "/auth/register", "", registration)
+		assert.Equal(t, http.StatusCreated, status, response.Message)
+	}
+
+	login(t, router, "first@example.com")
+	login(t, router, "fourth@example.com")
+}
+
+// registerAndLogin registers an account, in a new organization unless orgName is empty,
+// and returns its access token
+func registerAndLogin(t *testing.T, router *gin.Engine, email, orgName string) string {
+	t.Helper()
+
+	registration := gin.H{"email": email, "password": "Password123"}
+	if orgName != "" {
+		registration["organization_name"] = orgName
+	}
+	status, _ := do(t, router, http.MethodPost, "/auth/register", "", registration)
+	require.Equal(t, http.StatusCreated, status)
+	return login(t, router, email)
+}
+
+// login signs in an account registered by registerAndLogin and returns its access token
+func login(t *testing.T, router *gin.Engine, email string) string {
+	t.Helper()
+
+	status, response := do(t, router, http.MethodPost, "/auth/login", "", gin.H{"email": email, "password": "Password123"})
+	require.Equal(t, http.StatusOK, status)
+	token, _ := response.Data.(map[string]interface{})["token"].(string)
+	require.NotEmpty(t, token)
+	return token
+}
+
+func TestApp_TenantIsolation(t *testing.T) {
+	router := newTestApp(t).Router()
+	acme := registerAndLogin(t, router, "acme@example.com", "Acme")
+	globex := registerAndLogin(t, router, "globex@example.com", "Globex")
+	orgless := registerAndLogin(t, router, "orgless@example.com", "")
+	orglessPeer := registerAndLogin(t, router, "peer@example.com", "")
+
+	status, response := do(t, router, http.MethodPost, "/api/customer", acme, gin.H{"name": "Budi", "email": "budi@example.com", "phone": "081234567890"})
+	require.Equal(t, http.StatusCreated, status)
+	customer := response.Data.(map[string]interface{})["customer"].(map[string]interface{})
+	customerPath := fmt.Sprintf("/api/customer/%.0f", customer["id"])
+
+	t.Run("Other Organization Sees Nothing", func(t *testing.T) {
+		status, response := do(t, router, http.MethodGet, "/api/customers", globex, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, response.Data.(map[string]interface{})["data"])
+
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			status, _ := do(t, router, method, customerPath, globex, gin.H{"name": "Taken", "email": "taken@example.com", "phone": "081234567890"})
+			assert.Equal(t, http.StatusNotFound, status, method)
+		}
+	})
+
+	t.Run("Users Without Organization Share No Tenant", func(t *testing.T) {
+		status, _ := do(t, router, http.MethodPost, "/api/customer", orgless, gin.H{"name": "Sari", "email": "sari@example.com", "phone": "081298765432"})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, response := do(t, router, http.MethodGet, "/api/customers", orglessPeer, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "Join or create an organization first", response.Message)
+	})
+
+	t.Run("Owner Can't Change Another Account", func(t *testing.T) {
+		status, _ := do(t, router, http.MethodPut, "/api/user/globex@example.com", acme, gin.H{"email": "globex@example.com", "password": "Hijacked123"})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = do(t, router, http.MethodPost, "/auth/login", "", gin.H{"email": "globex@example.com", "password": "Password123"})
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Invitation Needs Acceptance", func(t *testing.T) {
+		status, response := do(t, router, http.MethodPost, "/api/organization/invitations", acme, gin.H{"email": "orgless@example.com"})
+		require.Equal(t, http.StatusCreated, status)
+		invitation := response.Data.(map[string]interface{})["invitation"].(map[string]interface{})
+
+		status, response = do(t, router, http.MethodGet, "/api/organization/members", acme, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, response.Data.(map[string]interface{})["members"], 1, "invited users aren't members yet")
+
+		acceptPath := fmt.Sprintf("/api/invitations/%.0f/accept", invitation["id"])
+		status, _ = do(t, router, http.MethodPost, acceptPath, orglessPeer, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, _ = do(t, router, http.MethodPost, acceptPath, orgless, nil)
+		require.Equal(t, http.StatusCreated, status)
+
+		status, response = do(t, router, http.MethodGet, "/api/organization/members", acme, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, response.Data.(map[string]interface{})["members"], 2)
+	})
+}
+
+func TestApp_AssignUserRole(t *testing.T) {
+	application := newTestApp(t)
+	router := application.Router()
+	registerAndLogin(t, router, "admin@example.com", "Acme")
+	require.NoError(t, config.SeedDB(application.DB, "admin@example.com"))
+	admin := login(t, router, "admin@example.com")
+	member := registerAndLogin(t, router, "member@example.com", "Globex")
+
+	var memberID uint
+	require.NoError(t, application.DB.Table("users").Select("id").Where("email = ?", "member@example.com").Scan(&memberID).Error)
+	rolePath := fmt.Sprintf("/api/admin/users/%d/role", memberID)
+
+	status, _ := do(t, router, http.MethodGet, "/api/admin/roles", member, nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	status, response := do(t, router, http.MethodPut, rolePath, admin, gin.H{"role": "owner"})
+	assert.Equal(t, http.StatusBadRequest, status, "organization roles aren't assigned here")
+	assert.Equal(t, "Invalid role", response.Message)
+
+	status, _ = do(t, router, http.MethodPut, rolePath, admin, gin.H{"role": "admin"})
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = do(t, router, http.MethodGet, "/api/admin/roles", login(t, router, "member@example.com"), nil)
+	assert.Equal(t, http.StatusOK, status, "the new role applies from the next login")
+}
+
+func TestApp_ErrorResponses(t *testing.T) {
+	router := newTestApp(t).Router()
+
+	owner := gin.H{"email": "owner@example.com", "password": "Password123"}
+	status, _ := do(t, router, http.MethodPost, "/auth/register", "", owner)
+	require.Equal(t, http.StatusCreated, status)
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		body            interface{}
+		expectCode      int
+		expectErrorCode string
+		expectMsg       string
+	}{
+		{
+			name:            "Duplicate Email",
+			method:          http.MethodPost,
+			path:            "/auth/register",
+			body:            owner,
+			expectCode:      http.StatusConflict,
+			expectErrorCode: utils.CodeConflict,
+			expectMsg:       "User already exists",
+		},
+		{
+			name:            "Invalid Body",
+			method:          http.MethodPost,
+			path:            "/auth/register",
+			body:            gin.H{"email": "not-an-email"},
+			expectCode:      http.StatusBadRequest,
+			expectErrorCode: utils.CodeValidation,
+		},
+		{
+			name:            "Missing Token",
+			method:          http.MethodGet,
+			path:            "/api/customers",
+			expectCode:      http.StatusUnauthorized,
+			expectErrorCode: utils.CodeUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := do(t, router, tt.method, tt.path, "", tt.body)
+			assert.Equal(t, tt.expectCode, status)
+			assert.Equal(t, tt.expectCode, response.Code)
+			assert.Equal(t, tt.expectErrorCode, response.ErrorCode)
+			if tt.expectMsg != "" {
+				assert.Equal(t, tt.expectMsg, response.Message)
+			}
+		})
+	}
+}
+
+func TestApp_ProblemDetails(t *testing.T) {
+	router := newTestApp(t).Router()
+
+	owner := gin.H{"email": "owner@example.com", "password": "Password123"}
+	status, _ := do(t, router, http.MethodPost, "/auth/register", "", owner)
+	require.Equal(t, http.StatusCreated, status)
+
+	tests := []struct {
+		name         string
+		body         interface{}
+		expectStatus int
+		expectCode   string
+		expectField  string
+	}{
+		{name: "Conflict", body: owner, expectStatus: http.StatusConflict, expectCode: utils.CodeConflict},
+		{name: "Validation", body: gin.H{"email": "not-an-email", "password": "Password123"}, expectStatus: http.StatusBadRequest, expectCode: utils.CodeValidation, expectField: "email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", utils.MIMEProblemJSON)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectStatus, w.Code)
+			assert.Equal(t, utils.MIMEProblemJSON, w.Header().Get("Content-Type"))
+
+			var problem utils.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem), w.Body.String())
+			assert.Equal(t, tt.expectStatus, problem.Status)
+			assert.Equal(t, http.StatusText(tt.expectStatus), problem.Title)
+			assert.Equal(t, "/auth/register", problem.Instance)
+			assert.Equal(t, tt.expectCode, problem.ErrorCode)
+			if tt.expectField != "" {
+				require.Len(t, problem.Errors, 1)
+				assert.Equal(t, tt.expectField, problem.Errors[0].Field)
+			}
+		})
+	}
+}
+
+func TestApp_Serve_GracefulShutdown(t *testing.T) {
+	application := newTestApp(t)
+	application.Config.Server.DrainDelay = 300 * time.Millisecond
+
+	// The drain lasts until the test releases it, however long the checks below take
+	draining := make(chan time.Duration, 1)
+	release := make(chan struct{})
+	app.SetDrainWait(application, func(delay time.Duration) {
+		draining <- delay
+		<-release
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "http://" + ln.Addr().String() + "/readyz"
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	readyz := func() int {
+		resp, err := client.Get(url)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- application.Serve(ctx, ln) }()
+
+	// ln is already listening, so the request waits in its backlog until Serve accepts it
+	require.Equal(t, http.StatusOK, readyz())
+
+	cancel()
+	select {
+	case delay := <-draining:
+		assert.Equal(t, 300*time.Millisecond, delay)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not start draining")
+	}
+	assert.False(t, application.Ready())
+	assert.Equal(t, http.StatusServiceUnavailable, readyz(), "readiness fails while draining")
+
+	close(release)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	assert.Equal(t, 0, readyz(), "the listener is closed after draining")
+}
+
+func TestApp_HealthProbes(t *testing.T) {
+	router := newTestApp(t).Router()
+
+	for _, path := range []string{"/livez", "/health"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String(), path)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusOK, report.Status)
+	for _, name := range []string{"database", "migrations"} {
+		assert.Equal(t, health.StatusOK, report.Checks[name].Status, name)
+		assert.True(t, report.Checks[name].Critical, name)
+	}
+}
+
+func TestApp_RequestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	router := newTestAppWithLogger(t, utils.NewLogger(&logs, utils.LogOptions{Format: utils.LogFormatJSON})).Router()
+
+	tests := []struct {
+		name        string
+		requestID   string
+		expectReuse bool
+	}{
+		{name: "Generated", requestID: ""},
+		{name: "Propagated", requestID: "lb-4f1c2a", expectReuse: true},
+		{name: "Malformed Replaced", requestID: "bad id\nlevel=ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/api/customers", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+
+			requestID := w.Header().Get("X-Request-ID")
+			require.NotEmpty(t, requestID)
+			assert.Equal(t, tt.expectReuse, requestID == tt.requestID)
+
+			// The middleware warning and the access log line both carry the request ID
+			var lines []map[string]interface{}
+			decoder := json.NewDecoder(&logs)
+			for decoder.More() {
+				var line map[string]interface{}
+				require.NoError(t, decoder.Decode(&line))
+				lines = append(lines, line)
+			}
+			require.Len(t, lines, 2)
+			assert.Equal(t, "WARN", lines[0]["level"])
+			assert.Equal(t, "JWT Middleware: Missing Authorization header", lines[0]["msg"])
+			assert.Equal(t, "HTTP request", lines[1]["msg"])
+			assert.Equal(t, "/api/customers", lines[1]["route"])
+			assert.EqualValues(t, http.StatusUnauthorized, lines[1]["status"])
+			for _, line := range lines {
+				assert.Equal(t, requestID, line["request_id"])
+			}
+		})
+	}
+}
+
+func TestApp_Metrics(t *testing.T) {
+	application := newTestApp(t)
+	router := application.Router()
+
+	status, _ := do(t, router, http.MethodPost, "/auth/register", "", gin.H{
+		"email": "owner@example.com", "password": "Password123", "organization_name": "Acme",
+	})
+	require.Equal(t, http.StatusCreated, status)
+	status, _ = do(t, router, http.MethodPost, "/auth/login", "", gin.H{"email": "owner@example.com", "password": "Wrong12345"})
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, response := do(t, router, http.MethodPost, "/auth/login", "", gin.H{"email": "owner@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusOK, status)
+	token, _ := response.Data.(map[string]interface{})["token"].(string)
+
+	status, response = do(t, router, http.MethodPost, "/api/customer", token, gin.H{
+		"name": "Budi", "email": "budi@example.com", "phone": "0812-3456-7890",
+	})
+	require.Equal(t, http.StatusCreated, status)
+	id := response.Data.(map[string]interface{})["customer"].(map[string]interface{})["id"]
+	status, _ = do(t, router, http.MethodGet, fmt.Sprintf("/api/customer/%v", id), token, nil)
+	require.Equal(t, http.StatusOK, status)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/no/such/path", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusNotFound, w.Code, "metrics are not served on the public port")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- application.ServeMetrics(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	scraped, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	body := string(scraped)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	for _, line := range []string{
+		`go_backend_http_requests_total{method="POST",route="/auth/login",status="200"} 1`,
+		`go_backend_http_requests_total{method="POST",route="/auth/login",status="401"} 1`,
+		`go_backend_http_requests_total{method="GET",route="/api/customer/:id",status="200"} 1`,
+		`go_backend_http_requests_total{method="GET",route="unmatched",status="404"} 2`, // Including /metrics on the public port
+		`go_backend_http_requests_in_flight{route="/api/customer/:id"} 0`,
+		`go_backend_auth_logins_total{result="failure"} 1`,
+		`go_backend_auth_logins_total{result="success"} 1`,
+		`go_backend_customers_created_total{source="api"} 1`,
+		`go_backend_db_query_duration_seconds_count{operation="create",status="ok",table="customers"} 1`,
+		`go_sql_max_open_connections{db_name="sqlite"} 1`,
+	} {
+		assert.Contains(t, body, line)
+	}
+	assert.NotContains(t, body, "/no/such/path", "raw paths are not used as labels")
+}
+
+func TestApp_Tracing(t *testing.T) {
+	application := newTestApp(t)
+	exporter := tracetest.NewInMemoryExporter()
+	application.Tracer = tracing.NewProviderWithExporter(tracing.Options{ServiceName: "go-backend", SampleRatio: 1}, sdktrace.WithSyncer(exporter))
+	router := application.Router()
+
+	status, _ := do(t, router, http.MethodPost, "/auth/register", "", gin.H{"email": "owner@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusCreated, status)
+	exporter.Reset()
+
+	// Continue the trace of an upstream caller
+	payload, err := json.Marshal(gin.H{"email": "owner@example.com", "password": "Password123"})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), span.Name)
+		byName[span.Name] = span
+	}
+
+	server, ok := byName["POST /auth/login"]
+	require.True(t, ok, "server span")
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+
+	for _, name := range []string{"bcrypt.compare", "gorm.query", "gorm.create"} {
+		span, ok := byName[name]
+		require.True(t, ok, name)
+		assert.Equal(t, server.SpanContext.SpanID(), span.Parent.SpanID(), name)
+	}
+}
@@ -0,0 +1,8 @@
+package app
+
+import "time"
+
+// SetDrainWait replaces how Serve waits out the drain delay, so tests can end the drain on cue
+func SetDrainWait(a *App, wait func(time.Duration)) {
+	a.drainWait = wait
+}
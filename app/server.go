@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/metabbe3/go-backend/utils"
+)
+
+// Ready reports whether the application accepts traffic; it turns false once draining starts
+func (a *App) Ready() bool {
+	return !a.draining.Load()
+}
+
+// Run listens on the configured address and serves until ctx is cancelled, then drains.
+// With metrics enabled, /metrics is served on the internal METRICS_ADDR listener meanwhile.
+// It does not close the application; call Close afterwards.
+func (a *App) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", a.Config.Server.Addr)
+	if err != nil {
+		return err
+	}
+
+	if a.Metrics != nil {
+		metricsLn, err := net.Listen("tcp", a.Config.Metrics.Addr)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("metrics listener: %w", err)
+		}
+		// Not tied to ctx, so scrapes keep working while the main server drains
+		metricsCtx, stopMetrics := context.WithCancel(context.Background())
+		defer stopMetrics()
+		go func() {
+			if err := a.ServeMetrics(metricsCtx, metricsLn); err != nil {
+				utils.Error("Metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	return a.Serve(ctx, ln)
+}
+
+// ServeMetrics serves the Prometheus scrape endpoint, /metrics, on ln until ctx is cancelled.
+// It is meant for an internal listener: nothing else is served there, and nothing requires auth.
+func (a *App) ServeMetrics(ctx context.Context, ln net.Listener) error {
+	if a.Metrics == nil {
+		ln.Close()
+		return errors.New("metrics are disabled")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", a.Metrics.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: a.Config.Server.ReadHeaderTimeout}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+	utils.Info("Metrics server listening", "addr", ln.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	server.Close()
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Serve serves the router on ln until ctx is cancelled. It then fails readiness, waits
+// DrainDelay for load balancers to notice, stops accepting connections and waits up to
+// ShutdownTimeout for in-flight requests before cutting them off.
+func (a *App) Serve(ctx context.Context, ln net.Listener) error {
+	cfg := a.Config.Server
+	server := &http.Server{
+		Handler:           a.Router(),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+	utils.Info("HTTP server listening", "addr", ln.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	a.draining.Store(true)
+	utils.Info("Shutting down, draining", "drain_delay", cfg.DrainDelay.String())
+	if a.drainWait != nil {
+		a.drainWait(cfg.DrainDelay)
+	} else {
+		time.Sleep(cfg.DrainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		utils.Warning("Requests still running at the shutdown timeout were cut off", "shutdown_timeout", cfg.ShutdownTimeout.String(), "error", err)
+		server.Close()
+		return err
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	utils.Info("HTTP server stopped")
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/metabbe3/go-backend/config"
+	"github.com/metabbe3/go-backend/migrations"
+	"github.com/metabbe3/go-backend/repositories"
+	"gorm.io/gorm"
+)
+
+// commands are the maintenance subcommands, run as `go run ./cmd <name> [flags]`
+var commands = map[string]func(args []string){
+	"backfill-phones": backfillPhones,
+	"config":          configCommand,
+	"migrate":         migrate,
+}
+
+// runCommand runs the named subcommand and reports whether one matched
+func runCommand(args []string) bool {
+	command, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+	command(args[1:])
+	return true
+}
+
+// openDB loads the configuration and connects to the database without migrating it
+func openDB() (config.Config, *gorm.DB) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+	return cfg, db
+}
+
+// configCommand inspects the configuration: `config print` writes the effective configuration,
+// merged from all sources, as YAML with secrets redacted, and exits 1 if it is invalid
+func configCommand(args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		log.Fatalf("❌ Usage: config print")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	out, err := cfg.Redacted()
+	if err != nil {
+		log.Fatalf("❌ Failed to render configuration: %v", err)
+	}
+	os.Stdout.Write(out)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "❌ "+err.Error())
+		os.Exit(1)
+	}
+}
+
+// backfillPhones normalizes existing customer phones to E.164 and reports collisions.
+// Run it before deploying the unique phone index: migrations fail while duplicates exist.
+func backfillPhones(args []string) {
+	flags := flag.NewFlagSet("backfill-phones", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report changes without writing them")
+	region := flags.String("region", "", "default region for numbers without a country code (default PHONE_DEFAULT_REGION)")
+	flags.Parse(args)
+
+	cfg, db := openDB()
+	if *region == "" {
+		*region = cfg.PhoneRegion
+	}
+
+	report, err := repositories.BackfillCustomerPhones(context.Background(), db, *region, *dryRun)
+	if err != nil {
+		log.Fatalf("❌ Phone backfill failed: %v", err)
+	}
+
+	for _, issue := range report.Invalid {
+		fmt.Printf("invalid    customer=%d org=%d phone=%q\n", issue.CustomerID, issue.OrganizationID, issue.Phone)
+	}
+	for _, issue := range report.Collisions {
+		fmt.Printf("collision  customer=%d org=%d phone=%q normalized=%s conflicts_with=%d\n",
+			issue.CustomerID, issue.OrganizationID, issue.Phone, issue.Normalized, issue.ConflictsWith)
+	}
+
+	verb := "Updated"
+	if *dryRun {
+		verb = "Would update"
+	}
+	fmt.Printf("Scanned %d customers. %s %d, %d already normalized, %d invalid, %d collisions.\n",
+		report.Scanned, verb, report.Updated, report.Unchanged, len(report.Invalid), len(report.Collisions))
+
+	if len(report.Invalid) > 0 || len(report.Collisions) > 0 {
+		os.Exit(1)
+	}
+}
+
+// migrate runs versioned schema migrations: `migrate up`, `migrate down [steps]` (default 1),
+// `migrate status` or `migrate baseline <version>`, which adopts a database created by
+// AutoMigrate by checking its schema and recording the migrations up to version as applied
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("❌ Usage: migrate up|down [steps]|status|baseline <version>")
+	}
+
+	_, db := openDB()
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("❌ Failed to load migrations: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("✅ Applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("❌ Invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			fmt.Printf("✅ Reverted %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ Migration failed: %v", err)
+		}
+	case "baseline":
+		if len(args) != 2 {
+			log.Fatalf("❌ Usage: migrate baseline <version>")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			log.Fatalf("❌ Invalid version %q", args[1])
+		}
+		adopted, err := migrator.Baseline(version)
+		if err != nil {
+			log.Fatalf("❌ Baseline failed: %v", err)
+		}
+		for _, migration := range adopted {
+			fmt.Printf("✅ Marked %d_%s as applied\n", migration.Version, migration.Name)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("❌ Failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		log.Fatalf("❌ Unknown migrate command %q, expected up, down, status or baseline", args[0])
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/app"
 	"github.com/metabbe3/go-backend/config"
-	"github.com/metabbe3/go-backend/routes"
 )
 
 func runTests() {
@@ -20,15 +24,22 @@ func runTests() {
 }
 
 // InitializeApp sets up the application for running and testing.
-func InitializeApp() (*gin.Engine, error) {
+func InitializeApp() (*app.App, error) {
 	fmt.Println("🚀 Starting application...")
 
 	fmt.Println("🚀 Testing Functions...")
 	runTests()
 
-	// Initialize the database
-	if err := config.ConnectDB(); err != nil {
-		log.Printf("❌ Failed to connect to database: %v", err)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("❌ Failed to load configuration: %v", err)
+		return nil, err
+	}
+
+	// Validate the configuration, connect the database and wire the application
+	application, err := app.New(cfg)
+	if err != nil {
+		log.Printf("❌ Failed to start application: %v", err)
 		return nil, err
 	}
 
@@ -37,28 +48,45 @@ func InitializeApp() (*gin.Engine, error) {
 	// Set Gin to release mode for production
 	gin.SetMode(gin.ReleaseMode)
 
-	// Initialize Gin router
-	r := gin.Default()
-
-	// Trust only localhost as a proxy (adjust if needed)
-	r.SetTrustedProxies([]string{"127.0.0.1"})
-
-	// Setup routes
-	routes.SetupRoutes(r)
-
-	return r, nil
+	return application, nil
 }
 
 func main() {
-	r, err := InitializeApp()
+	if len(os.Args) > 1 {
+		if !runCommand(os.Args[1:]) {
+			log.Fatalf("❌ Unknown command %q", os.Args[1])
+		}
+		return
+	}
+
+	application, err := InitializeApp()
 	if err != nil {
 		log.Fatalf("❌ Application initialization failed: %v", err)
 	}
 
-	// Start the server
-	port := ":8080"
-	fmt.Println("🌍 Server running on http://localhost" + port)
-	if err := r.Run(port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	// Serve until SIGINT or SIGTERM, then drain in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Reopen the log file on SIGHUP, after an external logrotate has moved it away
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	go func() {
+		for range hangup {
+			if err := application.Logger.Reopen(); err != nil {
+				log.Printf("❌ Failed to reopen log file: %v", err)
+			}
+		}
+	}()
+
+	fmt.Println("🌍 Server running on " + application.Config.Server.Addr)
+	runErr := application.Run(ctx)
+	if err := application.Close(); err != nil {
+		log.Printf("❌ Shutdown cleanup failed: %v", err)
+	}
+	if runErr != nil {
+		log.Fatalf("❌ Server stopped with error: %v", runErr)
 	}
+	fmt.Println("👋 Server stopped")
 }
@@ -0,0 +1,436 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/tracing"
+	"github.com/metabbe3/go-backend/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed application configuration an App is built from.
+//
+// Every field can be set in the YAML file under its yaml key and overridden by the
+// environment variable in its env tag; default tags hold the built-in defaults, and
+// fields tagged secret are redacted when the configuration is printed.
+type Config struct {
+	Env             string         `yaml:"env" env:"APP_ENV" default:"development"` // development, test or production
+	PhoneRegion     string         `yaml:"phone_region" env:"PHONE_DEFAULT_REGION" default:"ID"`
+	AdminEmail      string         `yaml:"admin_email" env:"ADMIN_EMAIL"`
+	RevocationStore string         `yaml:"revocation_store" env:"TOKEN_REVOCATION_STORE" default:"db"`
+	Log             LogConfig      `yaml:"log"`
+	Server          ServerConfig   `yaml:"server"`
+	Health          HealthConfig   `yaml:"health"`
+	Metrics         MetricsConfig  `yaml:"metrics"`
+	Tracing         TracingConfig  `yaml:"tracing"`
+	Auth            AuthConfig     `yaml:"auth"`
+	Database        DatabaseConfig `yaml:"database"`
+	WhatsApp        WhatsAppConfig `yaml:"whatsapp"`
+}
+
+// LogConfig configures the application log
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" default:"info"`   // debug, info, warn or error
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"` // json or logfmt
+	File   string `yaml:"file" env:"LOG_FILE"`                    // Written to stdout when empty
+
+	// Rotation of LOG_FILE; a zero value disables each rule
+	MaxSizeMB      uint          `yaml:"max_size_mb" env:"LOG_MAX_SIZE_MB" default:"100"`
+	RotateInterval time.Duration `yaml:"rotate_interval" env:"LOG_ROTATE_INTERVAL" default:"24h"` // Rotates at each multiple, in UTC
+	Compress       bool          `yaml:"compress" env:"LOG_COMPRESS" default:"true"`
+	MaxBackups     uint          `yaml:"max_backups" env:"LOG_MAX_BACKUPS" default:"14"`
+	MaxAge         time.Duration `yaml:"max_age" env:"LOG_MAX_AGE" default:"720h"`
+}
+
+// ServerConfig configures the HTTP server and its shutdown
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" env:"HTTP_ADDR" default:":8080"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"10s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"1m"`   // Not applied to customer imports and exports, see DB_BULK_REQUEST_TIMEOUT
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"2m"` // Not applied to customer imports and exports, see DB_BULK_REQUEST_TIMEOUT
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"2m"`
+	DrainDelay        time.Duration `yaml:"drain_delay" env:"HTTP_DRAIN_DELAY" default:"0s"`            // Time between failing readiness and closing the listener, so load balancers stop routing first
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" default:"30s"` // Deadline for in-flight requests to finish
+	ErrorFormat       string        `yaml:"error_format" env:"HTTP_ERROR_FORMAT" default:"envelope"`    // envelope or problem (RFC 7807), for clients whose Accept header asks for neither
+}
+
+// HealthConfig tunes the dependency checks of the readiness probe
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	CacheTTL     time.Duration `yaml:"cache_ttl" env:"HEALTH_CACHE_TTL" default:"2s"`       // How long a report is reused between probes
+	ProviderTTL  time.Duration `yaml:"provider_ttl" env:"HEALTH_PROVIDER_TTL" default:"1m"` // How long the messaging provider's result is reused, sparing its rate limit
+}
+
+// MetricsConfig controls the Prometheus endpoint. It is served on a listener of its own,
+// loopback-only by default, so scrapes never go through the public port.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED" default:"true"`     // Serve /metrics and instrument requests and queries
+	Addr    string `yaml:"addr" env:"METRICS_ADDR" default:"127.0.0.1:9090"` // Internal listener for /metrics; bind it to a private interface to scrape from other hosts
+}
+
+// TracingConfig configures OpenTelemetry tracing, under the standard OTEL_* variable names
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none"` // none or otlp (OTLP over HTTP)
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`         // e.g. http://localhost:4318
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"go-backend"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1"` // Fraction of new traces recorded
+}
+
+// AuthConfig holds the token signing keys and lifetimes
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	CursorSecret    string        `yaml:"cursor_secret" env:"CURSOR_SECRET" secret:"true"` // Defaults to the JWT secret
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" default:"720h"`
+}
+
+// DatabaseConfig selects and configures the database
+type DatabaseConfig struct {
+	Driver         string `yaml:"driver" env:"DB_DRIVER" default:"mysql"` // mysql, postgres or sqlite
+	DSN            string `yaml:"dsn" env:"DB_DSN" secret:"true"`         // Used as is when set; otherwise built from the fields below
+	Host           string `yaml:"host" env:"DB_HOST" default:"localhost"`
+	Port           string `yaml:"port" env:"DB_PORT"` // Defaults to the driver's port
+	User           string `yaml:"user" env:"DB_USER"` // Defaults to the driver's superuser
+	Password       string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name           string `yaml:"name" env:"DB_NAME" default:"mydatabase"`
+	Path           string `yaml:"path" env:"DB_PATH" default:"app.db"`                // SQLite database file
+	Charset        string `yaml:"charset" env:"DB_CHARSET" default:"utf8mb4"`         // MySQL only
+	ParseTime      string `yaml:"parse_time" env:"DB_PARSE_TIME" default:"True"`      // MySQL only
+	Location       string `yaml:"location" env:"DB_LOC" default:"Local"`              // MySQL only
+	SSLMode        string `yaml:"ssl_mode" env:"DB_SSLMODE" default:"disable"`        // PostgreSQL only
+	TimeZone       string `yaml:"time_zone" env:"DB_TIMEZONE" default:"UTC"`          // PostgreSQL only
+	AutoMigrate    bool   `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE" default:"false"` // Sync the schema from the models instead of running migrations (development only)
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START" default:"true"`
+
+	RequestTimeout     time.Duration `yaml:"request_timeout" env:"DB_REQUEST_TIMEOUT" default:"10s"`          // Deadline for the queries of one API request; 0 disables it
+	BulkRequestTimeout time.Duration `yaml:"bulk_request_timeout" env:"DB_BULK_REQUEST_TIMEOUT" default:"2m"` // Replaces it for customer imports and exports, and bounds their whole transfer: set it above the longest export, which is cut off when it expires
+}
+
+// WhatsAppConfig holds the WhatsApp Cloud API credentials and webhook settings.
+// Messaging is disabled without a phone number ID and access token.
+type WhatsAppConfig struct {
+	PhoneNumberID   string `yaml:"phone_number_id" env:"WHATSAPP_PHONE_NUMBER_ID"`
+	AccessToken     string `yaml:"access_token" env:"WHATSAPP_ACCESS_TOKEN" secret:"true"`
+	APIURL          string `yaml:"api_url" env:"WHATSAPP_API_URL"`
+	APIVersion      string `yaml:"api_version" env:"WHATSAPP_API_VERSION"`
+	AppSecret       string `yaml:"app_secret" env:"WHATSAPP_APP_SECRET" secret:"true"`     // Verifies webhook signatures
+	VerifyToken     string `yaml:"verify_token" env:"WHATSAPP_VERIFY_TOKEN" secret:"true"` // Answers the webhook subscription handshake
+	OrganizationID  uint   `yaml:"organization_id" env:"WHATSAPP_ORGANIZATION_ID"`         // Organization inbound messages are filed under
+	AutoCreateLeads bool   `yaml:"auto_create_leads" env:"WHATSAPP_AUTO_CREATE_LEADS"`     // Create a customer for messages from unknown numbers
+}
+
+// Deployment environments
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// Revocation store backends
+const (
+	RevocationStoreDB     = "db"
+	RevocationStoreMemory = "memory"
+)
+
+// MinProductionSecretLength is the shortest JWT secret accepted in production (256 bits for HS256)
+const MinProductionSecretLength = 32
+
+// DefaultConfigFile is the YAML file read when CONFIG_FILE is not set, if it exists
+const DefaultConfigFile = "config.yaml"
+
+// Load builds the configuration from, in increasing order of precedence: the built-in
+// defaults, the YAML file named by CONFIG_FILE (config.yaml if present), the .env file
+// and the process environment. It does not validate the result; call Validate.
+func Load() (Config, error) {
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("read .env: %w", err)
+	}
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := dotenv[key]
+		return value, ok
+	}
+
+	path, explicit := lookup("CONFIG_FILE")
+	if !explicit {
+		path = DefaultConfigFile
+	}
+	return load(path, explicit, lookup)
+}
+
+// Default returns the built-in configuration, without reading any file or variable
+func Default() Config {
+	var cfg Config
+	if err := cfg.applyTagDefaults(); err != nil {
+		panic(fmt.Sprintf("config: %v", err))
+	}
+	cfg.applyDerivedDefaults()
+	return cfg
+}
+
+// load applies the defaults, the YAML file at path and the variables found by lookup.
+// A missing file is only an error when it was asked for explicitly.
+func load(path string, required bool, lookup func(string) (string, bool)) (Config, error) {
+	var cfg Config
+	if err := cfg.applyTagDefaults(); err != nil {
+		return cfg, err
+	}
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(content, &cfg); err != nil {
+				return cfg, fmt.Errorf("parse %s: %w", path, err)
+			}
+		case required || !errors.Is(err, os.ErrNotExist):
+			return cfg, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	if err := walk(&cfg, func(field reflect.StructField, value reflect.Value) error {
+		key := field.Tag.Get("env")
+		raw, ok := lookup(key)
+		if key == "" || !ok {
+			return nil
+		}
+		if err := setField(value, raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return nil
+	}); err != nil {
+		return cfg, err
+	}
+
+	cfg.applyDerivedDefaults()
+	return cfg, nil
+}
+
+// applyTagDefaults sets every field that has a default tag
+func (cfg *Config) applyTagDefaults() error {
+	return walk(cfg, func(field reflect.StructField, value reflect.Value) error {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := setField(value, def); err != nil {
+				return fmt.Errorf("invalid default for %s: %w", field.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// applyDerivedDefaults fills in defaults that depend on other settings
+func (cfg *Config) applyDerivedDefaults() {
+	db := &cfg.Database
+	if db.Port == "" {
+		db.Port = map[string]string{DriverMySQL: "3306", DriverPostgres: "5432"}[db.Driver]
+	}
+	if db.User == "" {
+		db.User = map[string]string{DriverMySQL: "root", DriverPostgres: "postgres"}[db.Driver]
+	}
+
+	if cfg.WhatsApp.APIURL == "" {
+		cfg.WhatsApp.APIURL = messaging.DefaultWhatsAppBaseURL
+	}
+	if cfg.WhatsApp.APIVersion == "" {
+		cfg.WhatsApp.APIVersion = messaging.DefaultWhatsAppAPIVersion
+	}
+}
+
+// Validate reports every setting that is invalid or unsafe for the environment
+func (cfg Config) Validate() error {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	production := cfg.Env == EnvProduction
+	switch cfg.Env {
+	case EnvDevelopment, EnvTest, EnvProduction:
+	default:
+		fail("APP_ENV must be one of %s, %s, %s, got %q", EnvDevelopment, EnvTest, EnvProduction, cfg.Env)
+	}
+
+	if _, err := utils.ParseLogLevel(cfg.Log.Level); err != nil {
+		fail("LOG_LEVEL must be one of debug, info, warn, error, got %q", cfg.Log.Level)
+	}
+	if cfg.Log.Format != utils.LogFormatJSON && cfg.Log.Format != utils.LogFormatLogfmt {
+		fail("LOG_FORMAT must be %s or %s, got %q", utils.LogFormatJSON, utils.LogFormatLogfmt, cfg.Log.Format)
+	}
+	if cfg.Log.RotateInterval < 0 || cfg.Log.MaxAge < 0 {
+		fail("LOG_ROTATE_INTERVAL and LOG_MAX_AGE must not be negative")
+	}
+
+	if cfg.Server.Addr == "" {
+		fail("HTTP_ADDR is required")
+	}
+	server := cfg.Server
+	if server.ReadHeaderTimeout < 0 || server.ReadTimeout < 0 || server.WriteTimeout < 0 || server.IdleTimeout < 0 || server.DrainDelay < 0 {
+		fail("HTTP timeouts must not be negative")
+	}
+	if server.ShutdownTimeout <= 0 {
+		fail("HTTP_SHUTDOWN_TIMEOUT must be positive")
+	}
+	if server.ErrorFormat != utils.ErrorFormatEnvelope && server.ErrorFormat != utils.ErrorFormatProblem {
+		fail("HTTP_ERROR_FORMAT must be %s or %s, got %q", utils.ErrorFormatEnvelope, utils.ErrorFormatProblem, server.ErrorFormat)
+	}
+	if cfg.Health.CheckTimeout <= 0 || cfg.Health.CacheTTL < 0 || cfg.Health.ProviderTTL < 0 {
+		fail("HEALTH_CHECK_TIMEOUT must be positive, HEALTH_CACHE_TTL and HEALTH_PROVIDER_TTL must not be negative")
+	}
+	if cfg.Metrics.Enabled && (cfg.Metrics.Addr == "" || cfg.Metrics.Addr == server.Addr) {
+		fail("METRICS_ADDR must be set and differ from HTTP_ADDR, metrics are not served on the public port")
+	}
+	if cfg.Tracing.Exporter != tracing.ExporterNone && cfg.Tracing.Exporter != tracing.ExporterOTLP {
+		fail("OTEL_TRACES_EXPORTER must be %s or %s, got %q", tracing.ExporterNone, tracing.ExporterOTLP, cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		fail("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	}
+	if cfg.RevocationStore != RevocationStoreDB && cfg.RevocationStore != RevocationStoreMemory {
+		fail("TOKEN_REVOCATION_STORE must be %s or %s, got %q", RevocationStoreDB, RevocationStoreMemory, cfg.RevocationStore)
+	}
+	if !utils.IsKnownPhoneRegion(cfg.PhoneRegion) {
+		fail("PHONE_DEFAULT_REGION %q is not a known region", cfg.PhoneRegion)
+	}
+
+	switch {
+	case cfg.Auth.JWTSecret == "":
+		fail("JWT_SECRET is required")
+	case production && len(cfg.Auth.JWTSecret) < MinProductionSecretLength:
+		fail("JWT_SECRET must be at least %d bytes in production", MinProductionSecretLength)
+	}
+	if cfg.Auth.AccessTokenTTL <= 0 || cfg.Auth.RefreshTokenTTL <= 0 {
+		fail("ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL must be positive")
+	}
+
+	db := cfg.Database
+	switch db.Driver {
+	case DriverMySQL, DriverPostgres, DriverSQLite:
+	default:
+		fail("DB_DRIVER must be one of %s, %s, %s, got %q", DriverMySQL, DriverPostgres, DriverSQLite, db.Driver)
+	}
+	if production && db.AutoMigrate {
+		fail("DB_AUTO_MIGRATE must not be enabled in production")
+	}
+	if production && db.DSN == "" && db.Driver != DriverSQLite && db.Password == "" {
+		fail("DB_PASSWORD is required in production")
+	}
+	if db.RequestTimeout < 0 || db.BulkRequestTimeout < 0 {
+		fail("DB_REQUEST_TIMEOUT and DB_BULK_REQUEST_TIMEOUT must not be negative")
+	}
+
+	if (cfg.WhatsApp.AppSecret != "") != (cfg.WhatsApp.OrganizationID != 0) {
+		fail("WHATSAPP_APP_SECRET and WHATSAPP_ORGANIZATION_ID must be set together")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// Redacted returns the configuration as YAML, annotated with the environment variable of
+// each setting, with secrets replaced by a placeholder
+func (cfg Config) Redacted() ([]byte, error) {
+	var node func(v reflect.Value) *yaml.Node
+	node = func(v reflect.Value) *yaml.Node {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field, value := t.Field(i), v.Field(i)
+			key := &yaml.Node{Kind: yaml.ScalarNode, Value: field.Tag.Get("yaml")}
+
+			if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Duration(0)) {
+				mapping.Content = append(mapping.Content, key, node(value))
+				continue
+			}
+
+			text := fmt.Sprint(value.Interface())
+			if field.Tag.Get("secret") == "true" && text != "" {
+				text = "[redacted]"
+			}
+			scalar := &yaml.Node{Kind: yaml.ScalarNode, Value: text, LineComment: field.Tag.Get("env")}
+			if value.Kind() == reflect.String {
+				scalar.Style = yaml.DoubleQuotedStyle
+			}
+			mapping.Content = append(mapping.Content, key, scalar)
+		}
+		return mapping
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node(reflect.ValueOf(cfg))); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), encoder.Close()
+}
+
+// walk calls fn for every leaf field of the struct pointed to by cfg
+func walk(cfg interface{}, fn func(reflect.StructField, reflect.Value) error) error {
+	var visit func(v reflect.Value) error
+	visit = func(v reflect.Value) error {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field, value := t.Field(i), v.Field(i)
+			if value.Kind() == reflect.Struct {
+				if err := visit(value); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fn(field, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(reflect.ValueOf(cfg).Elem())
+}
+
+// setField parses raw into a string, bool, unsigned integer, float or duration field
+func setField(value reflect.Value, raw string) error {
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case value.Kind() == reflect.Uint:
+		n, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported config field type %s", value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envLookup returns a lookup over a fixed set of variables
+func envLookup(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+env: production
+auth:
+  jwt_secret: from-yaml
+  access_token_ttl: 5m
+database:
+  driver: postgres
+  host: db.internal
+  name: crm
+`), 0600))
+
+	cfg, err := load(path, true, envLookup(map[string]string{
+		"DB_HOST":                  "db.override",
+		"WHATSAPP_ORGANIZATION_ID": "7",
+		"DB_MIGRATE_ON_START":      "false",
+		"OTEL_TRACES_SAMPLER_ARG":  "0.25",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, EnvProduction, cfg.Env, "YAML overrides defaults")
+	assert.Equal(t, "from-yaml", cfg.Auth.JWTSecret)
+	assert.Equal(t, 5*time.Minute, cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, 720*time.Hour, cfg.Auth.RefreshTokenTTL, "defaults fill what YAML leaves out")
+	assert.Equal(t, "db.override", cfg.Database.Host, "environment overrides YAML")
+	assert.Equal(t, "crm", cfg.Database.Name)
+	assert.Equal(t, "5432", cfg.Database.Port, "port defaults to the driver's")
+	assert.Equal(t, "postgres", cfg.Database.User)
+	assert.False(t, cfg.Database.MigrateOnStart)
+	assert.Equal(t, uint(7), cfg.WhatsApp.OrganizationID)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	_, err := load(filepath.Join(t.TempDir(), "missing.yaml"), true, envLookup(nil))
+	assert.ErrorContains(t, err, "read config file")
+
+	cfg, err := load(filepath.Join(t.TempDir(), "missing.yaml"), false, envLookup(nil))
+	require.NoError(t, err, "the default file is optional")
+	assert.Equal(t, Default(), cfg)
+
+	_, err = load("", false, envLookup(map[string]string{"ACCESS_TOKEN_TTL": "soon"}))
+	assert.ErrorContains(t, err, "invalid ACCESS_TOKEN_TTL")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() Config {
+		cfg := Default()
+		cfg.Auth.JWTSecret = strings.Repeat("s", MinProductionSecretLength)
+		cfg.Database.Password = "hunter2"
+		return cfg
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(cfg *Config)
+		expectErr string
+	}{
+		{
+			name:   "Success - Development",
+			modify: func(cfg *Config) { cfg.Auth.JWTSecret = "short"; cfg.Database.Password = "" },
+		},
+		{
+			name:   "Success - Production",
+			modify: func(cfg *Config) { cfg.Env = EnvProduction },
+		},
+		{
+			name:      "Failure - Missing JWT Secret",
+			modify:    func(cfg *Config) { cfg.Auth.JWTSecret = "" },
+			expectErr: "JWT_SECRET is required",
+		},
+		{
+			name:      "Failure - Short JWT Secret In Production",
+			modify:    func(cfg *Config) { cfg.Env = EnvProduction; cfg.Auth.JWTSecret = "short" },
+			expectErr: "JWT_SECRET must be at least 32 bytes in production",
+		},
+		{
+			name:      "Failure - Auto Migrate In Production",
+			modify:    func(cfg *Config) { cfg.Env = EnvProduction; cfg.Database.AutoMigrate = true },
+			expectErr: "DB_AUTO_MIGRATE must not be enabled in production",
+		},
+		{
+			name:      "Failure - Unknown Values",
+			modify:    func(cfg *Config) { cfg.Env = "staging"; cfg.Database.Driver = "oracle"; cfg.PhoneRegion = "XX" },
+			expectErr: "APP_ENV must be one of",
+		},
+		{
+			name:      "Failure - Unknown Log Level",
+			modify:    func(cfg *Config) { cfg.Log.Level = "verbose" },
+			expectErr: `LOG_LEVEL must be one of debug, info, warn, error, got "verbose"`,
+		},
+		{
+			name:      "Failure - Unknown Log Format",
+			modify:    func(cfg *Config) { cfg.Log.Format = "xml" },
+			expectErr: `LOG_FORMAT must be json or logfmt, got "xml"`,
+		},
+		{
+			name:      "Failure - Metrics On The Public Port",
+			modify:    func(cfg *Config) { cfg.Metrics.Addr = cfg.Server.Addr },
+			expectErr: "METRICS_ADDR must be set and differ from HTTP_ADDR",
+		},
+		{
+			name:   "Success - Metrics Disabled Without Address",
+			modify: func(cfg *Config) { cfg.Metrics.Enabled = false; cfg.Metrics.Addr = "" },
+		},
+		{
+			name:      "Failure - Tracing Settings",
+			modify:    func(cfg *Config) { cfg.Tracing.Exporter = "jaeger"; cfg.Tracing.SampleRatio = 1.5 },
+			expectErr: `OTEL_TRACES_EXPORTER must be none or otlp, got "jaeger"`,
+		},
+		{
+			name:      "Failure - Negative Log Retention",
+			modify:    func(cfg *Config) { cfg.Log.MaxAge = -time.Hour },
+			expectErr: "LOG_ROTATE_INTERVAL and LOG_MAX_AGE must not be negative",
+		},
+		{
+			name:      "Failure - Unknown Error Format",
+			modify:    func(cfg *Config) { cfg.Server.ErrorFormat = "xml" },
+			expectErr: `HTTP_ERROR_FORMAT must be envelope or problem, got "xml"`,
+		},
+		{
+			name:      "Failure - Negative DB Timeout",
+			modify:    func(cfg *Config) { cfg.Database.RequestTimeout = -time.Second },
+			expectErr: "DB_REQUEST_TIMEOUT and DB_BULK_REQUEST_TIMEOUT must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.expectErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectErr)
+		})
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWTSecret = "super-secret-key"
+	cfg.Database.Password = "hunter2"
+
+	out, err := cfg.Redacted()
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(out), "super-secret-key")
+	assert.NotContains(t, string(out), "hunter2")
+	assert.Contains(t, string(out), `jwt_secret: "[redacted]" # JWT_SECRET`)
+	assert.Contains(t, string(out), `cursor_secret: "" # CURSOR_SECRET`, "unset secrets show as empty")
+	assert.Contains(t, string(out), "access_token_ttl: 15m0s # ACCESS_TOKEN_TTL")
+}
@@ -1,71 +1,76 @@
 package config
 
 import (
-	"fmt"
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"github.com/metabbe3/go-backend/migrations"
 	"github.com/metabbe3/go-backend/models"
 	"github.com/metabbe3/go-backend/utils"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// OpenDB opens the database connection without touching the schema,
+// for maintenance commands that must run before migrations can succeed
+func OpenDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	utils.Info("Initializing database connection", "driver", cfg.Driver)
 
-// Load environment variables
-func loadEnv() {
-	if err := godotenv.Load(); err != nil {
-		utils.Warning("No .env file found, using default values")
+	dialector, err := NewDialector(cfg)
+	if err != nil {
+		utils.Error("Invalid database configuration", "error", err)
+		return nil, err
 	}
-}
 
-// GetEnv gets environment variables with a default fallback
-func GetEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		utils.Error("Database connection failed", "driver", cfg.Driver, "error", err)
+		return nil, err // 🔹 Return the error instead of logging fatal
 	}
-	return defaultValue
-}
 
-// ConnectDB initializes the database connection
-func ConnectDB() error { // 🔹 Change function to return error
-	utils.InitLogger()
-	loadEnv()
+	utils.Info("Connected to database", "driver", cfg.Driver)
+	return db, nil
+}
 
-	utils.Info("Initializing database connection...")
+// MigrateDB brings the schema up to date: with versioned migrations by default, or with
+// GORM's AutoMigrate when cfg.AutoMigrate is set
+func MigrateDB(db *gorm.DB, cfg DatabaseConfig) error {
+	if cfg.AutoMigrate {
+		return autoMigrate(db)
+	}
+	if cfg.MigrateOnStart {
+		return runMigrations(db)
+	}
+	return nil
+}
 
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%s&loc=%s",
-		GetEnv("DB_USER", "root"),
-		GetEnv("DB_PASSWORD", "secret"),
-		GetEnv("DB_HOST", "localhost"),
-		GetEnv("DB_PORT", "3306"),
-		GetEnv("DB_NAME", "mydatabase"),
-		GetEnv("DB_CHARSET", "utf8mb4"),
-		GetEnv("DB_PARSE_TIME", "True"),
-		GetEnv("DB_LOC", "Local"),
-	)
+// runMigrations applies pending versioned migrations; concurrent instances wait on a lock
+func runMigrations(db *gorm.DB) error {
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		utils.Error("Loading migrations failed", "error", err)
+		return err
+	}
 
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	applied, err := migrator.Up()
 	if err != nil {
-		utils.Error(fmt.Sprintf("Database connection failed: %v", err))
-		return err // 🔹 Return the error instead of logging fatal
+		utils.Error("Database migration failed", "error", err)
+		return err
 	}
 
-	utils.Info("Connected to MySQL database successfully!")
-	autoMigrate()
-	return nil // ✅ Return nil on success
+	for _, migration := range applied {
+		utils.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	utils.Info("Database schema is up to date", "version", migrator.Latest())
+	return nil
 }
 
-// autoMigrate runs migrations for models
-func autoMigrate() {
-	err := DB.AutoMigrate(&models.User{}, &models.Customer{}) // Add more models as needed
+// autoMigrate syncs the schema from the models with GORM's AutoMigrate. It is a development
+// shortcut enabled by DB_AUTO_MIGRATE=true; it can't drop or rename columns, so schema
+// changes must still ship as versioned migrations.
+func autoMigrate(db *gorm.DB) error {
+	utils.Warning("DB_AUTO_MIGRATE is enabled, syncing schema from models instead of running migrations")
+	err := db.AutoMigrate(&models.User{}, &models.Customer{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.UserTokenRevocation{}, &models.Role{}, &models.Permission{}, &models.Organization{}, &models.OrganizationMember{}, &models.OrganizationInvitation{}, &models.Message{}) // Add more models as needed
 	if err != nil {
-		utils.Error(fmt.Sprintf("Auto migration failed: %v", err))
-		log.Fatalf("Failed to migrate database: %v", err)
+		utils.Error("Auto migration failed", "error", err)
+		return err
 	}
-	utils.Info("Database migration completed!")
+	utils.Info("Database migration completed")
+	return nil
 }
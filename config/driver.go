@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Supported values of DB_DRIVER
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// NewDialector returns the GORM dialector for the configured driver. cfg.DSN is used as is
+// when set; otherwise the DSN is built from the connection fields.
+func NewDialector(cfg DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverMySQL:
+		if cfg.DSN != "" {
+			return mysql.Open(cfg.DSN), nil
+		}
+		return mysql.Open(mysqlDSN(cfg)), nil
+	case DriverPostgres:
+		if cfg.DSN != "" {
+			return postgres.Open(cfg.DSN), nil
+		}
+		return postgres.Open(postgresDSN(cfg)), nil
+	case DriverSQLite:
+		if cfg.DSN != "" {
+			return sqlite.Open(cfg.DSN), nil
+		}
+		return sqlite.Open(sqliteDSN(cfg.Path)), nil
+	}
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q, expected %s, %s or %s", cfg.Driver, DriverMySQL, DriverPostgres, DriverSQLite)
+}
+
+// mysqlDSN builds a go-sql-driver/mysql DSN
+func mysqlDSN(cfg DatabaseConfig) string {
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%s&loc=%s",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name, cfg.Charset, cfg.ParseTime, cfg.Location,
+	)
+}
+
+// postgresDSN builds a pgx URL; credentials are escaped so any password works
+func postgresDSN(cfg DatabaseConfig) string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   cfg.Host + ":" + cfg.Port,
+		Path:   cfg.Name,
+	}
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	query.Set("TimeZone", cfg.TimeZone)
+	dsn.RawQuery = query.Encode()
+	return dsn.String()
+}
+
+// sqliteDSN opens a SQLite file (or ":memory:") with foreign keys enforced and a busy
+// timeout so concurrent writers wait instead of failing
+func sqliteDSN(path string) string {
+	return path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
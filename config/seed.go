@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+	"gorm.io/gorm"
+)
+
+// SeedDB creates the built-in roles and their permissions if they are missing,
+// and promotes the user with adminEmail (if any) to admin so roles can be managed
+func SeedDB(db *gorm.DB, adminEmail string) error {
+	ctx := context.Background()
+	if err := repositories.NewRoleRepository(db).SeedRoles(ctx, utils.DefaultRolePermissions); err != nil {
+		utils.Error("Role seeding failed", "error", err)
+		return err
+	}
+	utils.Info("Default roles seeded")
+
+	if adminEmail == "" {
+		return nil
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	user, err := userRepo.FindByEmail(ctx, adminEmail)
+	if errors.Is(err, utils.ErrNotFound) {
+		utils.Warning("ADMIN_EMAIL user not found, skipping admin bootstrap", "email", adminEmail)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("look up ADMIN_EMAIL user: %w", err)
+	}
+	if user.Role == utils.RoleAdmin {
+		return nil
+	}
+
+	user.Role = utils.RoleAdmin
+	if err := userRepo.UpdateUser(ctx, user); err != nil {
+		utils.Error("Failed to promote user to admin", "email", adminEmail, "error", err)
+		return err
+	}
+	utils.Info("Promoted user to admin", "email", adminEmail)
+	return nil
+}
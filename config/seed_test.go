@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSeedDB(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminEmail string
+		readOnly   bool // Make updates of users fail
+		expectErr  string
+		expectRole string
+	}{
+		{name: "Success - Promotes Admin", adminEmail: "budi@example.com", expectRole: utils.RoleAdmin},
+		{name: "Success - Unknown Admin Email", adminEmail: "siti@example.com", expectRole: utils.RoleUser},
+		{name: "Success - No Admin Email", expectRole: utils.RoleUser},
+		{name: "Failure - Promotion Fails", adminEmail: "budi@example.com", readOnly: true, expectErr: "users are read-only", expectRole: utils.RoleUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialector, err := NewDialector(DatabaseConfig{Driver: DriverSQLite, DSN: ":memory:"})
+			require.NoError(t, err)
+			db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+			sqlDB.SetMaxOpenConns(1)
+			t.Cleanup(func() { sqlDB.Close() })
+
+			require.NoError(t, MigrateDB(db, DatabaseConfig{AutoMigrate: true}))
+			require.NoError(t, db.Create(&models.User{Name: "Budi", Email: "budi@example.com", Password: "x", Role: utils.RoleUser}).Error)
+			if tt.readOnly {
+				require.NoError(t, db.Exec("CREATE TRIGGER users_read_only BEFORE UPDATE ON users BEGIN SELECT RAISE(ABORT, 'users are read-only'); END").Error)
+			}
+
+			err = SeedDB(db, tt.adminEmail)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var user models.User
+			require.NoError(t, db.Where("email = ?", "budi@example.com").First(&user).Error)
+			assert.Equal(t, tt.expectRole, user.Role)
+		})
+	}
+}
@@ -1,40 +1,55 @@
 package controllers
 
 import (
-	"strings"
+	"context"
+	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/metrics"
 	"github.com/metabbe3/go-backend/models"
 	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/tracing"
 	"github.com/metabbe3/go-backend/utils"
 )
 
 // Change from `*repositories.UserRepository` to `repositories.UserRepositoryInterface`
 type AuthController struct {
-	UserRepo repositories.UserRepositoryInterface
-	Hasher   utils.PasswordHasher // Use an interface instead of direct utils.HashPassword call
+	UserRepo    repositories.UserRepositoryInterface
+	OrgRepo     repositories.OrganizationRepositoryInterface
+	RefreshRepo repositories.RefreshTokenRepositoryInterface
+	Revocations utils.RevocationStore
+	Hasher      utils.PasswordHasher // Use an interface instead of direct utils.HashPassword call
+	Metrics     *metrics.Metrics     // Counts logins; nil records nothing
 }
 
+// errNotMember is returned when a user asks for a token scoped to an organization they don't belong to
+var errNotMember = errors.New("user is not a member of the organization")
+
 // Change `*repositories.UserRepository` to `repositories.UserRepositoryInterface`
-func NewAuthController(userRepo repositories.UserRepositoryInterface, hasher utils.PasswordHasher) *AuthController {
-	return &AuthController{UserRepo: userRepo, Hasher: hasher}
+func NewAuthController(userRepo repositories.UserRepositoryInterface, orgRepo repositories.OrganizationRepositoryInterface, refreshRepo repositories.RefreshTokenRepositoryInterface, revocations utils.RevocationStore, hasher utils.PasswordHasher) *AuthController {
+	return &AuthController{UserRepo: userRepo, OrgRepo: orgRepo, RefreshRepo: refreshRepo, Revocations: revocations, Hasher: hasher}
 }
 
-// RegisterUser handles user registration
+// RegisterUser handles user registration.
+// If an organization name is given, a new organization is created with the user as its owner.
 func (ctrl *AuthController) RegisterUser(c *gin.Context) {
 	var req struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=8"`
+		Email            string `json:"email" binding:"required,email"`
+		Password         string `json:"password" binding:"required,password"`
+		OrganizationName string `json:"organization_name"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, "Invalid request data", err.Error())
+		utils.SendValidationError(c, "Invalid request data", err)
 		return
 	}
 
+	_, span := tracing.Start(c.Request.Context(), "bcrypt.hash")
 	hashedPassword, err := ctrl.Hasher.HashPassword(req.Password)
+	span.End()
 	if err != nil {
-		utils.SendInternalServerError(c, "Failed to hash password")
+		c.Error(utils.Internal(err, "Failed to hash password"))
 		return
 	}
 
@@ -43,91 +58,375 @@ func (ctrl *AuthController) RegisterUser(c *gin.Context) {
 		Password: hashedPassword,
 	}
 
-	if err := ctrl.UserRepo.CreateUser(&user); err != nil {
-		utils.SendInternalServerError(c, "Failed to create user")
-		return
+	if req.OrganizationName == "" {
+		if err := ctrl.UserRepo.CreateUser(c.Request.Context(), &user); err != nil {
+			c.Error(utils.Internal(err, "Failed to create user"))
+			return
+		}
+	} else {
+		// The account and its organization are created together so a failure can be retried
+		org := models.Organization{Name: req.OrganizationName}
+		if err := ctrl.OrgRepo.CreateOrganizationWithOwner(c.Request.Context(), &org, &user, utils.RoleOwner); err != nil {
+			c.Error(utils.Internal(err, "Failed to create organization"))
+			return
+		}
 	}
 
 	utils.SendCreated(c, "User registered successfully", gin.H{"email": user.Email})
 }
 
-// LoginUser handles user authentication
+// LoginUser handles user authentication.
+// The token is scoped to the requested organization, or to the user's first one.
 func (ctrl *AuthController) LoginUser(c *gin.Context) {
 	var req struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
+		Email          string `json:"email" binding:"required,email"`
+		Password       string `json:"password" binding:"required"`
+		OrganizationID uint   `json:"organization_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, "Invalid request data", err.Error())
+		utils.SendValidationError(c, "Invalid request data", err)
 		return
 	}
 
-	// Validate input
-	if err := utils.ValidateUserInput(req.Email, req.Password); err != nil {
-		utils.SendValidationError(c, "Validation error", err.Error())
-		return
-	}
+	// The password policy applies when passwords are set, not here: accounts whose password
+	// predates it must still be able to log in, and a weak guess is just a wrong password
 
 	// Find user
-	user, err := ctrl.UserRepo.FindByEmail(req.Email)
+	user, err := ctrl.UserRepo.FindByEmail(c.Request.Context(), req.Email)
 	if err != nil {
+		ctrl.Metrics.LoginAttempted(false)
 		utils.SendUnauthorized(c, "Invalid credentials")
 		return
 	}
 
 	// Validate password
-	if err := ctrl.Hasher.ComparePasswords(user.Password, req.Password); err != nil {
+	_, span := tracing.Start(c.Request.Context(), "bcrypt.compare")
+	err = ctrl.Hasher.ComparePasswords(user.Password, req.Password)
+	span.End()
+	if err != nil {
+		ctrl.Metrics.LoginAttempted(false)
 		utils.SendUnauthorized(c, "Invalid credentials")
 		return
 	}
 
+	orgID, role, err := ctrl.resolveOrganization(c.Request.Context(), user, req.OrganizationID)
+	if err != nil {
+		if errors.Is(err, errNotMember) {
+			utils.SendForbidden(c, "Not a member of this organization")
+			return
+		}
+		c.Error(utils.Internal(err, "Failed to load organizations"))
+		return
+	}
+
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := utils.GenerateToken(user.ID, user.Email, role, orgID)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	// Start a new refresh token family for this login
+	familyID, err := utils.NewTokenFamilyID()
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	refreshToken, record, err := newRefreshToken(user.ID, orgID, familyID)
 	if err != nil {
-		utils.SendInternalServerError(c, "Failed to generate token")
+		c.Error(utils.Internal(err, "Failed to generate token"))
 		return
 	}
 
-	// Save token in DB
-	user.Token = token
-	if err := ctrl.UserRepo.UpdateUser(user); err != nil { // ✅ Remove '&' since user is already a pointer
-		utils.SendInternalServerError(c, "Failed to update user token")
+	if err := ctrl.RefreshRepo.CreateRefreshToken(c.Request.Context(), record); err != nil {
+		c.Error(utils.Internal(err, "Failed to save refresh token"))
 		return
 	}
 
-	utils.SendSuccess(c, "Login successful", gin.H{"token": token})
+	ctrl.Metrics.LoginAttempted(true)
+	utils.SendSuccess(c, "Login successful", tokenResponse(token, refreshToken, orgID))
 }
 
-// LogoutUser handles user logout by removing the JWT token from the database
-func (ctrl *AuthController) LogoutUser(c *gin.Context) {
-	// Extract token from Authorization header
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
+// SwitchOrganization issues a new token pair scoped to another organization the user belongs to
+func (ctrl *AuthController) SwitchOrganization(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
 		utils.SendUnauthorized(c, "No token provided")
 		return
 	}
 
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-	claims, err := utils.ValidateToken(token)
-	if err != nil {
-		utils.SendUnauthorized(c, "Invalid token")
+	var req struct {
+		OrganizationID uint `json:"organization_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
 		return
 	}
 
-	// Find user by email
-	user, err := ctrl.UserRepo.FindByEmail(claims.Username)
+	user, err := ctrl.UserRepo.FindByID(c.Request.Context(), claims.UserID)
 	if err != nil {
 		utils.SendUnauthorized(c, "User not found")
 		return
 	}
 
-	// Clear token from DB
-	user.Token = ""
-	if err := ctrl.UserRepo.UpdateUser(user); err != nil {
-		utils.SendInternalServerError(c, "Failed to logout")
+	orgID, role, err := ctrl.resolveOrganization(c.Request.Context(), user, req.OrganizationID)
+	if err != nil {
+		if errors.Is(err, errNotMember) {
+			utils.SendForbidden(c, "Not a member of this organization")
+			return
+		}
+		c.Error(utils.Internal(err, "Failed to load organizations"))
+		return
+	}
+
+	token, err := utils.GenerateToken(user.ID, user.Email, role, orgID)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	familyID, err := utils.NewTokenFamilyID()
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	refreshToken, record, err := newRefreshToken(user.ID, orgID, familyID)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	if err := ctrl.RefreshRepo.CreateRefreshToken(c.Request.Context(), record); err != nil {
+		c.Error(utils.Internal(err, "Failed to save refresh token"))
+		return
+	}
+
+	utils.SendSuccess(c, "Organization switched successfully", tokenResponse(token, refreshToken, orgID))
+}
+
+// RefreshToken exchanges a valid refresh token for a new access/refresh token pair.
+// Every refresh token can be used once; presenting an already rotated token is treated
+// as theft and revokes the whole token family.
+func (ctrl *AuthController) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	current, err := ctrl.RefreshRepo.FindByTokenHash(c.Request.Context(), utils.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		utils.SendUnauthorized(c, "Invalid refresh token")
+		return
+	}
+
+	// Reuse of a rotated token: revoke every token in the family. A token revoked by a
+	// logout is merely stale, e.g. a second tab refreshing after the first logged out.
+	if current.IsRotated() {
+		ctrl.revokeFamily(c.Request.Context(), current)
+		utils.SendUnauthorized(c, "Refresh token reuse detected")
+		return
+	}
+	if current.IsRevoked() {
+		utils.SendUnauthorized(c, "Refresh token revoked")
+		return
+	}
+
+	if current.IsExpired(time.Now()) {
+		utils.SendUnauthorized(c, "Refresh token expired")
+		return
+	}
+
+	user, err := ctrl.UserRepo.FindByID(c.Request.Context(), current.UserID)
+	if err != nil {
+		utils.SendUnauthorized(c, "Invalid refresh token")
+		return
+	}
+
+	// Membership may have been revoked since login
+	orgID, role, err := ctrl.resolveOrganization(c.Request.Context(), user, current.OrganizationID)
+	if err != nil {
+		if errors.Is(err, errNotMember) {
+			utils.SendUnauthorized(c, "No longer a member of this organization")
+			return
+		}
+		c.Error(utils.Internal(err, "Failed to load organizations"))
 		return
 	}
 
+	refreshToken, next, err := newRefreshToken(user.ID, orgID, current.FamilyID)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	if err := ctrl.RefreshRepo.RotateRefreshToken(c.Request.Context(), current, next); err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenReused) {
+			ctrl.revokeFamily(c.Request.Context(), current)
+			utils.SendUnauthorized(c, "Refresh token reuse detected")
+			return
+		}
+		if errors.Is(err, repositories.ErrRefreshTokenRevoked) {
+			utils.SendUnauthorized(c, "Refresh token revoked")
+			return
+		}
+		c.Error(utils.Internal(err, "Failed to rotate refresh token"))
+		return
+	}
+
+	token, err := utils.GenerateToken(user.ID, user.Email, role, orgID)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to generate token"))
+		return
+	}
+
+	utils.SendSuccess(c, "Token refreshed successfully", tokenResponse(token, refreshToken, orgID))
+}
+
+// revokeFamily revokes all tokens descending from the same login
+func (ctrl *AuthController) revokeFamily(ctx context.Context, token *models.RefreshToken) {
+	utils.WarningContext(ctx, "Refresh token reuse detected, revoking family", "user_id", token.UserID, "family_id", token.FamilyID)
+	if err := ctrl.RefreshRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		utils.ErrorContext(ctx, "Failed to revoke refresh token family", "family_id", token.FamilyID, "error", err)
+	}
+}
+
+// resolveOrganization picks the organization a token is scoped to and the role that applies in it.
+// requested == 0 selects the user's first organization; users without any get an unscoped token.
+// Platform admins keep the admin role in every organization.
+func (ctrl *AuthController) resolveOrganization(ctx context.Context, user *models.User, requested uint) (uint, string, error) {
+	var membership *models.OrganizationMember
+
+	if requested != 0 {
+		m, err := ctrl.OrgRepo.FindMembership(ctx, requested, user.ID)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return 0, "", errNotMember
+			}
+			return 0, "", err
+		}
+		membership = m
+	} else {
+		memberships, err := ctrl.OrgRepo.GetMemberships(ctx, user.ID)
+		if err != nil {
+			return 0, "", err
+		}
+		if len(memberships) == 0 {
+			return 0, user.Role, nil
+		}
+		membership = &memberships[0]
+	}
+
+	if user.Role == utils.RoleAdmin {
+		return membership.OrganizationID, utils.RoleAdmin, nil
+	}
+	return membership.OrganizationID, membership.Role, nil
+}
+
+// newRefreshToken generates an opaque refresh token and the record that stores its hash
+func newRefreshToken(userID, orgID uint, familyID string) (string, *models.RefreshToken, error) {
+	token, tokenHash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &models.RefreshToken{
+		UserID:         userID,
+		OrganizationID: orgID,
+		TokenHash:      tokenHash,
+		FamilyID:       familyID,
+		ExpiresAt:      time.Now().Add(utils.RefreshTokenTTL),
+	}, nil
+}
+
+// tokenResponse builds the payload returned by login and refresh
+func tokenResponse(accessToken, refreshToken string, orgID uint) gin.H {
+	return gin.H{
+		"token":           accessToken,
+		"refresh_token":   refreshToken,
+		"token_type":      "Bearer",
+		"expires_in":      int(utils.AccessTokenTTL.Seconds()),
+		"organization_id": orgID,
+	}
+}
+
+// LogoutUser revokes the access token used for this request.
+// If a refresh token is sent in the body, its whole family is revoked as well.
+func (ctrl *AuthController) LogoutUser(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.SendUnauthorized(c, "No token provided")
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.SendValidationError(c, "Invalid request data", err)
+			return
+		}
+	}
+
+	if err := ctrl.Revocations.RevokeToken(c.Request.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		c.Error(utils.Internal(err, "Failed to logout"))
+		return
+	}
+
+	if req.RefreshToken != "" {
+		token, err := ctrl.RefreshRepo.FindByTokenHash(c.Request.Context(), utils.HashRefreshToken(req.RefreshToken))
+		if err == nil && token.UserID == claims.UserID {
+			if err := ctrl.RefreshRepo.RevokeFamily(c.Request.Context(), token.FamilyID); err != nil {
+				c.Error(utils.Internal(err, "Failed to logout"))
+				return
+			}
+		}
+	}
+
 	utils.SendSuccess(c, "Logout successful", nil)
 }
+
+// LogoutAll revokes every access and refresh token issued to the current user
+func (ctrl *AuthController) LogoutAll(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		utils.SendUnauthorized(c, "No token provided")
+		return
+	}
+
+	if err := ctrl.Revocations.RevokeUserTokens(c.Request.Context(), claims.UserID, time.Now()); err != nil {
+		c.Error(utils.Internal(err, "Failed to logout from all sessions"))
+		return
+	}
+
+	// The user-wide cutoff has second precision, so revoke the current token explicitly too
+	if err := ctrl.Revocations.RevokeToken(c.Request.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		c.Error(utils.Internal(err, "Failed to logout from all sessions"))
+		return
+	}
+
+	if err := ctrl.RefreshRepo.RevokeAllForUser(c.Request.Context(), claims.UserID); err != nil {
+		c.Error(utils.Internal(err, "Failed to logout from all sessions"))
+		return
+	}
+
+	utils.SendSuccess(c, "Logged out from all sessions", nil)
+}
+
+// currentClaims returns the JWT claims attached by JWTAuthMiddleware
+func currentClaims(c *gin.Context) (*utils.Claims, bool) {
+	value, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok && claims.ExpiresAt != nil
+}
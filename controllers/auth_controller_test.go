@@ -2,13 +2,18 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/metabbe3/go-backend/middleware"
 	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
 	"github.com/metabbe3/go-backend/test"
 	"github.com/metabbe3/go-backend/utils"
 	"github.com/stretchr/testify/assert"
@@ -21,15 +26,25 @@ func TestAuthController_RegisterUser(t *testing.T) {
 	tests := []struct {
 		name       string
 		request    string
-		mockSetup  func(mockRepo *test.MockUserRepository)
+		mockSetup  func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository)
 		expectCode int
 		expectMsg  string
 	}{
 		{
 			name:    "Success - Valid Registration",
 			request: `{"email":"test@example.com","password":"StrongPass123"}`,
-			mockSetup: func(mockRepo *test.MockUserRepository) {
-				mockRepo.On("CreateUser", mock.Anything).Return(nil).Once()
+			mockSetup: func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository) {
+				mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectCode: http.StatusCreated,
+			expectMsg:  "User registered successfully",
+		},
+		{
+			name:    "Success - Registration With Organization",
+			request: `{"email":"test@example.com","password":"StrongPass123","organization_name":"Acme"}`,
+			mockSetup: func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("CreateOrganizationWithOwner", mock.Anything, mock.MatchedBy(func(o *models.Organization) bool { return o.Name == "Acme" }),
+					mock.MatchedBy(func(u *models.User) bool { return u.Email == "test@example.com" }), utils.RoleOwner).Return(nil).Once()
 			},
 			expectCode: http.StatusCreated,
 			expectMsg:  "User registered successfully",
@@ -37,29 +52,29 @@ func TestAuthController_RegisterUser(t *testing.T) {
 		{
 			name:       "Failure - Invalid JSON",
 			request:    `{"email":"test@example.com", "password":}`,
-			mockSetup:  func(mockRepo *test.MockUserRepository) {},
+			mockSetup:  func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository) {},
 			expectCode: http.StatusBadRequest,
 			expectMsg:  "Invalid request data",
 		},
 		{
 			name:       "Failure - Invalid Email",
 			request:    `{"email":"invalid-email","password":"StrongPass123"}`,
-			mockSetup:  func(mockRepo *test.MockUserRepository) {},
+			mockSetup:  func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository) {},
 			expectCode: http.StatusBadRequest,
 			expectMsg:  "Invalid request data", // ✅ Update expected message
 		},
 		{
 			name:       "Failure - Weak Password",
 			request:    `{"email":"test@example.com","password":"123"}`,
-			mockSetup:  func(mockRepo *test.MockUserRepository) {},
+			mockSetup:  func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository) {},
 			expectCode: http.StatusBadRequest,
 			expectMsg:  "Invalid request data", // ✅ Update expected message
 		},
 		{
 			name:    "Failure - Database Error",
 			request: `{"email":"test@example.com","password":"StrongPass123"}`,
-			mockSetup: func(mockRepo *test.MockUserRepository) {
-				mockRepo.On("CreateUser", mock.Anything).Return(errors.New("db error")).Once()
+			mockSetup: func(mockRepo *test.MockUserRepository, orgRepo *test.MockOrganizationRepository) {
+				mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(errors.New("db error")).Once()
 			},
 			expectCode: http.StatusInternalServerError,
 			expectMsg:  "Failed to create user",
@@ -69,9 +84,11 @@ func TestAuthController_RegisterUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(test.MockUserRepository) // ✅ Mock Repository
+			orgRepo := new(test.MockOrganizationRepository)
 			ctrl := AuthController{
 				UserRepo: mockRepo,             // ✅ Inject interface-based mock
 				Hasher:   utils.BcryptHasher{}, // ✅ Ensure Hasher is set
+				OrgRepo:  orgRepo,
 			}
 
 			w := httptest.NewRecorder()
@@ -79,12 +96,15 @@ func TestAuthController_RegisterUser(t *testing.T) {
 			c.Request = httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(tt.request))
 			c.Request.Header.Set("Content-Type", "application/json")
 
-			tt.mockSetup(mockRepo) // ✅ Set up mocks
+			tt.mockSetup(mockRepo, orgRepo) // ✅ Set up mocks
 
 			ctrl.RegisterUser(c) // ✅ Call function
+			middleware.RenderError(c)
 
 			assert.Equal(t, tt.expectCode, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			mockRepo.AssertExpectations(t)
+			orgRepo.AssertExpectations(t)
 		})
 
 	}
@@ -105,13 +125,27 @@ func TestAuthController_LoginUser(t *testing.T) {
 			request: `{"email":"test@example.com","password":"StrongPass123"}`,
 			mockSetup: func(mockRepo *test.MockUserRepository) {
 				hashedPassword, _ := utils.BcryptHasher{}.HashPassword("StrongPass123")
-				mockRepo.On("FindByEmail", "test@example.com").Return(&models.User{
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@example.com",
+					Password: hashedPassword,
+					Role:     "user",
+				}, nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "Login successful",
+		},
+		{
+			name:    "Success - Password Predating The Policy",
+			request: `{"email":"test@example.com","password":"legacy"}`,
+			mockSetup: func(mockRepo *test.MockUserRepository) {
+				hashedPassword, _ := utils.BcryptHasher{}.HashPassword("legacy")
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&models.User{
 					ID:       1,
 					Email:    "test@example.com",
 					Password: hashedPassword,
 					Role:     "user",
 				}, nil).Once()
-				mockRepo.On("UpdateUser", mock.Anything).Return(nil).Once()
 			},
 			expectCode: http.StatusOK,
 			expectMsg:  "Login successful",
@@ -134,7 +168,7 @@ func TestAuthController_LoginUser(t *testing.T) {
 			name:    "Failure - User Not Found",
 			request: `{"email":"notfound@example.com","password":"StrongPass123"}`,
 			mockSetup: func(mockRepo *test.MockUserRepository) {
-				mockRepo.On("FindByEmail", "notfound@example.com").Return(nil, errors.New("user not found")).Once()
+				mockRepo.On("FindByEmail", mock.Anything, "notfound@example.com").Return(nil, errors.New("user not found")).Once()
 			},
 			expectCode: http.StatusUnauthorized,
 			expectMsg:  "Invalid credentials",
@@ -144,7 +178,7 @@ func TestAuthController_LoginUser(t *testing.T) {
 			request: `{"email":"test@example.com","password":"WrongPass123"}`,
 			mockSetup: func(mockRepo *test.MockUserRepository) {
 				hashedPassword, _ := utils.BcryptHasher{}.HashPassword("StrongPass123")
-				mockRepo.On("FindByEmail", "test@example.com").Return(&models.User{
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&models.User{
 					ID:       1,
 					Email:    "test@example.com",
 					Password: hashedPassword,
@@ -154,31 +188,21 @@ func TestAuthController_LoginUser(t *testing.T) {
 			expectCode: http.StatusUnauthorized,
 			expectMsg:  "Invalid credentials",
 		},
-		{
-			name:    "Failure - Database Error on Token Update",
-			request: `{"email":"test@example.com","password":"StrongPass123"}`,
-			mockSetup: func(mockRepo *test.MockUserRepository) {
-				hashedPassword, _ := utils.BcryptHasher{}.HashPassword("StrongPass123")
-				mockRepo.On("FindByEmail", "test@example.com").Return(&models.User{
-					ID:       1,
-					Email:    "test@example.com",
-					Password: hashedPassword,
-					Role:     "user",
-				}, nil).Once()
-				mockRepo.On("UpdateUser", mock.Anything).Return(errors.New("db error")).Once()
-			},
-			expectCode: http.StatusInternalServerError,
-			expectMsg:  "Failed to update user token",
-		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(test.MockUserRepository)
+			refreshRepo := new(test.MockRefreshTokenRepository)
+			refreshRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+			orgRepo := new(test.MockOrganizationRepository)
+			orgRepo.On("GetMemberships", mock.Anything, mock.Anything).Return([]models.OrganizationMember{}, nil).Maybe()
 
 			ctrl := AuthController{
-				UserRepo: mockRepo,
-				Hasher:   utils.BcryptHasher{},
+				UserRepo:    mockRepo,
+				OrgRepo:     orgRepo,
+				RefreshRepo: refreshRepo,
+				Hasher:      utils.BcryptHasher{},
 			}
 
 			w := httptest.NewRecorder()
@@ -189,122 +213,385 @@ func TestAuthController_LoginUser(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			ctrl.LoginUser(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+		})
+	}
+}
+
+func TestAuthController_RefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const plainToken = "opaque-refresh-token"
+	tokenHash := utils.HashRefreshToken(plainToken)
+
+	activeToken := func() *models.RefreshToken {
+		return &models.RefreshToken{
+			ID:        7,
+			UserID:    1,
+			TokenHash: tokenHash,
+			FamilyID:  "family-1",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		request    string
+		mockSetup  func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:    "Success - Token Rotated",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(activeToken(), nil).Once()
+				userRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Email: "test@example.com", Role: "user"}, nil).Once()
+				refreshRepo.On("RotateRefreshToken", mock.Anything, mock.Anything, mock.MatchedBy(func(next *models.RefreshToken) bool {
+					return next.FamilyID == "family-1" && next.TokenHash != tokenHash
+				})).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "Token refreshed successfully",
+		},
+		{
+			name:       "Failure - Missing Token",
+			request:    `{}`,
+			mockSetup:  func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid request data",
+		},
+		{
+			name:    "Failure - Unknown Token",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(nil, errors.New("record not found")).Once()
+			},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Invalid refresh token",
+		},
+		{
+			name:    "Failure - Expired Token",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				token := activeToken()
+				token.ExpiresAt = time.Now().Add(-time.Minute)
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(token, nil).Once()
+			},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Refresh token expired",
+		},
+		{
+			name:    "Failure - Reuse Of Rotated Token Revokes Family",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				token := activeToken()
+				revokedAt, replacedBy := time.Now().Add(-time.Minute), uint(8)
+				token.RevokedAt, token.ReplacedByID = &revokedAt, &replacedBy
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(token, nil).Once()
+				refreshRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil).Once()
+			},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Refresh token reuse detected",
+		},
+		{
+			name:    "Failure - Token Revoked By Logout Keeps Family",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				token := activeToken()
+				revokedAt := time.Now().Add(-time.Minute)
+				token.RevokedAt = &revokedAt
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(token, nil).Once()
+			},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Refresh token revoked",
+		},
+		{
+			name:    "Failure - Logout During Rotation",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(activeToken(), nil).Once()
+				userRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Email: "test@example.com", Role: "user"}, nil).Once()
+				refreshRepo.On("RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything).Return(repositories.ErrRefreshTokenRevoked).Once()
+			},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Refresh token revoked",
+		},
+		{
+			name:    "Failure - Concurrent Rotation Revokes Family",
+			request: `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				refreshRepo.On("FindByTokenHash", mock.Anything, tokenHash).Return(activeToken(), nil).Once()
+				userRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Email: "test@example.com", Role: "user"}, nil).Once()
+				refreshRepo.On("RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything).Return(repositories.ErrRefreshTokenReused).Once()
+				refreshRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil).Once()
+			},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Refresh token reuse detected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(test.MockUserRepository)
+			refreshRepo := new(test.MockRefreshTokenRepository)
+			orgRepo := new(test.MockOrganizationRepository)
+			orgRepo.On("GetMemberships", mock.Anything, uint(1)).Return([]models.OrganizationMember{}, nil).Maybe()
+
+			ctrl := AuthController{
+				UserRepo:    userRepo,
+				OrgRepo:     orgRepo,
+				RefreshRepo: refreshRepo,
+				Hasher:      utils.BcryptHasher{},
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			tt.mockSetup(userRepo, refreshRepo)
+
+			ctrl.RefreshToken(c)
+			middleware.RenderError(c)
 
 			assert.Equal(t, tt.expectCode, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			userRepo.AssertExpectations(t)
+			refreshRepo.AssertExpectations(t)
 		})
 	}
 }
 
-// func TestAuthController_LogoutUser(t *testing.T) {
-// 	gin.SetMode(gin.TestMode)
-
-// 	tests := []struct {
-// 		name       string
-// 		authHeader string
-// 		mockSetup  func(mockRepo *test.MockUserRepository)
-// 		expectCode int
-// 		expectMsg  string
-// 	}{
-// 		{
-// 			name:       "Success - Valid Token",
-// 			authHeader: "Bearer valid_token",
-// 			mockSetup: func(mockRepo *test.MockUserRepository) {
-// 				mockRepo.On("FindByEmail", "test@example.com").Return(&models.User{
-// 					ID:    1,
-// 					Email: "test@example.com",
-// 					Token: "valid_token",
-// 				}, nil).Once()
-// 				mockRepo.On("UpdateUser", mock.Anything).Return(nil).Once()
-
-// 				// Mock token validation
-// 				utils.ValidateToken = func(token string) (*utils.Claims, error) {
-// 					if token == "valid_token" {
-// 						return &utils.Claims{Username: "test@example.com"}, nil
-// 					}
-// 					return nil, errors.New("invalid token")
-// 				}
-// 			},
-// 			expectCode: http.StatusOK,
-// 			expectMsg:  "Logout successful",
-// 		},
-// 		{
-// 			name:       "Failure - No Token Provided",
-// 			authHeader: "",
-// 			mockSetup:  func(mockRepo *test.MockUserRepository) {},
-// 			expectCode: http.StatusUnauthorized,
-// 			expectMsg:  "No token provided",
-// 		},
-// 		{
-// 			name:       "Failure - Invalid Token",
-// 			authHeader: "Bearer invalid_token",
-// 			mockSetup: func(mockRepo *test.MockUserRepository) {
-// 				utils.ValidateToken = func(token string) (*utils.Claims, error) {
-// 					return nil, errors.New("invalid token")
-// 				}
-// 			},
-// 			expectCode: http.StatusUnauthorized,
-// 			expectMsg:  "Invalid token",
-// 		},
-// 		{
-// 			name:       "Failure - User Not Found",
-// 			authHeader: "Bearer valid_token",
-// 			mockSetup: func(mockRepo *test.MockUserRepository) {
-// 				mockRepo.On("FindByEmail", "test@example.com").Return(nil, errors.New("user not found")).Once()
-
-// 				utils.ValidateToken = func(token string) (*utils.Claims, error) {
-// 					if token == "valid_token" {
-// 						return &utils.Claims{Username: "test@example.com"}, nil
-// 					}
-// 					return nil, errors.New("invalid token")
-// 				}
-// 			},
-// 			expectCode: http.StatusUnauthorized,
-// 			expectMsg:  "User not found",
-// 		},
-// 		{
-// 			name:       "Failure - Database Error on Logout",
-// 			authHeader: "Bearer valid_token",
-// 			mockSetup: func(mockRepo *test.MockUserRepository) {
-// 				mockRepo.On("FindByEmail", "test@example.com").Return(&models.User{
-// 					ID:    1,
-// 					Email: "test@example.com",
-// 					Token: "valid_token",
-// 				}, nil).Once()
-// 				mockRepo.On("UpdateUser", mock.Anything).Return(errors.New("db error")).Once()
-
-// 				utils.ValidateToken = func(token string) (*utils.Claims, error) {
-// 					if token == "valid_token" {
-// 						return &utils.Claims{Username: "test@example.com"}, nil
-// 					}
-// 					return nil, errors.New("invalid token")
-// 				}
-// 			},
-// 			expectCode: http.StatusInternalServerError,
-// 			expectMsg:  "Failed to logout",
-// 		},
-// 	}
-
-// 	for _, tt := range tests {
-// 		t.Run(tt.name, func(t *testing.T) {
-// 			mockRepo := new(test.MockUserRepository)
-
-// 			ctrl := AuthController{
-// 				UserRepo: mockRepo,
-// 				Hasher:   utils.BcryptHasher{},
-// 			}
-
-// 			w := httptest.NewRecorder()
-// 			c, _ := gin.CreateTestContext(w)
-// 			c.Request = httptest.NewRequest(http.MethodPost, "/logout", nil)
-// 			c.Request.Header.Set("Authorization", tt.authHeader)
-
-// 			tt.mockSetup(mockRepo)
-
-// 			ctrl.LogoutUser(c)
-
-// 			assert.Equal(t, tt.expectCode, w.Code)
-// 			assert.Contains(t, w.Body.String(), tt.expectMsg)
-// 		})
-// 	}
-// }
+func TestAuthController_SwitchOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &utils.Claims{
+		UserID: 1,
+		OrgID:  10,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		request    string
+		user       *models.User
+		mockSetup  func(orgRepo *test.MockOrganizationRepository, refreshRepo *test.MockRefreshTokenRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:    "Success - Member Of Organization",
+			request: `{"organization_id":20}`,
+			user:    &models.User{ID: 1, Email: "test@example.com", Role: "user"},
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				orgRepo.On("FindMembership", mock.Anything, uint(20), uint(1)).Return(&models.OrganizationMember{OrganizationID: 20, UserID: 1, Role: "owner"}, nil).Once()
+				refreshRepo.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+					return token.OrganizationID == 20
+				})).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  `"organization_id":20`,
+		},
+		{
+			name:    "Failure - Not A Member",
+			request: `{"organization_id":30}`,
+			user:    &models.User{ID: 1, Email: "test@example.com", Role: "user"},
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				orgRepo.On("FindMembership", mock.Anything, uint(30), uint(1)).Return(nil, utils.NotFound("Member not found")).Once()
+			},
+			expectCode: http.StatusForbidden,
+			expectMsg:  "Not a member of this organization",
+		},
+		{
+			name:       "Failure - Missing Organization",
+			request:    `{}`,
+			mockSetup:  func(orgRepo *test.MockOrganizationRepository, refreshRepo *test.MockRefreshTokenRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid request data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(test.MockUserRepository)
+			orgRepo := new(test.MockOrganizationRepository)
+			refreshRepo := new(test.MockRefreshTokenRepository)
+			if tt.user != nil {
+				userRepo.On("FindByID", mock.Anything, uint(1)).Return(tt.user, nil).Once()
+			}
+
+			ctrl := AuthController{
+				UserRepo:    userRepo,
+				OrgRepo:     orgRepo,
+				RefreshRepo: refreshRepo,
+				Hasher:      utils.BcryptHasher{},
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/switch-organization", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("claims", claims)
+
+			tt.mockSetup(orgRepo, refreshRepo)
+
+			ctrl.SwitchOrganization(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			orgRepo.AssertExpectations(t)
+			refreshRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthController_LogoutUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &utils.Claims{
+		UserID:   1,
+		Username: "test@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-1",
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		withClaims    bool
+		request       string
+		mockSetup     func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository)
+		expectCode    int
+		expectMsg     string
+		expectRevoked bool
+	}{
+		{
+			name:       "Success - Token Revoked",
+			withClaims: true,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+			},
+			expectCode:    http.StatusOK,
+			expectMsg:     "Logout successful",
+			expectRevoked: true,
+		},
+		{
+			name:       "Success - Refresh Token Family Revoked",
+			withClaims: true,
+			request:    `{"refresh_token":"opaque-refresh-token"}`,
+			mockSetup: func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {
+				refreshRepo.On("FindByTokenHash", mock.Anything, utils.HashRefreshToken("opaque-refresh-token")).
+					Return(&models.RefreshToken{UserID: 1, FamilyID: "family-1"}, nil).Once()
+				refreshRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil).Once()
+			},
+			expectCode:    http.StatusOK,
+			expectMsg:     "Logout successful",
+			expectRevoked: true,
+		},
+		{
+			name:       "Failure - No Token Provided",
+			withClaims: false,
+			mockSetup:  func(userRepo *test.MockUserRepository, refreshRepo *test.MockRefreshTokenRepository) {},
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "No token provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(test.MockUserRepository)
+			refreshRepo := new(test.MockRefreshTokenRepository)
+			revocations := utils.NewMemoryRevocationStore(time.Minute)
+			defer revocations.Close()
+
+			ctrl := AuthController{
+				UserRepo:    userRepo,
+				RefreshRepo: refreshRepo,
+				Revocations: revocations,
+				Hasher:      utils.BcryptHasher{},
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.withClaims {
+				c.Set("claims", claims)
+			}
+
+			tt.mockSetup(userRepo, refreshRepo)
+
+			ctrl.LogoutUser(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			revoked, _ := utils.IsRevoked(context.Background(), revocations, claims)
+			assert.Equal(t, tt.expectRevoked, revoked)
+			userRepo.AssertExpectations(t)
+			refreshRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthController_LogoutAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userRepo := new(test.MockUserRepository)
+	refreshRepo := new(test.MockRefreshTokenRepository)
+	revocations := utils.NewMemoryRevocationStore(time.Minute)
+	defer revocations.Close()
+
+	ctrl := AuthController{
+		UserRepo:    userRepo,
+		RefreshRepo: refreshRepo,
+		Revocations: revocations,
+		Hasher:      utils.BcryptHasher{},
+	}
+
+	current := &utils.Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-1",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	otherSession := &utils.Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-2",
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+
+	refreshRepo.On("RevokeAllForUser", mock.Anything, uint(1)).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil)
+	c.Set("claims", current)
+
+	ctrl.LogoutAll(c)
+	middleware.RenderError(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Logged out from all sessions")
+
+	for _, claims := range []*utils.Claims{current, otherSession} {
+		revoked, err := utils.IsRevoked(context.Background(), revocations, claims)
+		assert.NoError(t, err)
+		assert.True(t, revoked, "token %s should be revoked", claims.ID)
+	}
+	refreshRepo.AssertExpectations(t)
+}
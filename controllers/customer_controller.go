@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/metrics"
 	"github.com/metabbe3/go-backend/models"
 	"github.com/metabbe3/go-backend/repositories"
 	"github.com/metabbe3/go-backend/utils"
@@ -11,6 +15,7 @@ import (
 
 type CustomerController struct {
 	CustomerRepo repositories.CustomerRepositoryInterface
+	Metrics      *metrics.Metrics // Counts customers created; nil records nothing
 }
 
 // NewCustomerController returns a new instance of CustomerController
@@ -18,29 +23,47 @@ func NewCustomerController(customerRepo repositories.CustomerRepositoryInterface
 	return &CustomerController{CustomerRepo: customerRepo}
 }
 
+// customers returns the customer repository scoped to the request's organization
+func (ctrl *CustomerController) customers(c *gin.Context) repositories.CustomerRepositoryInterface {
+	return ctrl.CustomerRepo.ForOrganization(organizationID(c))
+}
+
 // CreateCustomer handles customer creation
 func (ctrl *CustomerController) CreateCustomer(c *gin.Context) {
 	var req struct {
 		Name  string `json:"name" binding:"required"`
 		Email string `json:"email" binding:"required,email"`
-		Phone string `json:"phone" binding:"required"`
+		Phone string `json:"phone" binding:"required,phone"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, "Invalid request data", err.Error())
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid phone number", err)
+		return
+	}
+
+	customers := ctrl.customers(c)
+	if err := phoneTaken(c, customers, phone); err != nil {
+		c.Error(err)
 		return
 	}
 
 	customer := models.Customer{
 		Name:  req.Name,
 		Email: req.Email,
-		Phone: req.Phone,
+		Phone: phone,
 	}
 
-	if err := ctrl.CustomerRepo.CreateCustomer(&customer); err != nil {
-		utils.SendInternalServerError(c, "Failed to create customer")
+	if err := customers.CreateCustomer(c.Request.Context(), &customer); err != nil {
+		c.Error(utils.Internal(err, "Failed to create customer"))
 		return
 	}
+	ctrl.Metrics.CustomersAdded(metrics.SourceAPI, 1)
 
 	utils.SendCreated(c, "Customer created successfully", gin.H{"customer": customer})
 }
@@ -50,13 +73,13 @@ func (ctrl *CustomerController) GetCustomer(c *gin.Context) {
 	customerID := c.Param("id")
 	id, err := strconv.ParseUint(customerID, 10, 32)
 	if err != nil {
-		utils.SendValidationError(c, "Invalid customer ID", err.Error())
+		utils.SendValidationError(c, "Invalid customer ID", err)
 		return
 	}
 
-	customer, err := ctrl.CustomerRepo.FindCustomerByID(uint(id))
+	customer, err := ctrl.customers(c).FindCustomerByID(c.Request.Context(), uint(id))
 	if err != nil {
-		utils.SendNotFound(c, "Customer not found")
+		c.Error(err)
 		return
 	}
 
@@ -68,27 +91,42 @@ func (ctrl *CustomerController) UpdateCustomer(c *gin.Context) {
 	customerID := c.Param("id")
 	id, err := strconv.ParseUint(customerID, 10, 32)
 	if err != nil {
-		utils.SendValidationError(c, "Invalid customer ID", err.Error())
+		utils.SendValidationError(c, "Invalid customer ID", err)
 		return
 	}
 
 	var req struct {
 		Name  string `json:"name"`
 		Email string `json:"email" binding:"email"`
-		Phone string `json:"phone"`
+		Phone string `json:"phone" binding:"omitempty,phone"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, "Invalid request data", err.Error())
+		utils.SendValidationError(c, "Invalid request data", err)
 		return
 	}
 
-	customer, err := ctrl.CustomerRepo.FindCustomerByID(uint(id))
+	if req.Phone != "" {
+		if req.Phone, err = utils.NormalizePhone(req.Phone); err != nil {
+			utils.SendValidationError(c, "Invalid phone number", err)
+			return
+		}
+	}
+
+	customers := ctrl.customers(c)
+	customer, err := customers.FindCustomerByID(c.Request.Context(), uint(id))
 	if err != nil {
-		utils.SendNotFound(c, "Customer not found")
+		c.Error(err)
 		return
 	}
 
+	if req.Phone != "" && req.Phone != customer.Phone {
+		if err := phoneTaken(c, customers, req.Phone); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
 	// Update customer fields
 	if req.Name != "" {
 		customer.Name = req.Name
@@ -100,46 +138,154 @@ func (ctrl *CustomerController) UpdateCustomer(c *gin.Context) {
 		customer.Phone = req.Phone
 	}
 
-	if err := ctrl.CustomerRepo.UpdateCustomer(customer); err != nil {
-		utils.SendInternalServerError(c, "Failed to update customer")
+	if err := customers.UpdateCustomer(c.Request.Context(), customer); err != nil {
+		c.Error(utils.Internal(err, "Failed to update customer"))
 		return
 	}
 
 	utils.SendSuccess(c, "Customer updated successfully", gin.H{"customer": customer})
 }
 
+// phoneTaken returns a conflict if a customer, live or deleted, already holds the phone number.
+// Deleted customers keep their number in the unique index, so it stays taken.
+func phoneTaken(c *gin.Context, customers repositories.CustomerRepositoryInterface, phone string) error {
+	existing, err := customers.FindCustomerByPhone(c.Request.Context(), phone)
+	switch {
+	case errors.Is(err, utils.ErrNotFound):
+		return nil
+	case err != nil:
+		return utils.Internal(err, "Failed to check phone number")
+	case existing.DeletedAt.Valid:
+		return utils.Conflict("A deleted customer has this phone number")
+	default:
+		return utils.Conflict("Customer with this phone number already exists")
+	}
+}
+
 // DeleteCustomer handles deleting a customer
 func (ctrl *CustomerController) DeleteCustomer(c *gin.Context) {
 	customerID := c.Param("id")
 	id, err := strconv.ParseUint(customerID, 10, 32)
 	if err != nil {
-		utils.SendValidationError(c, "Invalid customer ID", err.Error())
+		utils.SendValidationError(c, "Invalid customer ID", err)
 		return
 	}
 
-	err = ctrl.CustomerRepo.DeleteCustomer(uint(id))
+	err = ctrl.customers(c).DeleteCustomer(c.Request.Context(), uint(id))
 	if err != nil {
-		utils.SendNotFound(c, "Customer not found")
+		c.Error(err)
 		return
 	}
 
 	utils.SendSuccess(c, "Customer deleted successfully", nil)
 }
 
-// GetAllCustomers handles fetching all customers with pagination
+// GetAllCustomers handles fetching customers with search, filters, sorting and pagination.
+//
+// Query parameters:
+//   - q: free-text search across name, email, phone and address
+//   - created_from, created_to: RFC 3339 timestamps or YYYY-MM-DD dates (created_to is inclusive)
+//   - has_email: true|false
+//   - sort: one of repositories.CustomerSortFields, order: asc|desc
+//   - page, limit, pagination, cursor, include_total: see parseListOptions
 func (ctrl *CustomerController) GetAllCustomers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	query, cursorMode, err := parseCustomerQuery(c)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid query parameters", err)
+		return
+	}
 
-	customers, totalCount, err := ctrl.CustomerRepo.GetAllCustomers(limit, offset)
+	customers, result, err := ctrl.customers(c).GetAllCustomers(c.Request.Context(), query)
 	if err != nil {
-		utils.SendInternalServerError(c, "Failed to fetch customers")
+		c.Error(utils.Internal(err, "Failed to fetch customers"))
 		return
 	}
 
-	utils.SendSuccess(c, "Customers fetched successfully", gin.H{
-		"data":        customers,
-		"total_count": totalCount,
+	page, err := buildPage(customers, len(customers), query.ListOptions, result, cursorMode, func(i int) (string, uint) {
+		return customerSortValue(&customers[i], query.SortBy), customers[i].ID
 	})
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to build pagination cursor"))
+		return
+	}
+
+	utils.SendSuccess(c, "Customers fetched successfully", page)
+}
+
+// parseCustomerQuery builds a validated CustomerQuery from the request's query parameters
+func parseCustomerQuery(c *gin.Context) (repositories.CustomerQuery, bool, error) {
+	var query repositories.CustomerQuery
+
+	opts, cursorMode, err := parseListOptions(c)
+	if err != nil {
+		return query, cursorMode, err
+	}
+	query.ListOptions = opts
+
+	// Refused on the first page too, rather than handing out a next_cursor that can't be followed
+	if cursorMode && query.SortBy == "email" {
+		return query, cursorMode, errors.New("sorting by email is not supported with cursor pagination")
+	}
+
+	query.Search = c.Query("q")
+
+	if value := c.Query("created_from"); value != "" {
+		from, _, err := parseTimeParam(value)
+		if err != nil {
+			return query, cursorMode, fmt.Errorf("created_from: %w", err)
+		}
+		query.CreatedFrom = &from
+	}
+	if value := c.Query("created_to"); value != "" {
+		to, dateOnly, err := parseTimeParam(value)
+		if err != nil {
+			return query, cursorMode, fmt.Errorf("created_to: %w", err)
+		}
+		// A bare date includes the whole day; a timestamp is inclusive up to that instant
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			to = to.Add(time.Nanosecond)
+		}
+		query.CreatedBefore = &to
+	}
+
+	if value := c.Query("has_email"); value != "" {
+		hasEmail, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, cursorMode, errors.New("has_email must be true or false")
+		}
+		query.HasEmail = &hasEmail
+	}
+
+	return query, cursorMode, query.Validate()
+}
+
+// customerSortValue returns the value of a customer's sort field as stored in a cursor
+func customerSortValue(customer *models.Customer, sortBy string) string {
+	switch sortBy {
+	case "name":
+		return customer.Name
+	case "email":
+		return customer.Email
+	case "phone":
+		return customer.Phone
+	case "created_at":
+		return customer.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return customer.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return ""
+	}
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or a YYYY-MM-DD date (in server local time)
+func parseTimeParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q, expected RFC 3339 or YYYY-MM-DD", value)
 }
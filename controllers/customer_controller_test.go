@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestParseCustomerQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		rawQuery  string
+		expectErr string
+		check     func(t *testing.T, c *gin.Context)
+	}{
+		{
+			name:     "Success - Defaults",
+			rawQuery: "",
+			check: func(t *testing.T, c *gin.Context) {
+				query, _, _ := parseCustomerQuery(c)
+				assert.Equal(t, 10, query.Limit)
+				assert.Equal(t, 0, query.Offset)
+				assert.Equal(t, "id", query.SortBy)
+				assert.False(t, query.SortDesc)
+			},
+		},
+		{
+			name:     "Success - Search, Filters And Sort",
+			rawQuery: "q=budi&page=3&limit=20&has_email=true&created_from=2024-01-01&created_to=2024-01-31&sort=name&order=desc",
+			check: func(t *testing.T, c *gin.Context) {
+				query, _, _ := parseCustomerQuery(c)
+				assert.Equal(t, "budi", query.Search)
+				assert.Equal(t, 40, query.Offset)
+				assert.True(t, *query.HasEmail)
+				assert.Equal(t, "2024-01-01", query.CreatedFrom.Format("2006-01-02"))
+				assert.Equal(t, "2024-02-01", query.CreatedBefore.Format("2006-01-02")) // created_to is inclusive
+				assert.Equal(t, "name", query.SortBy)
+				assert.True(t, query.SortDesc)
+			},
+		},
+		{
+			name:     "Success - Cursor Carries Sort And Position",
+			rawQuery: "cursor=" + mustEncodeCursor(utils.Cursor{SortBy: "name", SortDesc: true, Value: "Budi", ID: 42}) + "&sort=phone",
+			check: func(t *testing.T, c *gin.Context) {
+				query, cursorMode, _ := parseCustomerQuery(c)
+				assert.True(t, cursorMode)
+				assert.Equal(t, "name", query.SortBy)
+				assert.True(t, query.SortDesc)
+				assert.Equal(t, &repositories.Keyset{Value: "Budi", ID: 42}, query.Keyset)
+				assert.True(t, query.SkipCount)
+			},
+		},
+		{
+			name:      "Failure - Tampered Cursor",
+			rawQuery:  "cursor=" + mustEncodeCursor(utils.Cursor{SortBy: "name", ID: 42}) + "x",
+			expectErr: "invalid cursor",
+		},
+		{
+			name:      "Failure - Nullable Sort With Cursor",
+			rawQuery:  "pagination=cursor&cursor=" + mustEncodeCursor(utils.Cursor{SortBy: "email", ID: 1}),
+			expectErr: "not supported with cursor pagination",
+		},
+		{
+			name:      "Failure - Nullable Sort On First Cursor Page",
+			rawQuery:  "pagination=cursor&sort=email",
+			expectErr: "not supported with cursor pagination",
+		},
+		{
+			name:     "Success - Nullable Sort With Offset",
+			rawQuery: "sort=email",
+			check: func(t *testing.T, c *gin.Context) {
+				query, cursorMode, err := parseCustomerQuery(c)
+				assert.NoError(t, err)
+				assert.False(t, cursorMode)
+				assert.Equal(t, "email", query.SortBy)
+			},
+		},
+		{
+			name:      "Failure - Sort Field Not Whitelisted",
+			rawQuery:  "sort=password%3BDROP+TABLE+customers",
+			expectErr: "unsupported sort field",
+		},
+		{
+			name:      "Failure - Invalid Order",
+			rawQuery:  "order=sideways",
+			expectErr: "order must be asc or desc",
+		},
+		{
+			name:      "Failure - Limit Too Large",
+			rawQuery:  "limit=1000",
+			expectErr: "limit must be between 1 and 100",
+		},
+		{
+			name:      "Failure - Invalid Date",
+			rawQuery:  "created_from=yesterday",
+			expectErr: "created_from",
+		},
+		{
+			name:      "Failure - Empty Date Range",
+			rawQuery:  "created_from=2024-02-01&created_to=2024-01-01",
+			expectErr: "created_from must be before created_to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/customers?"+tt.rawQuery, nil)
+
+			_, _, err := parseCustomerQuery(c)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestCustomerController_InvalidPhone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		method  string
+		request string
+	}{
+		{"Create - Letters", http.MethodPost, `{"name":"Budi","email":"budi@example.com","phone":"call me"}`},
+		{"Create - Too Short", http.MethodPost, `{"name":"Budi","email":"budi@example.com","phone":"0812"}`},
+		{"Update - Unknown Country Code", http.MethodPut, `{"email":"budi@example.com","phone":"+999 1234 5678"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Validation happens before any repository call
+			ctrl := CustomerController{}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/api/customer", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+			if tt.method == http.MethodPost {
+				ctrl.CreateCustomer(c)
+			} else {
+				ctrl.UpdateCustomer(c)
+			}
+			middleware.RenderError(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `{"field":"phone","rule":"phone","message":"phone must be a valid phone number"}`)
+		})
+	}
+}
+
+func TestCustomerController_GetCustomer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type ctxKey struct{}
+	requestCtx := context.WithValue(context.Background(), ctxKey{}, "request")
+	// The repository must run with the request's context so a disconnect cancels the query
+	fromRequest := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(ctxKey{}) == "request" })
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mockRepo *test.MockCustomerRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name: "Success - Customer Found",
+			mockSetup: func(mockRepo *test.MockCustomerRepository) {
+				mockRepo.On("FindCustomerByID", fromRequest, uint(1)).Return(&models.Customer{ID: 1, Name: "Budi"}, nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "Customer details fetched successfully",
+		},
+		{
+			name: "Failure - Not Found",
+			mockSetup: func(mockRepo *test.MockCustomerRepository) {
+				mockRepo.On("FindCustomerByID", fromRequest, uint(1)).Return(nil, utils.NotFound("Customer not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "Customer not found",
+		},
+		{
+			name: "Failure - Query Cancelled",
+			mockSetup: func(mockRepo *test.MockCustomerRepository) {
+				mockRepo.On("FindCustomerByID", fromRequest, uint(1)).Return(nil, context.Canceled).Once()
+			},
+			expectCode: http.StatusInternalServerError,
+			expectMsg:  "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(test.MockCustomerRepository)
+			tt.mockSetup(mockRepo)
+			ctrl := CustomerController{CustomerRepo: mockRepo}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/customers/1", nil).WithContext(requestCtx)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			c.Set("orgID", uint(7))
+
+			ctrl.GetCustomer(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			assert.Equal(t, uint(7), mockRepo.OrganizationID)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCustomerController_CreateCustomer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const request = `{"name":"Budi","email":"budi@example.com","phone":"0812-3456-7890"}`
+	deleted := &models.Customer{ID: 3, Phone: "+6281234567890", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+
+	tests := []struct {
+		name       string
+		mockSetup  func(mockRepo *test.MockCustomerRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name: "Success - Customer Created",
+			mockSetup: func(mockRepo *test.MockCustomerRepository) {
+				mockRepo.On("FindCustomerByPhone", mock.Anything, "+6281234567890").Return(nil, utils.NotFound("Customer not found")).Once()
+				mockRepo.On("CreateCustomer", mock.Anything, mock.MatchedBy(func(c *models.Customer) bool { return c.Phone == "+6281234567890" })).Return(nil).Once()
+			},
+			expectCode: http.StatusCreated,
+			expectMsg:  "Customer created successfully",
+		},
+		{
+			name: "Failure - Phone Taken",
+			mockSetup: func(mockRepo *test.MockCustomerRepository) {
+				mockRepo.On("FindCustomerByPhone", mock.Anything, "+6281234567890").Return(&models.Customer{ID: 2, Phone: "+6281234567890"}, nil).Once()
+			},
+			expectCode: http.StatusConflict,
+			expectMsg:  "Customer with this phone number already exists",
+		},
+		{
+			name: "Failure - Phone Held By Deleted Customer",
+			mockSetup: func(mockRepo *test.MockCustomerRepository) {
+				mockRepo.On("FindCustomerByPhone", mock.Anything, "+6281234567890").Return(deleted, nil).Once()
+			},
+			expectCode: http.StatusConflict,
+			expectMsg:  "A deleted customer has this phone number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(test.MockCustomerRepository)
+			tt.mockSetup(mockRepo)
+			ctrl := CustomerController{CustomerRepo: mockRepo}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/customer", bytes.NewBufferString(request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("orgID", uint(7))
+
+			ctrl.CreateCustomer(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBuildPage_Cursors(t *testing.T) {
+	keyset := func(i int) (string, uint) { return "", uint(i + 1) }
+	opts := repositories.ListOptions{SortBy: "id", Limit: 2}
+
+	// First page with more rows: only a next cursor
+	page, err := buildPage([]int{1, 2}, 2, opts, repositories.PageResult{HasMore: true}, true, keyset)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Empty(t, page.PrevCursor)
+
+	next, err := utils.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Cursor{SortBy: "id", ID: 2}, next)
+
+	// Last page reached by a forward cursor: only a prev cursor pointing before its first row
+	opts.Keyset = &repositories.Keyset{ID: 2}
+	page, err = buildPage([]int{3}, 1, opts, repositories.PageResult{}, true, keyset)
+	assert.NoError(t, err)
+	assert.Empty(t, page.NextCursor)
+
+	prev, err := utils.DecodeCursor(page.PrevCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Cursor{SortBy: "id", ID: 1, Backward: true}, prev)
+
+	// Offset mode never returns cursors
+	page, err = buildPage([]int{1, 2}, 2, repositories.ListOptions{Limit: 2}, repositories.PageResult{HasMore: true}, false, keyset)
+	assert.NoError(t, err)
+	assert.Empty(t, page.NextCursor)
+}
+
+func mustEncodeCursor(cursor utils.Cursor) string {
+	token, err := utils.EncodeCursor(cursor)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
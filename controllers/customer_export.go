@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportBatchSize is how many customers are read from the database at a time during an export
+const exportBatchSize = 1000
+
+// exportColumns are the columns of CSV and XLSX exports
+var exportColumns = []string{"id", "name", "email", "phone", "address", "created_at", "updated_at"}
+
+// customerExporter writes exported customers in one format
+type customerExporter interface {
+	Write(customers []models.Customer) error
+	Finish() error // Writes anything still buffered
+	Close() error  // Releases resources, whether or not the export finished
+}
+
+// exportContentTypes are the Content-Type headers of each export format
+var exportContentTypes = map[string]string{
+	utils.FormatCSV:   "text/csv; charset=utf-8",
+	utils.FormatJSONL: "application/x-ndjson",
+	utils.FormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportCustomers handles streaming all customers as a file download.
+// format is csv (default), jsonl or xlsx; the filters are those of GetAllCustomers,
+// while pagination and sort parameters are ignored (rows come in ID order).
+func (ctrl *CustomerController) ExportCustomers(c *gin.Context) {
+	extendDeadlines(c)
+
+	format := c.DefaultQuery("format", utils.FormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		utils.SendValidationError(c, "Invalid query parameters", "format must be one of csv, jsonl, xlsx")
+		return
+	}
+
+	query, _, err := parseCustomerQuery(c)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid query parameters", err)
+		return
+	}
+
+	exporter, err := newCustomerExporter(format, c.Writer)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to start export"))
+		return
+	}
+	defer exporter.Close()
+
+	filename := fmt.Sprintf("customers-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	rows := 0
+	err = ctrl.customers(c).ExportCustomers(c.Request.Context(), query, exportBatchSize, func(customers []models.Customer) error {
+		if err := exporter.Write(customers); err != nil {
+			return err
+		}
+		rows += len(customers)
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		err = exporter.Finish()
+	}
+
+	if err != nil {
+		// Headers are already sent; aborting leaves the client with a truncated download
+		utils.ErrorContext(c.Request.Context(), "Customer export failed", "rows", rows, "error", err)
+		c.Abort()
+		return
+	}
+	utils.InfoContext(c.Request.Context(), "Exported customers", "organization_id", organizationID(c), "rows", rows, "format", format)
+}
+
+// newCustomerExporter returns the exporter for a format
+func newCustomerExporter(format string, w io.Writer) (customerExporter, error) {
+	switch format {
+	case utils.FormatCSV:
+		return newCSVExporter(w)
+	case utils.FormatJSONL:
+		return &jsonlExporter{encoder: json.NewEncoder(w)}, nil
+	case utils.FormatXLSX:
+		return newXLSXExporter(w)
+	}
+	return nil, utils.ErrUnsupportedFormat
+}
+
+// exportRow formats a customer as the cells of exportColumns
+func exportRow(customer *models.Customer) []string {
+	return []string{
+		strconv.FormatUint(uint64(customer.ID), 10),
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		customer.Address,
+		customer.CreatedAt.Format(time.RFC3339),
+		customer.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// csvExporter writes a header row followed by one row per customer
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) (*csvExporter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return &csvExporter{writer: writer}, nil
+}
+
+func (e *csvExporter) Write(customers []models.Customer) error {
+	for i := range customers {
+		row := exportRow(&customers[i])
+		for j := range row {
+			// Phones are normalized E.164 and must stay as they are for re-import
+			if exportColumns[j] != "phone" {
+				row[j] = escapeFormula(row[j])
+			}
+		}
+		if err := e.writer.Write(row); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Finish() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Close() error {
+	return nil
+}
+
+// formulaPrefixes are the first characters that make spreadsheet tools evaluate a cell
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula keeps spreadsheet tools from evaluating free-text cells as formulas (CSV injection)
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeFormula undoes escapeFormula so an exported file imports back unchanged.
+// Other cells starting with a quote are kept as they are.
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// jsonlExporter writes one JSON customer object per line
+type jsonlExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *jsonlExporter) Write(customers []models.Customer) error {
+	for i := range customers {
+		if err := e.encoder.Encode(&customers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *jsonlExporter) Finish() error {
+	return nil
+}
+
+func (e *jsonlExporter) Close() error {
+	return nil
+}
+
+// xlsxExporter streams rows into a single-sheet workbook. XLSX is a zip archive, so the
+// workbook can only be sent once complete; excelize keeps the rows in a temporary file
+// rather than in memory until then.
+type xlsxExporter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExporter(w io.Writer) (*xlsxExporter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	e := &xlsxExporter{out: w, file: file, stream: stream, row: 1}
+	if err := e.writeRow(exportColumns); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExporter) writeRow(cells []string) error {
+	values := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		values[i] = cell
+	}
+
+	axis, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	e.row++
+	return e.stream.SetRow(axis, values)
+}
+
+func (e *xlsxExporter) Write(customers []models.Customer) error {
+	for i := range customers {
+		if err := e.writeRow(exportRow(&customers[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *xlsxExporter) Finish() error {
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.out)
+	return err
+}
+
+func (e *xlsxExporter) Close() error {
+	return e.file.Close()
+}
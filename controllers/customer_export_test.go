@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerExporters(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	batches := [][]models.Customer{
+		{{ID: 1, Name: "Budi", Email: "budi@example.com", Phone: "+6281234567890", CreatedAt: created, UpdatedAt: created}},
+		{{ID: 2, Name: "=HYPERLINK(\"x\")", Phone: "+6281311112222", Address: "Bandung", CreatedAt: created, UpdatedAt: created}},
+	}
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, output []byte)
+	}{
+		{
+			format: utils.FormatCSV,
+			check: func(t *testing.T, output []byte) {
+				rows, err := utils.ReadSpreadsheet(bytes.NewReader(output), utils.FormatCSV)
+				assert.NoError(t, err)
+				assert.Equal(t, [][]string{
+					exportColumns,
+					{"1", "Budi", "budi@example.com", "+6281234567890", "", "2026-01-02T03:04:05Z", "2026-01-02T03:04:05Z"},
+					{"2", "'=HYPERLINK(\"x\")", "", "+6281311112222", "Bandung", "2026-01-02T03:04:05Z", "2026-01-02T03:04:05Z"},
+				}, rows)
+			},
+		},
+		{
+			format: utils.FormatJSONL,
+			check: func(t *testing.T, output []byte) {
+				lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+				assert.Len(t, lines, 2)
+
+				var customer models.Customer
+				assert.NoError(t, json.Unmarshal([]byte(lines[1]), &customer))
+				assert.Equal(t, uint(2), customer.ID)
+				assert.Equal(t, "=HYPERLINK(\"x\")", customer.Name)
+			},
+		},
+		{
+			format: utils.FormatXLSX,
+			check: func(t *testing.T, output []byte) {
+				rows, err := utils.ReadSpreadsheet(bytes.NewReader(output), utils.FormatXLSX)
+				assert.NoError(t, err)
+				assert.Len(t, rows, 3)
+				assert.Equal(t, exportColumns, rows[0])
+				assert.Equal(t, []string{"2", "=HYPERLINK(\"x\")", "", "+6281311112222", "Bandung"}, rows[2][:5])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var output bytes.Buffer
+			exporter, err := newCustomerExporter(tt.format, &output)
+			assert.NoError(t, err)
+			defer exporter.Close()
+
+			for _, batch := range batches {
+				assert.NoError(t, exporter.Write(batch))
+			}
+			assert.NoError(t, exporter.Finish())
+
+			tt.check(t, output.Bytes())
+		})
+	}
+}
+
+func TestCustomerExport_ImportRoundTrip(t *testing.T) {
+	exported := []models.Customer{
+		{ID: 1, Name: "=HYPERLINK(\"x\")", Email: "budi@example.com", Phone: "+6281234567890", Address: "-Gang 5"},
+		{ID: 2, Name: "'Siti", Phone: "+6281311112222", Address: "@Bandung"},
+	}
+
+	var output bytes.Buffer
+	exporter, err := newCustomerExporter(utils.FormatCSV, &output)
+	assert.NoError(t, err)
+	assert.NoError(t, exporter.Write(exported))
+	assert.NoError(t, exporter.Finish())
+
+	rows, err := utils.ReadSpreadsheet(&output, utils.FormatCSV)
+	assert.NoError(t, err)
+	plan, err := planImport(rows, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, plan.errors)
+
+	assert.Len(t, plan.valid, len(exported))
+	for i, row := range plan.valid {
+		assert.Equal(t, exported[i].Name, row.customer.Name)
+		assert.Equal(t, exported[i].Email, row.customer.Email)
+		assert.Equal(t, exported[i].Phone, row.customer.Phone)
+		assert.Equal(t, exported[i].Address, row.customer.Address)
+	}
+}
@@ -0,0 +1,421 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/metrics"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Limits of a single customer import
+const (
+	maxImportFileSize  = 10 << 20 // 10 MB
+	maxImportRows      = 50000
+	importBatchSize    = 500 // Rows per lookup query and per INSERT
+	importErrorsReport = "customer-import-errors.csv"
+)
+
+// importFields are the customer fields a spreadsheet column can be mapped to
+var importFields = []string{"name", "email", "phone", "address"}
+
+// importRowError is a problem with one spreadsheet row; Row is the 1-based line, the header being row 1
+type importRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// importRow is a validated spreadsheet row
+type importRow struct {
+	line     int
+	customer models.Customer
+}
+
+// importPlan is what an import will do, computed without writing anything
+type importPlan struct {
+	header   []string
+	rows     [][]string // Data rows, for the error report
+	valid    []importRow
+	creates  []models.Customer
+	updates  []models.Customer
+	restored int // Updates that restore a deleted customer
+	errors   []importRowError
+}
+
+// ImportCustomers handles bulk customer import from a CSV or XLSX upload.
+//
+// Form fields:
+//   - file: the .csv or .xlsx file; the first row is the header
+//   - mapping: optional JSON object of customer field to column header, e.g. {"phone":"Mobile"};
+//     unmapped fields use the column with the same name (case-insensitive)
+//   - dry_run: true to validate and report without writing
+//   - restore: true to restore deleted customers that rows match; otherwise such rows are rejected
+//
+// Rows are matched to existing customers by phone, then email, and updated; the rest are
+// created. Invalid rows are skipped and reported. With report=csv the response is a CSV of
+// the rejected rows with an error column instead of JSON. Cells escaped against formula
+// injection by ExportCustomers are unescaped, so an export imports back unchanged.
+func (ctrl *CustomerController) ImportCustomers(c *gin.Context) {
+	extendDeadlines(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+
+	upload, header, err := c.Request.FormFile("file")
+	if err != nil {
+		utils.SendValidationError(c, "Invalid upload", err)
+		return
+	}
+	defer upload.Close()
+
+	format, err := utils.SpreadsheetFormat(header.Filename)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid upload", err)
+		return
+	}
+
+	mapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			utils.SendValidationError(c, "Invalid column mapping", err)
+			return
+		}
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultPostForm("dry_run", c.DefaultQuery("dry_run", "false")))
+	if err != nil {
+		utils.SendValidationError(c, "Invalid dry_run", err)
+		return
+	}
+
+	restore, err := strconv.ParseBool(c.DefaultPostForm("restore", c.DefaultQuery("restore", "false")))
+	if err != nil {
+		utils.SendValidationError(c, "Invalid restore", err)
+		return
+	}
+
+	rows, err := utils.ReadSpreadsheet(upload, format)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid upload", err)
+		return
+	}
+
+	plan, err := planImport(rows, mapping)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid spreadsheet", err)
+		return
+	}
+
+	customers := ctrl.customers(c)
+	existing, err := findExistingCustomers(c.Request.Context(), customers, plan.valid)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to look up existing customers"))
+		return
+	}
+	plan.match(existing, restore)
+
+	if !dryRun {
+		if err := customers.ImportCustomers(c.Request.Context(), plan.creates, plan.updates, importBatchSize); err != nil {
+			c.Error(utils.Internal(err, "Failed to import customers"))
+			return
+		}
+		ctrl.Metrics.CustomersAdded(metrics.SourceImport, len(plan.creates))
+		utils.InfoContext(c.Request.Context(), "Imported customers", "organization_id", organizationID(c),
+			"created", len(plan.creates), "updated", len(plan.updates), "rejected", plan.rejectedRows())
+	}
+
+	if c.Query("report") == utils.FormatCSV {
+		c.Header("X-Import-Created", strconv.Itoa(len(plan.creates)))
+		c.Header("X-Import-Updated", strconv.Itoa(len(plan.updates)))
+		c.Header("X-Import-Restored", strconv.Itoa(plan.restored))
+		c.Header("X-Import-Rejected", strconv.Itoa(plan.rejectedRows()))
+		c.Header("Content-Disposition", `attachment; filename="`+importErrorsReport+`"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", plan.errorReport())
+		return
+	}
+
+	message := "Customers imported successfully"
+	if dryRun {
+		message = "Import validated successfully, nothing was saved"
+	}
+	utils.SendSuccess(c, message, gin.H{
+		"dry_run":    dryRun,
+		"total_rows": len(plan.rows),
+		"created":    len(plan.creates),
+		"updated":    len(plan.updates),
+		"restored":   plan.restored,
+		"rejected":   plan.rejectedRows(),
+		"errors":     plan.errors,
+	})
+}
+
+// planImport maps the spreadsheet columns and validates every data row.
+// It fails only for problems with the file as a whole; row problems end up in plan.errors.
+func planImport(rows [][]string, mapping map[string]string) (*importPlan, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, fmt.Errorf("the file has %d rows, at most %d can be imported at once", len(rows)-1, maxImportRows)
+	}
+
+	columns, err := mapImportColumns(rows[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{header: rows[0], rows: rows[1:]}
+	seenPhones := make(map[string]int)
+	seenEmails := make(map[string]int)
+
+	for i, row := range plan.rows {
+		line := i + 2
+		value := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(unescapeFormula(row[index]))
+		}
+
+		if isBlankRow(row) {
+			continue
+		}
+
+		customer := models.Customer{
+			Name:    value("name"),
+			Email:   strings.ToLower(value("email")),
+			Address: value("address"),
+		}
+		failed := false
+		fail := func(field, message string) {
+			plan.errors = append(plan.errors, importRowError{Row: line, Field: field, Message: message})
+			failed = true
+		}
+
+		if customer.Name == "" {
+			fail("name", "name is required")
+		}
+		if customer.Email != "" && !utils.IsValidEmail(customer.Email) {
+			fail("email", "invalid email format")
+		}
+		if phone, err := utils.NormalizePhone(value("phone")); err != nil {
+			fail("phone", err.Error())
+		} else {
+			customer.Phone = phone
+		}
+
+		if failed {
+			continue
+		}
+
+		if previous, seen := seenPhones[customer.Phone]; seen {
+			fail("phone", fmt.Sprintf("duplicate of row %d", previous))
+		} else if previous, seen := seenEmails[customer.Email]; seen && customer.Email != "" {
+			fail("email", fmt.Sprintf("duplicate of row %d", previous))
+		}
+		if failed {
+			continue
+		}
+
+		seenPhones[customer.Phone] = line
+		if customer.Email != "" {
+			seenEmails[customer.Email] = line
+		}
+		plan.valid = append(plan.valid, importRow{line: line, customer: customer})
+	}
+
+	return plan, nil
+}
+
+// mapImportColumns resolves each customer field to a column index of the header
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int)
+	for field := range mapping {
+		if !containsString(importFields, field) {
+			return nil, fmt.Errorf("mapping: unknown field %q, expected one of %s", field, strings.Join(importFields, ", "))
+		}
+	}
+
+	for _, field := range importFields {
+		column, mapped := mapping[field]
+		if !mapped {
+			column = field
+		}
+		if i, ok := index[strings.ToLower(strings.TrimSpace(column))]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("mapping: column %q for %s not found in the header", column, field)
+		}
+	}
+
+	for _, field := range []string{"name", "phone"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("no column for required field %q, add one or map it", field)
+		}
+	}
+	return columns, nil
+}
+
+// findExistingCustomers looks up, in batches, the customers sharing a phone or email with the rows
+func findExistingCustomers(ctx context.Context, customers repositories.CustomerRepositoryInterface, rows []importRow) ([]models.Customer, error) {
+	var existing []models.Customer
+	for start := 0; start < len(rows); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		var phones, emails []string
+		for _, row := range rows[start:end] {
+			phones = append(phones, row.customer.Phone)
+			if row.customer.Email != "" {
+				emails = append(emails, row.customer.Email)
+			}
+		}
+
+		found, err := customers.FindCustomersByContacts(ctx, phones, emails)
+		if err != nil {
+			return nil, err
+		}
+		existing = append(existing, found...)
+	}
+	return existing, nil
+}
+
+// match splits the valid rows into creates and updates of the existing customers.
+// A row whose phone and email belong to two different customers is rejected, and so is a row
+// matching a deleted customer unless restore is set. Errors end up sorted by row.
+func (p *importPlan) match(existing []models.Customer, restore bool) {
+	defer func() {
+		sort.SliceStable(p.errors, func(i, j int) bool { return p.errors[i].Row < p.errors[j].Row })
+	}()
+
+	byPhone := make(map[string]*models.Customer)
+	byEmail := make(map[string]*models.Customer)
+	for i := range existing {
+		byPhone[existing[i].Phone] = &existing[i]
+		if existing[i].Email != "" {
+			byEmail[strings.ToLower(existing[i].Email)] = &existing[i]
+		}
+	}
+
+	matched := make(map[uint]int)
+	for _, row := range p.valid {
+		phoneMatch := byPhone[row.customer.Phone]
+		emailMatch := byEmail[row.customer.Email]
+
+		target, field := phoneMatch, "phone"
+		if target == nil {
+			target, field = emailMatch, "email"
+		}
+		if phoneMatch != nil && emailMatch != nil && phoneMatch.ID != emailMatch.ID {
+			p.errors = append(p.errors, importRowError{Row: row.line, Field: "email", Message: fmt.Sprintf(
+				"phone belongs to customer %d but email belongs to customer %d", phoneMatch.ID, emailMatch.ID)})
+			continue
+		}
+
+		if target == nil {
+			p.creates = append(p.creates, row.customer)
+			continue
+		}
+		if target.DeletedAt.Valid && !restore {
+			p.errors = append(p.errors, importRowError{Row: row.line, Field: field, Message: fmt.Sprintf(
+				"belongs to deleted customer %d, import with restore=true to restore it", target.ID)})
+			continue
+		}
+		if previous, seen := matched[target.ID]; seen {
+			p.errors = append(p.errors, importRowError{Row: row.line, Message: fmt.Sprintf(
+				"matches the same customer as row %d", previous)})
+			continue
+		}
+		matched[target.ID] = row.line
+
+		updated := *target
+		updated.Name = row.customer.Name
+		updated.Phone = row.customer.Phone
+		if row.customer.Email != "" {
+			updated.Email = row.customer.Email
+		}
+		if row.customer.Address != "" {
+			updated.Address = row.customer.Address
+		}
+		if updated.DeletedAt.Valid {
+			updated.DeletedAt = gorm.DeletedAt{}
+			p.restored++
+		}
+		p.updates = append(p.updates, updated)
+	}
+}
+
+// rejectedRows counts the rows with at least one error
+func (p *importPlan) rejectedRows() int {
+	rows := make(map[int]bool)
+	for _, e := range p.errors {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+// errorReport renders the rejected rows as CSV: the original header and cells plus row and error columns
+func (p *importPlan) errorReport() []byte {
+	messages := make(map[int][]string)
+	var lines []int
+	for _, e := range p.errors {
+		if _, seen := messages[e.Row]; !seen {
+			lines = append(lines, e.Row)
+		}
+		message := e.Message
+		if e.Field != "" {
+			message = e.Field + ": " + message
+		}
+		messages[e.Row] = append(messages[e.Row], message)
+	}
+	sort.Ints(lines)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(append([]string{"row"}, append(append([]string{}, p.header...), "error")...))
+	for _, line := range lines {
+		cells := make([]string, len(p.header))
+		copy(cells, p.rows[line-2])
+		writer.Write(append([]string{strconv.Itoa(line)}, append(cells, strings.Join(messages[line], "; "))...))
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// isBlankRow reports whether every cell of the row is empty
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
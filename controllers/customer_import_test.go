@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPlanImport(t *testing.T) {
+	tests := []struct {
+		name          string
+		csv           string
+		mapping       map[string]string
+		existing      []models.Customer
+		restore       bool
+		expectErr     string
+		expectCreate  []string // Phones of created customers
+		expectUpdate  []uint   // IDs of updated customers
+		expectRestore int
+		expectErrors  []importRowError
+	}{
+		{
+			name:         "Success - Default Columns",
+			csv:          "Name,Email,Phone\nBudi,budi@example.com,0812-3456-7890\nSiti,,+62 813 1111 2222\n",
+			expectCreate: []string{"+6281234567890", "+6281311112222"},
+		},
+		{
+			name:         "Success - Mapped Columns",
+			csv:          "Full Name,Mobile,City\nBudi,081234567890,Bandung\n",
+			mapping:      map[string]string{"name": "Full Name", "phone": "Mobile", "address": "City"},
+			expectCreate: []string{"+6281234567890"},
+		},
+		{
+			name: "Success - Upsert By Phone And Email",
+			csv:  "name,email,phone\nBudi,budi@example.com,081234567890\nSiti,siti@example.com,081311112222\n",
+			existing: []models.Customer{
+				{ID: 7, Name: "Budi Lama", Phone: "+6281234567890"},
+				{ID: 9, Name: "Siti Lama", Email: "SITI@example.com", Phone: "+6281399990000"},
+			},
+			expectUpdate: []uint{7, 9},
+		},
+		{
+			name: "Partial - Invalid And Duplicate Rows",
+			csv:  "name,email,phone\n,a@example.com,081234567890\nBudi,not-an-email,081234567891\nSiti,,12\nAni,,081234567892\nAni Lagi,,0812-3456-7892\n,,\n",
+			expectErrors: []importRowError{
+				{Row: 2, Field: "name", Message: "name is required"},
+				{Row: 3, Field: "email", Message: "invalid email format"},
+				{Row: 4, Field: "phone", Message: `invalid phone number: "12"`},
+				{Row: 6, Field: "phone", Message: "duplicate of row 5"},
+			},
+			expectCreate: []string{"+6281234567892"},
+		},
+		{
+			name: "Partial - Phone And Email Of Different Customers",
+			csv:  "name,email,phone\nBudi,siti@example.com,081234567890\n",
+			existing: []models.Customer{
+				{ID: 7, Phone: "+6281234567890"},
+				{ID: 9, Email: "siti@example.com", Phone: "+6281399990000"},
+			},
+			expectErrors: []importRowError{
+				{Row: 2, Field: "email", Message: "phone belongs to customer 7 but email belongs to customer 9"},
+			},
+		},
+		{
+			name: "Partial - Deleted Customer Without Restore",
+			csv:  "name,email,phone\nBudi,,081234567890\nSiti,siti@example.com,081311112222\n",
+			existing: []models.Customer{
+				{ID: 7, Phone: "+6281234567890", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+				{ID: 9, Email: "siti@example.com", Phone: "+6281399990000", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+			},
+			expectErrors: []importRowError{
+				{Row: 2, Field: "phone", Message: "belongs to deleted customer 7, import with restore=true to restore it"},
+				{Row: 3, Field: "email", Message: "belongs to deleted customer 9, import with restore=true to restore it"},
+			},
+		},
+		{
+			name: "Success - Deleted Customer With Restore",
+			csv:  "name,email,phone\nBudi,,081234567890\n",
+			existing: []models.Customer{
+				{ID: 7, Phone: "+6281234567890", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+			},
+			restore:       true,
+			expectUpdate:  []uint{7},
+			expectRestore: 1,
+		},
+		{
+			name: "Partial - Errors Sorted By Row",
+			csv:  "name,email,phone\nBudi,siti@example.com,081234567890\nAni,bad,081234567892\n",
+			existing: []models.Customer{
+				{ID: 7, Phone: "+6281234567890"},
+				{ID: 9, Email: "siti@example.com", Phone: "+6281399990000"},
+			},
+			expectErrors: []importRowError{
+				{Row: 2, Field: "email", Message: "phone belongs to customer 7 but email belongs to customer 9"},
+				{Row: 3, Field: "email", Message: "invalid email format"},
+			},
+		},
+		{
+			name:      "Failure - Missing Phone Column",
+			csv:       "name,email\nBudi,budi@example.com\n",
+			expectErr: `no column for required field "phone"`,
+		},
+		{
+			name:      "Failure - Mapping To Unknown Column",
+			csv:       "name,phone\nBudi,081234567890\n",
+			mapping:   map[string]string{"phone": "Mobile"},
+			expectErr: `column "Mobile" for phone not found`,
+		},
+		{
+			name:      "Failure - Mapping Unknown Field",
+			csv:       "name,phone\nBudi,081234567890\n",
+			mapping:   map[string]string{"password": "name"},
+			expectErr: `unknown field "password"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := csv.NewReader(strings.NewReader(tt.csv)).ReadAll()
+			assert.NoError(t, err)
+
+			plan, err := planImport(rows, tt.mapping)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			plan.match(tt.existing, tt.restore)
+
+			var created []string
+			for _, customer := range plan.creates {
+				created = append(created, customer.Phone)
+			}
+			var updated []uint
+			for _, customer := range plan.updates {
+				updated = append(updated, customer.ID)
+				assert.False(t, customer.DeletedAt.Valid, "updates are never of deleted customers")
+			}
+
+			assert.Equal(t, tt.expectCreate, created)
+			assert.Equal(t, tt.expectUpdate, updated)
+			assert.Equal(t, tt.expectRestore, plan.restored)
+			assert.Equal(t, tt.expectErrors, plan.errors)
+		})
+	}
+}
+
+func TestImportPlan_ErrorReport(t *testing.T) {
+	rows, _ := csv.NewReader(strings.NewReader("name,email,phone\nBudi,bad,12\nSiti,,081234567890\n")).ReadAll()
+	plan, err := planImport(rows, nil)
+	assert.NoError(t, err)
+
+	report, err := csv.NewReader(strings.NewReader(string(plan.errorReport()))).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"row", "name", "email", "phone", "error"},
+		{"2", "Budi", "bad", "12", `email: invalid email format; phone: invalid phone number: "12"`},
+	}, report)
+}
+
+func TestCustomerController_ImportCustomers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const rows = "name,phone\nBudi Baru,081234567890\nAni,081311112222\nSiti,12\n"
+
+	tests := []struct {
+		name         string
+		filename     string
+		content      string
+		fields       map[string]string
+		query        string
+		deleted      bool // Soft-delete customer 1 before the import
+		expectCode   int
+		expectBody   []string
+		expectHeader map[string]string
+		expectNames  []string // Names of organization 1's live customers afterwards
+	}{
+		{
+			name:        "Success - Creates And Updates",
+			filename:    "customers.csv",
+			content:     rows,
+			expectCode:  http.StatusOK,
+			expectBody:  []string{`"created":1`, `"updated":1`, `"rejected":1`, `"row":4`},
+			expectNames: []string{"Budi Baru", "Ani"},
+		},
+		{
+			name:        "Success - Dry Run Saves Nothing",
+			filename:    "customers.csv",
+			content:     rows,
+			fields:      map[string]string{"dry_run": "true"},
+			expectCode:  http.StatusOK,
+			expectBody:  []string{"nothing was saved", `"created":1`, `"updated":1`},
+			expectNames: []string{"Budi"},
+		},
+		{
+			name:       "Success - Error Report As CSV",
+			filename:   "customers.csv",
+			content:    rows,
+			query:      "report=csv",
+			expectCode: http.StatusOK,
+			expectBody: []string{"row,name,phone,error\n4,Siti,12,"},
+			expectHeader: map[string]string{
+				"Content-Type":      "text/csv; charset=utf-8",
+				"X-Import-Created":  "1",
+				"X-Import-Updated":  "1",
+				"X-Import-Rejected": "1",
+			},
+			expectNames: []string{"Budi Baru", "Ani"},
+		},
+		{
+			name:        "Partial - Deleted Customer Needs Restore",
+			filename:    "customers.csv",
+			content:     rows,
+			deleted:     true,
+			expectCode:  http.StatusOK,
+			expectBody:  []string{`"updated":0`, `"restored":0`, "restore=true"},
+			expectNames: []string{"Ani"},
+		},
+		{
+			name:        "Success - Deleted Customer Restored",
+			filename:    "customers.csv",
+			content:     rows,
+			fields:      map[string]string{"restore": "true"},
+			deleted:     true,
+			expectCode:  http.StatusOK,
+			expectBody:  []string{`"updated":1`, `"restored":1`},
+			expectNames: []string{"Budi Baru", "Ani"},
+		},
+		{
+			name:        "Failure - Unsupported File Type",
+			filename:    "customers.txt",
+			content:     rows,
+			expectCode:  http.StatusBadRequest,
+			expectBody:  []string{"Invalid upload"},
+			expectNames: []string{"Budi"},
+		},
+		{
+			name:        "Failure - File Too Large",
+			filename:    "customers.csv",
+			content:     "name,phone\n" + strings.Repeat("Budi,081234567890\n", maxImportFileSize/18+1),
+			expectCode:  http.StatusBadRequest,
+			expectBody:  []string{"Invalid upload"},
+			expectNames: []string{"Budi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMessageTestDB(t)
+			if tt.deleted {
+				require.NoError(t, db.Delete(&models.Customer{}, 1).Error)
+			}
+			ctrl := NewCustomerController(repositories.NewCustomerRepository(db))
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", tt.filename)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(tt.content))
+			require.NoError(t, err)
+			for key, value := range tt.fields {
+				require.NoError(t, form.WriteField(key, value))
+			}
+			require.NoError(t, form.Close())
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/customers/import?"+tt.query, &body)
+			c.Request.Header.Set("Content-Type", form.FormDataContentType())
+			c.Set("orgID", uint(1))
+
+			ctrl.ImportCustomers(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			for _, expected := range tt.expectBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			for header, expected := range tt.expectHeader {
+				assert.Equal(t, expected, w.Header().Get(header), header)
+			}
+
+			var names []string
+			require.NoError(t, db.Model(&models.Customer{}).Where("organization_id = ?", 1).Order("id").Pluck("name", &names).Error)
+			assert.Equal(t, tt.expectNames, names)
+		})
+	}
+}
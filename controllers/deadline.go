@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+// bulkDeadlineGrace is how long a bulk transfer may keep writing after its request context
+// expires, e.g. to send the error response of an import whose queries timed out
+const bulkDeadlineGrace = 10 * time.Second
+
+// extendDeadlines lifts the server's read and write timeouts for a bulk upload or download,
+// which may legitimately outlast them. The connection deadlines follow the request context's
+// deadline, set from DB_BULK_REQUEST_TIMEOUT, so that timeout alone bounds the transfer;
+// without one the connection has no deadline.
+func extendDeadlines(c *gin.Context) {
+	var deadline time.Time
+	if ctxDeadline, ok := c.Request.Context().Deadline(); ok {
+		deadline = ctxDeadline.Add(bulkDeadlineGrace)
+	}
+
+	controller := http.NewResponseController(c.Writer)
+	for _, set := range []func(time.Time) error{controller.SetReadDeadline, controller.SetWriteDeadline} {
+		if err := set(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			utils.WarningContext(c.Request.Context(), "Failed to extend connection deadline", "error", err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendDeadlines(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		extend    bool
+		expectErr bool
+	}{
+		{name: "Success - Outlasts Write Timeout", extend: true},
+		{name: "Failure - Cut Off By Write Timeout", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/export", func(c *gin.Context) {
+				ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+				defer cancel()
+				c.Request = c.Request.WithContext(ctx)
+				if tt.extend {
+					extendDeadlines(c)
+				}
+				time.Sleep(300 * time.Millisecond) // A slow export, well past the write timeout
+				c.String(http.StatusOK, "done")
+			})
+
+			server := httptest.NewUnstartedServer(router)
+			server.Config.WriteTimeout = 100 * time.Millisecond
+			server.Start()
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/export")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "done", string(body))
+		})
+	}
+}
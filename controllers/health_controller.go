@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/health"
+)
+
+// HealthController serves the liveness and readiness probes. Probe responses are plain
+// JSON rather than the usual response envelope, as load balancers only look at the status code.
+type HealthController struct {
+	Registry *health.Registry
+	Ready    func() bool // False while the server drains before shutdown
+}
+
+// NewHealthController returns a new instance of HealthController
+func NewHealthController(registry *health.Registry, ready func() bool) *HealthController {
+	return &HealthController{Registry: registry, Ready: ready}
+}
+
+// Livez reports that the process is up and serving. It checks no dependencies, so an
+// outage of the database doesn't get every instance restarted.
+func (ctrl *HealthController) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readyz reports whether the instance should receive traffic: it fails while draining
+// and when a critical dependency check fails, and lists each check's status and latency
+func (ctrl *HealthController) Readyz(c *gin.Context) {
+	if !ctrl.Ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	report := ctrl.Registry.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+type MessageController struct {
+	CustomerRepo repositories.CustomerRepositoryInterface
+	MessageRepo  repositories.MessageRepositoryInterface
+	Sender       messaging.MessageSender
+}
+
+// NewMessageController returns a new instance of MessageController
+func NewMessageController(customerRepo repositories.CustomerRepositoryInterface, messageRepo repositories.MessageRepositoryInterface, sender messaging.MessageSender) *MessageController {
+	return &MessageController{CustomerRepo: customerRepo, MessageRepo: messageRepo, Sender: sender}
+}
+
+// SendMessage handles sending a WhatsApp text or template message to a customer.
+// The message is stored before sending so failed attempts stay visible in the history.
+func (ctrl *MessageController) SendMessage(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid customer ID", err)
+		return
+	}
+
+	var req struct {
+		Type     string              `json:"type" binding:"required,oneof=text template"`
+		Text     string              `json:"text"`
+		Template *messaging.Template `json:"template"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	orgID := organizationID(c)
+	customer, err := ctrl.CustomerRepo.ForOrganization(orgID).FindCustomerByID(c.Request.Context(), uint(customerID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	outbound := messaging.OutboundMessage{
+		To:       customer.Phone,
+		Type:     req.Type,
+		Text:     req.Text,
+		Template: req.Template,
+	}
+	if err := outbound.Validate(); err != nil {
+		utils.SendValidationError(c, "Invalid message", err)
+		return
+	}
+
+	message := models.Message{
+		CustomerID: customer.ID,
+		Direction:  models.MessageOutbound,
+		Type:       req.Type,
+		Body:       req.Text,
+		Status:     models.MessageStatusQueued,
+	}
+	if req.Template != nil {
+		message.TemplateName = req.Template.Name
+	}
+
+	messages := ctrl.MessageRepo.ForOrganization(orgID)
+	if err := messages.CreateMessage(c.Request.Context(), &message); err != nil {
+		c.Error(utils.Internal(err, "Failed to save message"))
+		return
+	}
+
+	result, sendErr := ctrl.Sender.Send(c.Request.Context(), outbound)
+	if sendErr != nil {
+		message.Status = models.MessageStatusFailed
+		message.Error = sendErr.Error()
+	} else {
+		now := time.Now()
+		message.Status = models.MessageStatusSent
+		message.ProviderMessageID = &result.ProviderMessageID
+		message.SentAt = &now
+	}
+
+	if err := messages.UpdateMessage(c.Request.Context(), &message); err != nil {
+		utils.ErrorContext(c.Request.Context(), "Failed to update message status", "message_id", message.ID, "error", err)
+	}
+
+	if sendErr != nil {
+		utils.WarningContext(c.Request.Context(), "Failed to send message", "message_id", message.ID, "customer_id", customer.ID, "error", sendErr)
+		if errors.Is(sendErr, messaging.ErrNotConfigured) {
+			utils.SendError(c, "Messaging is not configured", http.StatusServiceUnavailable)
+			return
+		}
+		utils.SendError(c, "Failed to send message", http.StatusBadGateway)
+		return
+	}
+
+	utils.SendCreated(c, "Message sent successfully", gin.H{"message": message})
+}
+
+// GetMessages handles fetching a customer's message history, newest first by default
+func (ctrl *MessageController) GetMessages(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid customer ID", err)
+		return
+	}
+
+	opts, cursorMode, err := parseListOptions(c)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid query parameters", err)
+		return
+	}
+	if opts.Keyset == nil && c.Query("order") == "" {
+		opts.SortDesc = true
+	}
+
+	orgID := organizationID(c)
+	if _, err := ctrl.CustomerRepo.ForOrganization(orgID).FindCustomerByID(c.Request.Context(), uint(customerID)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	messages, result, err := ctrl.MessageRepo.ForOrganization(orgID).GetMessagesForCustomer(c.Request.Context(), uint(customerID), opts)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to fetch messages"))
+		return
+	}
+
+	page, err := buildPage(messages, len(messages), opts, result, cursorMode, func(i int) (string, uint) {
+		if opts.SortBy == "created_at" {
+			return messages[i].CreatedAt.Format(time.RFC3339Nano), messages[i].ID
+		}
+		return "", messages[i].ID
+	})
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to build pagination cursor"))
+		return
+	}
+
+	utils.SendSuccess(c, "Messages fetched successfully", page)
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeSender records the messages it is asked to send and answers with a fixed result
+type fakeSender struct {
+	result messaging.SendResult
+	err    error
+	sent   []messaging.OutboundMessage
+}
+
+func (s *fakeSender) Send(_ context.Context, msg messaging.OutboundMessage) (messaging.SendResult, error) {
+	s.sent = append(s.sent, msg)
+	return s.result, s.err
+}
+
+// newMessageTestDB returns a database with customer 1 in organization 1 and customer 2 in organization 2
+func newMessageTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := test.NewTestDB(t)
+	require.NoError(t, db.Create(&[]models.Organization{{ID: 1, Name: "Acme"}, {ID: 2, Name: "Globex"}}).Error)
+	require.NoError(t, db.Create(&[]models.Customer{
+		{ID: 1, OrganizationID: 1, Name: "Budi", Phone: "+6281234567890"},
+		{ID: 2, OrganizationID: 2, Name: "Sari", Phone: "+6281298765432"},
+	}).Error)
+	return db
+}
+
+func TestMessageController_SendMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		customerID   string
+		request      string
+		sender       messaging.MessageSender
+		expectCode   int
+		expectMsg    string
+		expectStored *models.Message // Status and error of the stored message; nil if none is stored
+	}{
+		{
+			name:         "Success - Sent And Stored",
+			customerID:   "1",
+			request:      `{"type":"text","text":"Halo"}`,
+			sender:       &fakeSender{result: messaging.SendResult{ProviderMessageID: "wamid.1"}},
+			expectCode:   http.StatusCreated,
+			expectMsg:    "Message sent successfully",
+			expectStored: &models.Message{Status: models.MessageStatusSent},
+		},
+		{
+			name:         "Failure - Provider Error",
+			customerID:   "1",
+			request:      `{"type":"text","text":"Halo"}`,
+			sender:       &fakeSender{err: &messaging.ProviderError{StatusCode: 400, Code: 131026, Message: "Message undeliverable"}},
+			expectCode:   http.StatusBadGateway,
+			expectMsg:    "Failed to send message",
+			expectStored: &models.Message{Status: models.MessageStatusFailed, Error: "provider error 131026 (HTTP 400): Message undeliverable"},
+		},
+		{
+			name:         "Failure - Sender Disabled",
+			customerID:   "1",
+			request:      `{"type":"template","template":{"name":"welcome","language":"id"}}`,
+			sender:       messaging.DisabledSender{},
+			expectCode:   http.StatusServiceUnavailable,
+			expectMsg:    "Messaging is not configured",
+			expectStored: &models.Message{Status: models.MessageStatusFailed, Error: messaging.ErrNotConfigured.Error()},
+		},
+		{
+			name:       "Failure - Customer Of Another Organization",
+			customerID: "2",
+			request:    `{"type":"text","text":"Halo"}`,
+			sender:     &fakeSender{},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "Customer not found",
+		},
+		{
+			name:       "Failure - Text Missing",
+			customerID: "1",
+			request:    `{"type":"text"}`,
+			sender:     &fakeSender{},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "text is required for text messages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMessageTestDB(t)
+			ctrl := NewMessageController(repositories.NewCustomerRepository(db), repositories.NewMessageRepository(db), tt.sender)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/customer/"+tt.customerID+"/messages", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: tt.customerID}}
+			c.Set("orgID", uint(1))
+
+			ctrl.SendMessage(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+
+			var stored []models.Message
+			require.NoError(t, db.Find(&stored).Error)
+			if tt.expectStored == nil {
+				assert.Empty(t, stored)
+				if sender, ok := tt.sender.(*fakeSender); ok {
+					assert.Empty(t, sender.sent)
+				}
+				return
+			}
+			require.Len(t, stored, 1)
+			assert.Equal(t, uint(1), stored[0].OrganizationID)
+			assert.Equal(t, uint(1), stored[0].CustomerID)
+			assert.Equal(t, models.MessageOutbound, stored[0].Direction)
+			assert.Equal(t, tt.expectStored.Status, stored[0].Status)
+			assert.Equal(t, tt.expectStored.Error, stored[0].Error)
+			if tt.expectStored.Status == models.MessageStatusSent {
+				require.NotNil(t, stored[0].ProviderMessageID)
+				assert.Equal(t, "wamid.1", *stored[0].ProviderMessageID)
+				assert.NotNil(t, stored[0].SentAt)
+			}
+			if sender, ok := tt.sender.(*fakeSender); ok {
+				require.Len(t, sender.sent, 1)
+				assert.Equal(t, "+6281234567890", sender.sent[0].To)
+			}
+		})
+	}
+}
+
+func TestMessageController_GetMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := newMessageTestDB(t)
+	require.NoError(t, db.Create(&[]models.Message{
+		{OrganizationID: 1, CustomerID: 1, Direction: models.MessageOutbound, Type: "text", Body: "first", Status: models.MessageStatusSent},
+		{OrganizationID: 1, CustomerID: 1, Direction: models.MessageInbound, Type: "text", Body: "second", Status: models.MessageStatusReceived},
+		{OrganizationID: 2, CustomerID: 2, Direction: models.MessageOutbound, Type: "text", Body: "other tenant", Status: models.MessageStatusSent},
+	}).Error)
+	ctrl := NewMessageController(repositories.NewCustomerRepository(db), repositories.NewMessageRepository(db), &fakeSender{})
+
+	tests := []struct {
+		name       string
+		customerID string
+		query      string
+		expectCode int
+		expectBody []string
+	}{
+		{name: "Success - Newest First", customerID: "1", expectCode: http.StatusOK, expectBody: []string{`"body":"second"`, `"body":"first"`}},
+		{name: "Success - Oldest First", customerID: "1", query: "order=asc", expectCode: http.StatusOK, expectBody: []string{`"body":"first"`, `"body":"second"`}},
+		{name: "Failure - Customer Of Another Organization", customerID: "2", expectCode: http.StatusNotFound, expectBody: []string{"Customer not found"}},
+		{name: "Failure - Invalid Query", customerID: "1", query: "limit=0", expectCode: http.StatusBadRequest, expectBody: []string{"limit must be between"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/customer/"+tt.customerID+"/messages?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.customerID}}
+			c.Set("orgID", uint(1))
+
+			ctrl.GetMessages(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			body := w.Body.String()
+			assert.NotContains(t, body, "other tenant")
+			last := -1
+			for _, expected := range tt.expectBody {
+				index := strings.Index(body, expected)
+				require.GreaterOrEqual(t, index, 0, "missing %s in %s", expected, body)
+				assert.Greater(t, index, last, "%s out of order", expected)
+				last = index
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+type OrganizationController struct {
+	OrgRepo     repositories.OrganizationRepositoryInterface
+	UserRepo    repositories.UserRepositoryInterface
+	RoleRepo    repositories.RoleRepositoryInterface
+	Revocations utils.RevocationStore
+}
+
+// NewOrganizationController returns a new instance of OrganizationController
+func NewOrganizationController(orgRepo repositories.OrganizationRepositoryInterface, userRepo repositories.UserRepositoryInterface, roleRepo repositories.RoleRepositoryInterface, revocations utils.RevocationStore) *OrganizationController {
+	return &OrganizationController{OrgRepo: orgRepo, UserRepo: userRepo, RoleRepo: roleRepo, Revocations: revocations}
+}
+
+// CreateOrganization handles creating an organization owned by the current user
+func (ctrl *OrganizationController) CreateOrganization(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	org := models.Organization{Name: req.Name}
+	if err := ctrl.OrgRepo.CreateOrganization(c.Request.Context(), &org, c.GetUint("userID"), utils.RoleOwner); err != nil {
+		c.Error(utils.Internal(err, "Failed to create organization"))
+		return
+	}
+
+	utils.SendCreated(c, "Organization created successfully", gin.H{"organization": org})
+}
+
+// GetMyOrganizations handles listing the organizations the current user belongs to
+func (ctrl *OrganizationController) GetMyOrganizations(c *gin.Context) {
+	memberships, err := ctrl.OrgRepo.GetMemberships(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to fetch organizations"))
+		return
+	}
+
+	utils.SendSuccess(c, "Organizations fetched successfully", gin.H{
+		"memberships":    memberships,
+		"current_org_id": organizationID(c),
+	})
+}
+
+// GetMembers handles listing the members of the current organization
+func (ctrl *OrganizationController) GetMembers(c *gin.Context) {
+	members, err := ctrl.OrgRepo.GetMembers(c.Request.Context(), organizationID(c))
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to fetch members"))
+		return
+	}
+
+	utils.SendSuccess(c, "Members fetched successfully", gin.H{"members": members})
+}
+
+// InvitationTTL is how long an invitation to an organization can be accepted
+const InvitationTTL = 7 * 24 * time.Hour
+
+// InviteMember handles inviting a user to the current organization by email. The user
+// becomes a member only after accepting, so owners can't pull existing accounts in.
+func (ctrl *OrganizationController) InviteMember(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = utils.RoleUser
+	}
+	if !ctrl.validMemberRole(c, req.Role) {
+		return
+	}
+
+	orgID := organizationID(c)
+	email := strings.ToLower(req.Email)
+	if user, err := ctrl.UserRepo.FindByEmail(c.Request.Context(), email); err == nil {
+		if _, err := ctrl.OrgRepo.FindMembership(c.Request.Context(), orgID, user.ID); err == nil {
+			c.Error(utils.Conflict("User is already a member"))
+			return
+		}
+	} else if !errors.Is(err, utils.ErrNotFound) {
+		c.Error(utils.Internal(err, "Failed to fetch user"))
+		return
+	}
+
+	invitation := models.OrganizationInvitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           req.Role,
+		InvitedByID:    c.GetUint("userID"),
+		ExpiresAt:      time.Now().Add(InvitationTTL),
+	}
+	if err := ctrl.OrgRepo.CreateInvitation(c.Request.Context(), &invitation); err != nil {
+		c.Error(utils.Internal(err, "Failed to create invitation"))
+		return
+	}
+
+	utils.SendCreated(c, "Invitation sent successfully", gin.H{"invitation": invitation})
+}
+
+// GetInvitations handles listing the invitations the current organization has sent
+func (ctrl *OrganizationController) GetInvitations(c *gin.Context) {
+	invitations, err := ctrl.OrgRepo.GetInvitations(c.Request.Context(), organizationID(c))
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to fetch invitations"))
+		return
+	}
+
+	utils.SendSuccess(c, "Invitations fetched successfully", gin.H{"invitations": invitations})
+}
+
+// CancelInvitation handles withdrawing an invitation sent by the current organization
+func (ctrl *OrganizationController) CancelInvitation(c *gin.Context) {
+	invitationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.SendBadRequest(c, "Invalid invitation ID")
+		return
+	}
+
+	invitation, err := ctrl.OrgRepo.FindInvitationByID(c.Request.Context(), uint(invitationID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if invitation.OrganizationID != organizationID(c) {
+		c.Error(utils.NotFound("Invitation not found"))
+		return
+	}
+
+	if err := ctrl.OrgRepo.DeleteInvitation(c.Request.Context(), invitation.ID); err != nil {
+		c.Error(utils.Internal(err, "Failed to cancel invitation"))
+		return
+	}
+
+	utils.SendSuccess(c, "Invitation cancelled successfully", nil)
+}
+
+// GetMyInvitations handles listing the pending invitations sent to the current user's email
+func (ctrl *OrganizationController) GetMyInvitations(c *gin.Context) {
+	user, err := ctrl.UserRepo.FindByID(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	invitations, err := ctrl.OrgRepo.GetInvitationsForEmail(c.Request.Context(), user.Email)
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to fetch invitations"))
+		return
+	}
+
+	utils.SendSuccess(c, "Invitations fetched successfully", gin.H{"invitations": invitations})
+}
+
+// AcceptInvitation handles the current user joining an organization they were invited to
+func (ctrl *OrganizationController) AcceptInvitation(c *gin.Context) {
+	invitation, ok := ctrl.ownInvitation(c)
+	if !ok {
+		return
+	}
+	if invitation.IsExpired() {
+		c.Error(utils.Validation("Invitation has expired", nil))
+		return
+	}
+
+	member, err := ctrl.OrgRepo.AcceptInvitation(c.Request.Context(), invitation, c.GetUint("userID"))
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to accept invitation"))
+		return
+	}
+
+	utils.SendCreated(c, "Invitation accepted successfully", gin.H{"member": member})
+}
+
+// DeclineInvitation handles the current user turning down an invitation
+func (ctrl *OrganizationController) DeclineInvitation(c *gin.Context) {
+	invitation, ok := ctrl.ownInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.OrgRepo.DeleteInvitation(c.Request.Context(), invitation.ID); err != nil {
+		c.Error(utils.Internal(err, "Failed to decline invitation"))
+		return
+	}
+
+	utils.SendSuccess(c, "Invitation declined successfully", nil)
+}
+
+// ownInvitation loads the invitation named by the :id parameter if it was sent to the current
+// user's email. Invitations to other addresses are reported as not found.
+func (ctrl *OrganizationController) ownInvitation(c *gin.Context) (*models.OrganizationInvitation, bool) {
+	invitationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.SendBadRequest(c, "Invalid invitation ID")
+		return nil, false
+	}
+
+	user, err := ctrl.UserRepo.FindByID(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	invitation, err := ctrl.OrgRepo.FindInvitationByID(c.Request.Context(), uint(invitationID))
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		c.Error(utils.NotFound("Invitation not found"))
+		return nil, false
+	}
+	return invitation, true
+}
+
+// UpdateMemberRole handles changing the role of a member of the current organization
+func (ctrl *OrganizationController) UpdateMemberRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		utils.SendBadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	if !ctrl.validMemberRole(c, req.Role) {
+		return
+	}
+
+	if err := ctrl.OrgRepo.UpdateMemberRole(c.Request.Context(), organizationID(c), uint(userID), req.Role); err != nil {
+		c.Error(utils.Internal(err, "Failed to update member role"))
+		return
+	}
+
+	ctrl.revokeSessions(c.Request.Context(), uint(userID))
+	utils.SendSuccess(c, "Member role updated successfully", nil)
+}
+
+// RemoveMember handles removing a user from the current organization
+func (ctrl *OrganizationController) RemoveMember(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		utils.SendBadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := ctrl.OrgRepo.RemoveMember(c.Request.Context(), organizationID(c), uint(userID)); err != nil {
+		c.Error(utils.Internal(err, "Failed to remove member"))
+		return
+	}
+
+	ctrl.revokeSessions(c.Request.Context(), uint(userID))
+	utils.SendSuccess(c, "Member removed successfully", nil)
+}
+
+// validMemberRole checks that role exists and can be granted within an organization.
+// The platform-wide admin role can only be assigned through the admin endpoints, and so
+// can any role managing roles: its holders could grant themselves every permission.
+func (ctrl *OrganizationController) validMemberRole(c *gin.Context, role string) bool {
+	if role == utils.RoleAdmin {
+		utils.SendValidationError(c, "Invalid role", "the admin role can't be granted within an organization")
+		return false
+	}
+
+	found, err := ctrl.RoleRepo.FindRoleByName(c.Request.Context(), role)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			utils.SendValidationError(c, "Invalid role", fmt.Sprintf("role %q does not exist", role))
+			return false
+		}
+		c.Error(utils.Internal(err, "Failed to fetch role"))
+		return false
+	}
+
+	if utils.Grants(found.PermissionNames(), utils.PermRolesManage) {
+		utils.SendValidationError(c, "Invalid role", fmt.Sprintf("role %q manages roles and can't be granted within an organization", role))
+		return false
+	}
+	return true
+}
+
+// revokeSessions revokes a user's access tokens so membership changes apply on their next refresh
+func (ctrl *OrganizationController) revokeSessions(ctx context.Context, userID uint) {
+	if err := ctrl.Revocations.RevokeUserTokens(ctx, userID, time.Now()); err != nil {
+		utils.WarningContext(ctx, "Failed to revoke tokens after membership change", "user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrganizationController_InviteMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		request    string
+		mockSetup  func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:    "Success - Existing Account Invited, Not Added",
+			request: `{"email":"Member@Example.com"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "user").Return(&models.Role{Name: "user"}, nil).Once()
+				userRepo.On("FindByEmail", mock.Anything, "member@example.com").Return(&models.User{ID: 8, Email: "member@example.com"}, nil).Once()
+				orgRepo.On("FindMembership", mock.Anything, uint(3), uint(8)).Return(nil, utils.NotFound("Member not found")).Once()
+				orgRepo.On("CreateInvitation", mock.Anything, mock.MatchedBy(func(i *models.OrganizationInvitation) bool {
+					return i.OrganizationID == 3 && i.Email == "member@example.com" && i.Role == "user" && i.InvitedByID == 7
+				})).Return(nil).Once()
+			},
+			expectCode: http.StatusCreated,
+			expectMsg:  "Invitation sent successfully",
+		},
+		{
+			name:    "Success - Unknown Email",
+			request: `{"email":"new@example.com","role":"owner"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "owner").Return(&models.Role{Name: "owner"}, nil).Once()
+				userRepo.On("FindByEmail", mock.Anything, "new@example.com").Return(nil, utils.NotFound("User not found")).Once()
+				orgRepo.On("CreateInvitation", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectCode: http.StatusCreated,
+			expectMsg:  "Invitation sent successfully",
+		},
+		{
+			name:    "Failure - Already A Member",
+			request: `{"email":"member@example.com"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "user").Return(&models.Role{Name: "user"}, nil).Once()
+				userRepo.On("FindByEmail", mock.Anything, "member@example.com").Return(&models.User{ID: 8}, nil).Once()
+				orgRepo.On("FindMembership", mock.Anything, uint(3), uint(8)).Return(&models.OrganizationMember{}, nil).Once()
+			},
+			expectCode: http.StatusConflict,
+			expectMsg:  "User is already a member",
+		},
+		{
+			name:    "Failure - Admin Role",
+			request: `{"email":"member@example.com","role":"admin"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository) {
+			},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid role",
+		},
+		{
+			name:    "Failure - Role Managing Roles",
+			request: `{"email":"member@example.com","role":"deputy"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "deputy").
+					Return(&models.Role{Name: "deputy", Permissions: []models.Permission{{Name: utils.PermRolesManage}}}, nil).Once()
+			},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "manages roles",
+		},
+		{
+			name:    "Failure - Already Invited",
+			request: `{"email":"new@example.com"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, userRepo *test.MockUserRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "user").Return(&models.Role{Name: "user"}, nil).Once()
+				userRepo.On("FindByEmail", mock.Anything, "new@example.com").Return(nil, utils.NotFound("User not found")).Once()
+				orgRepo.On("CreateInvitation", mock.Anything, mock.Anything).Return(utils.Conflict("Invitation already exists")).Once()
+			},
+			expectCode: http.StatusConflict,
+			expectMsg:  "Invitation already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgRepo := new(test.MockOrganizationRepository)
+			userRepo := new(test.MockUserRepository)
+			roleRepo := new(test.MockRoleRepository)
+			tt.mockSetup(orgRepo, userRepo, roleRepo)
+			ctrl := OrganizationController{OrgRepo: orgRepo, UserRepo: userRepo, RoleRepo: roleRepo}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/organization/invitations", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("userID", uint(7))
+			c.Set("orgID", uint(3))
+
+			ctrl.InviteMember(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			orgRepo.AssertExpectations(t)
+			userRepo.AssertExpectations(t)
+			roleRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationController_AcceptInvitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	invitation := func(email string, expiresIn time.Duration) *models.OrganizationInvitation {
+		return &models.OrganizationInvitation{ID: 5, OrganizationID: 3, Email: email, Role: "user", ExpiresAt: time.Now().Add(expiresIn)}
+	}
+
+	tests := []struct {
+		name       string
+		mockSetup  func(orgRepo *test.MockOrganizationRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name: "Success - Joins Organization",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				inv := invitation("member@example.com", time.Hour)
+				orgRepo.On("FindInvitationByID", mock.Anything, uint(5)).Return(inv, nil).Once()
+				orgRepo.On("AcceptInvitation", mock.Anything, inv, uint(8)).
+					Return(&models.OrganizationMember{OrganizationID: 3, UserID: 8, Role: "user"}, nil).Once()
+			},
+			expectCode: http.StatusCreated,
+			expectMsg:  "Invitation accepted successfully",
+		},
+		{
+			name: "Failure - Invitation For Another Email",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("FindInvitationByID", mock.Anything, uint(5)).Return(invitation("someone@example.com", time.Hour), nil).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "Invitation not found",
+		},
+		{
+			name: "Failure - Expired",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("FindInvitationByID", mock.Anything, uint(5)).Return(invitation("member@example.com", -time.Hour), nil).Once()
+			},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invitation has expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgRepo := new(test.MockOrganizationRepository)
+			userRepo := new(test.MockUserRepository)
+			userRepo.On("FindByID", mock.Anything, uint(8)).Return(&models.User{ID: 8, Email: "Member@example.com"}, nil).Once()
+			tt.mockSetup(orgRepo)
+			ctrl := OrganizationController{OrgRepo: orgRepo, UserRepo: userRepo}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/invitations/5/accept", nil)
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+			c.Set("userID", uint(8))
+
+			ctrl.AcceptInvitation(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			orgRepo.AssertExpectations(t)
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationController_CancelInvitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		mockSetup  func(orgRepo *test.MockOrganizationRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name: "Success - Own Organization's Invitation",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("FindInvitationByID", mock.Anything, uint(5)).Return(&models.OrganizationInvitation{ID: 5, OrganizationID: 3}, nil).Once()
+				orgRepo.On("DeleteInvitation", mock.Anything, uint(5)).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "Invitation cancelled successfully",
+		},
+		{
+			name: "Failure - Another Organization's Invitation",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("FindInvitationByID", mock.Anything, uint(5)).Return(&models.OrganizationInvitation{ID: 5, OrganizationID: 4}, nil).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "Invitation not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgRepo := new(test.MockOrganizationRepository)
+			tt.mockSetup(orgRepo)
+			ctrl := OrganizationController{OrgRepo: orgRepo}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/api/organization/invitations/5", nil)
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+			c.Set("orgID", uint(3))
+
+			ctrl.CancelInvitation(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			orgRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationController_UpdateMemberRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		userID     string
+		request    string
+		mockSetup  func(orgRepo *test.MockOrganizationRepository, roleRepo *test.MockRoleRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:    "Success - Role Changed In Current Organization",
+			userID:  "8",
+			request: `{"role":"owner"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "owner").Return(&models.Role{Name: "owner"}, nil).Once()
+				orgRepo.On("UpdateMemberRole", mock.Anything, uint(3), uint(8), "owner").Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "Member role updated successfully",
+		},
+		{
+			name:    "Failure - Not A Member Of Current Organization",
+			userID:  "9",
+			request: `{"role":"user"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "user").Return(&models.Role{Name: "user"}, nil).Once()
+				orgRepo.On("UpdateMemberRole", mock.Anything, uint(3), uint(9), "user").Return(utils.NotFound("Member not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "Member not found",
+		},
+		{
+			name:       "Failure - Admin Role",
+			userID:     "8",
+			request:    `{"role":"admin"}`,
+			mockSetup:  func(orgRepo *test.MockOrganizationRepository, roleRepo *test.MockRoleRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid role",
+		},
+		{
+			name:    "Failure - Role With Every Permission",
+			userID:  "8",
+			request: `{"role":"superuser"}`,
+			mockSetup: func(orgRepo *test.MockOrganizationRepository, roleRepo *test.MockRoleRepository) {
+				roleRepo.On("FindRoleByName", mock.Anything, "superuser").
+					Return(&models.Role{Name: "superuser", Permissions: []models.Permission{{Name: "*"}}}, nil).Once()
+			},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "manages roles",
+		},
+		{
+			name:       "Failure - Invalid User ID",
+			userID:     "abc",
+			request:    `{"role":"user"}`,
+			mockSetup:  func(orgRepo *test.MockOrganizationRepository, roleRepo *test.MockRoleRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid user ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgRepo := new(test.MockOrganizationRepository)
+			roleRepo := new(test.MockRoleRepository)
+			revocations := utils.NewMemoryRevocationStore(time.Minute)
+			defer revocations.Close()
+			tt.mockSetup(orgRepo, roleRepo)
+			ctrl := OrganizationController{OrgRepo: orgRepo, RoleRepo: roleRepo, Revocations: revocations}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/organization/members/"+tt.userID, bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "userId", Value: tt.userID}}
+			c.Set("orgID", uint(3))
+
+			ctrl.UpdateMemberRole(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			orgRepo.AssertExpectations(t)
+			roleRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationController_RemoveMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		mockSetup  func(orgRepo *test.MockOrganizationRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name: "Success - Removed From Current Organization",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("RemoveMember", mock.Anything, uint(3), uint(8)).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "Member removed successfully",
+		},
+		{
+			name: "Failure - Member Of Another Organization",
+			mockSetup: func(orgRepo *test.MockOrganizationRepository) {
+				orgRepo.On("RemoveMember", mock.Anything, uint(3), uint(8)).Return(utils.NotFound("Member not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "Member not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgRepo := new(test.MockOrganizationRepository)
+			revocations := utils.NewMemoryRevocationStore(time.Minute)
+			defer revocations.Close()
+			tt.mockSetup(orgRepo)
+			ctrl := OrganizationController{OrgRepo: orgRepo, Revocations: revocations}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/api/organization/members/8", nil)
+			c.Params = gin.Params{{Key: "userId", Value: "8"}}
+			c.Set("orgID", uint(3))
+
+			ctrl.RemoveMember(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			orgRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationController_GetMembers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orgRepo := new(test.MockOrganizationRepository)
+	orgRepo.On("GetMembers", mock.Anything, uint(3)).Return([]models.OrganizationMember{{OrganizationID: 3, UserID: 7, Role: "owner"}}, nil).Once()
+	ctrl := OrganizationController{OrgRepo: orgRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/organization/members", nil)
+	c.Set("orgID", uint(3))
+
+	ctrl.GetMembers(c)
+	middleware.RenderError(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"organization_id":3`)
+	orgRepo.AssertExpectations(t)
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+// maxPageLimit caps how many rows a single list request can return
+const maxPageLimit = 100
+
+// parseListOptions reads the pagination and sort parameters shared by list endpoints.
+//
+// Offset mode (default): page, limit, sort, order; include_total defaults to true.
+// Cursor mode: pagination=cursor for the first page, then cursor=<next_cursor|prev_cursor>;
+// the cursor carries the sort, and include_total defaults to false.
+func parseListOptions(c *gin.Context) (repositories.ListOptions, bool, error) {
+	var opts repositories.ListOptions
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return opts, false, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	opts.Limit = limit
+
+	cursorMode := c.Query("pagination") == "cursor" || c.Query("cursor") != ""
+
+	if token := c.Query("cursor"); token != "" {
+		cursor, err := utils.DecodeCursor(token)
+		if err != nil {
+			return opts, true, err
+		}
+		opts.SortBy = cursor.SortBy
+		opts.SortDesc = cursor.SortDesc
+		opts.Keyset = &repositories.Keyset{Value: cursor.Value, ID: cursor.ID, Backward: cursor.Backward}
+	} else {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			return opts, cursorMode, errors.New("page must be a positive integer")
+		}
+		if !cursorMode {
+			opts.Offset = (page - 1) * limit
+		}
+
+		opts.SortBy = c.DefaultQuery("sort", "id")
+		switch strings.ToLower(c.DefaultQuery("order", "asc")) {
+		case "asc":
+		case "desc":
+			opts.SortDesc = true
+		default:
+			return opts, cursorMode, errors.New("order must be asc or desc")
+		}
+	}
+
+	includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", strconv.FormatBool(!cursorMode)))
+	if err != nil {
+		return opts, cursorMode, errors.New("include_total must be true or false")
+	}
+	opts.SkipCount = !includeTotal
+
+	return opts, cursorMode, nil
+}
+
+// keysetOf returns the cursor position (sort key and ID) of the row at index i
+type keysetOf func(i int) (value string, id uint)
+
+// buildPage assembles the list response data, adding next/prev cursors in cursor mode
+func buildPage(data interface{}, count int, opts repositories.ListOptions, result repositories.PageResult, cursorMode bool, keyset keysetOf) (utils.Page, error) {
+	page := utils.Page{Data: data, TotalCount: result.TotalCount}
+	if !cursorMode || count == 0 {
+		return page, nil
+	}
+
+	backward := opts.Keyset != nil && opts.Keyset.Backward
+	// Moving forward, a previous page exists if we came from a cursor; moving back, a next page always does
+	hasNext := (!backward && result.HasMore) || backward
+	hasPrev := (backward && result.HasMore) || (!backward && opts.Keyset != nil)
+
+	encode := func(i int, backward bool) (string, error) {
+		value, id := keyset(i)
+		return utils.EncodeCursor(utils.Cursor{
+			SortBy:   opts.SortBy,
+			SortDesc: opts.SortDesc,
+			Value:    value,
+			ID:       id,
+			Backward: backward,
+		})
+	}
+
+	var err error
+	if hasNext {
+		if page.NextCursor, err = encode(count-1, false); err != nil {
+			return page, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = encode(0, true); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
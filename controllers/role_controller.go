@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+type RoleController struct {
+	RoleRepo    repositories.RoleRepositoryInterface
+	UserRepo    repositories.UserRepositoryInterface
+	Policy      *utils.PolicyEngine
+	Revocations utils.RevocationStore
+}
+
+// NewRoleController returns a new instance of RoleController
+func NewRoleController(roleRepo repositories.RoleRepositoryInterface, userRepo repositories.UserRepositoryInterface, policy *utils.PolicyEngine, revocations utils.RevocationStore) *RoleController {
+	return &RoleController{RoleRepo: roleRepo, UserRepo: userRepo, Policy: policy, Revocations: revocations}
+}
+
+// GetAllRoles handles listing roles with their permissions
+func (ctrl *RoleController) GetAllRoles(c *gin.Context) {
+	roles, err := ctrl.RoleRepo.GetAllRoles(c.Request.Context())
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to fetch roles"))
+		return
+	}
+
+	utils.SendSuccess(c, "Roles fetched successfully", gin.H{
+		"roles":       roles,
+		"permissions": utils.AllPermissions,
+	})
+}
+
+// CreateRole handles creating a role with an initial set of permissions
+func (ctrl *RoleController) CreateRole(c *gin.Context) {
+	var req struct {
+		Name        string   `json:"name" binding:"required,max=50"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	if err := validatePermissions(req.Permissions); err != nil {
+		utils.SendValidationError(c, "Invalid permissions", err)
+		return
+	}
+
+	// A taken name fails on the unique index and is reported as a conflict
+	role := models.Role{Name: req.Name, Description: req.Description}
+	if err := ctrl.RoleRepo.CreateRole(c.Request.Context(), &role, req.Permissions); err != nil {
+		c.Error(utils.Internal(err, "Failed to create role"))
+		return
+	}
+
+	utils.SendCreated(c, "Role created successfully", gin.H{"role": role})
+}
+
+// UpdateRolePermissions handles replacing the permissions of a role
+func (ctrl *RoleController) UpdateRolePermissions(c *gin.Context) {
+	var req struct {
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	if err := validatePermissions(req.Permissions); err != nil {
+		utils.SendValidationError(c, "Invalid permissions", err)
+		return
+	}
+
+	role, err := ctrl.RoleRepo.FindRoleByName(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctrl.RoleRepo.SetRolePermissions(c.Request.Context(), role, req.Permissions); err != nil {
+		c.Error(utils.Internal(err, "Failed to set role permissions"))
+		return
+	}
+	ctrl.Policy.Invalidate(role.Name)
+
+	utils.SendSuccess(c, "Role permissions updated successfully", gin.H{"role": role})
+}
+
+// AssignUserRole handles making a user a platform admin or turning them back into a regular user.
+// The user's current access tokens are revoked so the new role applies on their next refresh.
+func (ctrl *RoleController) AssignUserRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.SendBadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, "Invalid request data", err)
+		return
+	}
+
+	// Other roles only apply through an organization membership, which login reads instead
+	if req.Role != utils.RoleAdmin && req.Role != utils.RoleUser {
+		utils.SendValidationError(c, "Invalid role", fmt.Sprintf(
+			"role %q applies per organization, assign it through the organization's members instead", req.Role))
+		return
+	}
+
+	user, err := ctrl.UserRepo.FindByID(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user.Role = req.Role
+	if err := ctrl.UserRepo.UpdateUser(c.Request.Context(), user); err != nil {
+		c.Error(utils.Internal(err, "Failed to update user role"))
+		return
+	}
+
+	if err := ctrl.Revocations.RevokeUserTokens(c.Request.Context(), user.ID, time.Now()); err != nil {
+		utils.WarningContext(c.Request.Context(), "Failed to revoke tokens after role change", "user_id", user.ID, "error", err)
+	}
+
+	utils.SendSuccess(c, "User role updated successfully", gin.H{"user": user})
+}
+
+// validatePermissions rejects permission names the application doesn't know about
+func validatePermissions(permissions []string) error {
+	for _, name := range permissions {
+		if !utils.IsKnownPermission(name) {
+			return fmt.Errorf("unknown permission %q", name)
+		}
+	}
+	return nil
+}
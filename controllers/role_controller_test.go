@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoleController_AssignUserRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		userID     string
+		request    string
+		mockSetup  func(roleRepo *test.MockRoleRepository, userRepo *test.MockUserRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:    "Success - Role Assigned",
+			userID:  "2",
+			request: `{"role":"admin"}`,
+			mockSetup: func(roleRepo *test.MockRoleRepository, userRepo *test.MockUserRepository) {
+				userRepo.On("FindByID", mock.Anything, uint(2)).Return(&models.User{ID: 2, Role: "user"}, nil).Once()
+				userRepo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.Role == "admin" })).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "User role updated successfully",
+		},
+		{
+			name:       "Failure - Invalid User ID",
+			userID:     "abc",
+			request:    `{"role":"admin"}`,
+			mockSetup:  func(roleRepo *test.MockRoleRepository, userRepo *test.MockUserRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid user ID",
+		},
+		{
+			name:       "Failure - Unknown Role",
+			userID:     "2",
+			request:    `{"role":"superuser"}`,
+			mockSetup:  func(roleRepo *test.MockRoleRepository, userRepo *test.MockUserRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid role",
+		},
+		{
+			name:       "Failure - Organization Role",
+			userID:     "2",
+			request:    `{"role":"owner"}`,
+			mockSetup:  func(roleRepo *test.MockRoleRepository, userRepo *test.MockUserRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "applies per organization",
+		},
+		{
+			name:    "Failure - User Not Found",
+			userID:  "9",
+			request: `{"role":"admin"}`,
+			mockSetup: func(roleRepo *test.MockRoleRepository, userRepo *test.MockUserRepository) {
+				userRepo.On("FindByID", mock.Anything, uint(9)).Return(nil, utils.NotFound("User not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "User not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roleRepo := new(test.MockRoleRepository)
+			userRepo := new(test.MockUserRepository)
+			revocations := utils.NewMemoryRevocationStore(time.Minute)
+			defer revocations.Close()
+
+			ctrl := RoleController{
+				RoleRepo:    roleRepo,
+				UserRepo:    userRepo,
+				Policy:      utils.NewPolicyEngine(roleRepo, time.Minute),
+				Revocations: revocations,
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/admin/users/"+tt.userID+"/role", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: tt.userID}}
+
+			tt.mockSetup(roleRepo, userRepo)
+
+			ctrl.AssignUserRole(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			roleRepo.AssertExpectations(t)
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoleController_CreateRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		request    string
+		mockSetup  func(roleRepo *test.MockRoleRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:    "Success - Role Created With Permissions",
+			request: `{"name":"support","permissions":["customers:read","messages:*"]}`,
+			mockSetup: func(roleRepo *test.MockRoleRepository) {
+				roleRepo.On("CreateRole", mock.Anything, mock.MatchedBy(func(r *models.Role) bool { return r.Name == "support" }),
+					[]string{"customers:read", "messages:*"}).Return(nil).Once()
+			},
+			expectCode: http.StatusCreated,
+			expectMsg:  "Role created successfully",
+		},
+		{
+			name:       "Failure - Unknown Permission",
+			request:    `{"name":"support","permissions":["billing:read"]}`,
+			mockSetup:  func(roleRepo *test.MockRoleRepository) {},
+			expectCode: http.StatusBadRequest,
+			expectMsg:  "Invalid permissions",
+		},
+		{
+			name:    "Failure - Name Taken",
+			request: `{"name":"admin","permissions":[]}`,
+			mockSetup: func(roleRepo *test.MockRoleRepository) {
+				roleRepo.On("CreateRole", mock.Anything, mock.Anything, []string{}).Return(utils.Conflict("Role already exists")).Once()
+			},
+			expectCode: http.StatusConflict,
+			expectMsg:  "Role already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roleRepo := new(test.MockRoleRepository)
+			tt.mockSetup(roleRepo)
+			ctrl := RoleController{RoleRepo: roleRepo}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/admin/roles", bytes.NewBufferString(tt.request))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			ctrl.CreateRole(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			roleRepo.AssertExpectations(t)
+		})
+	}
+}
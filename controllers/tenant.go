@@ -0,0 +1,12 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// organizationID returns the organization the authenticated request is scoped to.
+// Users without a membership get 0, which all of them share, so it is no tenant at all:
+// routes that read or write tenant data run behind middleware.RequireOrganization.
+func organizationID(c *gin.Context) uint {
+	return c.GetUint("orgID")
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/metabbe3/go-backend/models"
@@ -19,21 +21,26 @@ func NewUserController(userRepo repositories.UserRepositoryInterface, hasher uti
 	return &UserController{UserRepo: userRepo, Hasher: hasher}
 }
 
-// CreateUser handles user creation
+// users returns the user repository scoped to the request's organization
+func (ctrl *UserController) users(c *gin.Context) repositories.UserRepositoryInterface {
+	return ctrl.UserRepo.ForOrganization(organizationID(c))
+}
+
+// CreateUser handles user creation; the new user joins the current organization
 func (ctrl *UserController) CreateUser(c *gin.Context) {
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=8"`
+		Password string `json:"password" binding:"required,password"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, "Invalid request data", err.Error())
+		utils.SendValidationError(c, "Invalid request data", err)
 		return
 	}
 
 	hashedPassword, err := ctrl.Hasher.HashPassword(req.Password)
 	if err != nil {
-		utils.SendInternalServerError(c, "Failed to hash password")
+		c.Error(utils.Internal(err, "Failed to hash password"))
 		return
 	}
 
@@ -42,8 +49,8 @@ func (ctrl *UserController) CreateUser(c *gin.Context) {
 		Password: hashedPassword,
 	}
 
-	if err := ctrl.UserRepo.CreateUser(&user); err != nil {
-		utils.SendInternalServerError(c, "Failed to create user")
+	if err := ctrl.users(c).CreateUser(c.Request.Context(), &user); err != nil {
+		c.Error(utils.Internal(err, "Failed to create user"))
 		return
 	}
 
@@ -54,37 +61,39 @@ func (ctrl *UserController) CreateUser(c *gin.Context) {
 func (ctrl *UserController) GetUser(c *gin.Context) {
 	userEmail := c.Param("email") // Assuming email is passed as a parameter in the URL
 
-	user, err := ctrl.UserRepo.FindByEmail(userEmail) // Search by email
+	user, err := ctrl.users(c).FindByEmail(c.Request.Context(), userEmail) // Search by email
 	if err != nil {
-		utils.SendNotFound(c, "User not found")
+		c.Error(err)
 		return
 	}
 
 	utils.SendSuccess(c, "User details fetched successfully", gin.H{"user": user})
 }
 
-// UpdateUser handles updating user details by email
+// UpdateUser handles updating user details by email. Users are global accounts, so only
+// the account holder or a platform admin may change them; an organization role, even
+// owner, grants no control over a member's credentials.
 func (ctrl *UserController) UpdateUser(c *gin.Context) {
 	userEmail := c.Param("email")
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=8"`
+		Password string `json:"password" binding:"required,password"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, "Invalid request data", err.Error())
+		utils.SendValidationError(c, "Invalid request data", err)
 		return
 	}
 
-	hashedPassword, err := ctrl.Hasher.HashPassword(req.Password)
+	user, err := ctrl.accountToUpdate(c, userEmail)
 	if err != nil {
-		utils.SendInternalServerError(c, "Failed to hash password")
+		c.Error(err)
 		return
 	}
 
-	user, err := ctrl.UserRepo.FindByEmail(userEmail)
+	hashedPassword, err := ctrl.Hasher.HashPassword(req.Password)
 	if err != nil {
-		utils.SendNotFound(c, "User not found")
+		c.Error(utils.Internal(err, "Failed to hash password"))
 		return
 	}
 
@@ -96,14 +105,32 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 		user.Password = hashedPassword
 	}
 
-	if err := ctrl.UserRepo.UpdateUser(user); err != nil {
-		utils.SendInternalServerError(c, "Failed to update user")
+	if err := ctrl.UserRepo.UpdateUser(c.Request.Context(), user); err != nil {
+		c.Error(utils.Internal(err, "Failed to update user"))
 		return
 	}
 
 	utils.SendSuccess(c, "User updated successfully", gin.H{"user": user})
 }
 
+// accountToUpdate returns the account a request may update: any account for platform
+// admins, otherwise only the caller's own. Other users' emails are refused without
+// looking them up, so the response doesn't reveal whether they exist.
+func (ctrl *UserController) accountToUpdate(c *gin.Context, email string) (*models.User, error) {
+	if c.GetString("role") == utils.RoleAdmin {
+		return ctrl.UserRepo.FindByEmail(c.Request.Context(), email)
+	}
+
+	self, err := ctrl.UserRepo.FindByID(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(self.Email, email) {
+		return nil, utils.Forbidden("You can only update your own account")
+	}
+	return self, nil
+}
+
 // DeleteUser handles deleting a user by ID
 func (ctrl *UserController) DeleteUser(c *gin.Context) {
 	// Get user ID from URL parameters
@@ -114,9 +141,9 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 	}
 
 	// Call the DeleteUser method from UserRepository by ID
-	err = ctrl.UserRepo.DeleteUser(uint(userID))
+	err = ctrl.users(c).DeleteUser(c.Request.Context(), uint(userID))
 	if err != nil {
-		utils.SendNotFound(c, "User not found")
+		c.Error(err)
 		return
 	}
 
@@ -124,20 +151,41 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 	utils.SendSuccess(c, "User deleted successfully", nil)
 }
 
-// GetAllUsers handles fetching all users with pagination
+// GetAllUsers handles fetching users with offset or cursor pagination (see parseListOptions)
 func (ctrl *UserController) GetAllUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	opts, cursorMode, err := parseListOptions(c)
+	if err != nil {
+		utils.SendValidationError(c, "Invalid query parameters", err)
+		return
+	}
 
-	users, totalCount, err := ctrl.UserRepo.GetAllUsers(limit, offset)
+	users, result, err := ctrl.users(c).GetAllUsers(c.Request.Context(), opts)
 	if err != nil {
-		utils.SendInternalServerError(c, "Failed to fetch users")
+		c.Error(utils.Internal(err, "Failed to fetch users"))
 		return
 	}
 
-	utils.SendSuccess(c, "Users fetched successfully", gin.H{
-		"data":        users,
-		"total_count": totalCount,
+	page, err := buildPage(users, len(users), opts, result, cursorMode, func(i int) (string, uint) {
+		return userSortValue(&users[i], opts.SortBy), users[i].ID
 	})
+	if err != nil {
+		c.Error(utils.Internal(err, "Failed to build pagination cursor"))
+		return
+	}
+
+	utils.SendSuccess(c, "Users fetched successfully", page)
+}
+
+// userSortValue returns the value of a user's sort field as stored in a cursor
+func userSortValue(user *models.User, sortBy string) string {
+	switch sortBy {
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	case "created_at":
+		return user.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		return ""
+	}
 }
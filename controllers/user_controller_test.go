@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/middleware"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserController_UpdateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const request = `{"email":"member@example.com","password":"NewPassword1"}`
+
+	tests := []struct {
+		name       string
+		email      string
+		role       string
+		mockSetup  func(userRepo *test.MockUserRepository)
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:  "Success - Own Account",
+			email: "member@example.com",
+			role:  utils.RoleUser,
+			mockSetup: func(userRepo *test.MockUserRepository) {
+				userRepo.On("FindByID", mock.Anything, uint(7)).Return(&models.User{ID: 7, Email: "member@example.com"}, nil).Once()
+				userRepo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.ID == 7 })).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "User updated successfully",
+		},
+		{
+			name:  "Failure - Owner Changing Member's Password",
+			email: "member@example.com",
+			role:  utils.RoleOwner,
+			mockSetup: func(userRepo *test.MockUserRepository) {
+				userRepo.On("FindByID", mock.Anything, uint(7)).Return(&models.User{ID: 7, Email: "owner@example.com"}, nil).Once()
+			},
+			expectCode: http.StatusForbidden,
+			expectMsg:  "You can only update your own account",
+		},
+		{
+			name:  "Success - Admin Updates Any Account",
+			email: "member@example.com",
+			role:  utils.RoleAdmin,
+			mockSetup: func(userRepo *test.MockUserRepository) {
+				userRepo.On("FindByEmail", mock.Anything, "member@example.com").Return(&models.User{ID: 9, Email: "member@example.com"}, nil).Once()
+				userRepo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.ID == 9 })).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+			expectMsg:  "User updated successfully",
+		},
+		{
+			name:  "Failure - Admin Updates Unknown Account",
+			email: "nobody@example.com",
+			role:  utils.RoleAdmin,
+			mockSetup: func(userRepo *test.MockUserRepository) {
+				userRepo.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, utils.NotFound("User not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+			expectMsg:  "User not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(test.MockUserRepository)
+			tt.mockSetup(userRepo)
+			ctrl := UserController{UserRepo: userRepo, Hasher: utils.BcryptHasher{}}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/user/"+tt.email, bytes.NewBufferString(request))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "email", Value: tt.email}}
+			c.Set("userID", uint(7))
+			c.Set("role", tt.role)
+			c.Set("orgID", uint(3))
+
+			ctrl.UpdateUser(c)
+			middleware.RenderError(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/metrics"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+type WebhookController struct {
+	CustomerRepo repositories.CustomerRepositoryInterface
+	MessageRepo  repositories.MessageRepositoryInterface
+	Config       messaging.WebhookConfig
+	Metrics      *metrics.Metrics // Counts lead customers created; nil records nothing
+}
+
+// NewWebhookController returns a new instance of WebhookController
+func NewWebhookController(customerRepo repositories.CustomerRepositoryInterface, messageRepo repositories.MessageRepositoryInterface, config messaging.WebhookConfig) *WebhookController {
+	return &WebhookController{CustomerRepo: customerRepo, MessageRepo: messageRepo, Config: config}
+}
+
+// VerifyWhatsAppWebhook answers the subscription handshake by echoing hub.challenge
+func (ctrl *WebhookController) VerifyWhatsAppWebhook(c *gin.Context) {
+	if ctrl.Config.VerifyToken == "" {
+		utils.SendError(c, "Webhook is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Compared in constant time so response timing doesn't leak the token, as with signatures
+	validToken := subtle.ConstantTimeCompare([]byte(c.Query("hub.verify_token")), []byte(ctrl.Config.VerifyToken)) == 1
+	if c.Query("hub.mode") != "subscribe" || !validToken {
+		utils.SendForbidden(c, "Invalid verification token")
+		return
+	}
+
+	c.String(http.StatusOK, c.Query("hub.challenge"))
+}
+
+// ReceiveWhatsAppWebhook stores inbound messages and delivery status updates.
+// Processing is idempotent, so a callback retried by the provider is harmless.
+func (ctrl *WebhookController) ReceiveWhatsAppWebhook(c *gin.Context) {
+	if ctrl.Config.AppSecret == "" || ctrl.Config.OrganizationID == 0 {
+		utils.SendError(c, "Webhook is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		utils.SendBadRequest(c, "Failed to read request body")
+		return
+	}
+
+	if !messaging.VerifySignature(ctrl.Config.AppSecret, body, c.GetHeader(messaging.SignatureHeader)) {
+		utils.SendUnauthorized(c, "Invalid signature")
+		return
+	}
+
+	var payload messaging.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		utils.SendValidationError(c, "Invalid webhook payload", err)
+		return
+	}
+
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" {
+				continue
+			}
+			if ctrl.Config.PhoneNumberID != "" && change.Value.Metadata.PhoneNumberID != ctrl.Config.PhoneNumberID {
+				utils.WarningContext(c.Request.Context(), "Ignoring webhook for unknown phone number ID", "phone_number_id", change.Value.Metadata.PhoneNumberID)
+				continue
+			}
+
+			if err := ctrl.processChange(c.Request.Context(), change.Value); err != nil {
+				// A non-2xx response makes the provider retry the whole callback later
+				utils.ErrorContext(c.Request.Context(), "Failed to process WhatsApp webhook", "error", err)
+				utils.SendInternalServerError(c, "Failed to process webhook")
+				return
+			}
+		}
+	}
+
+	utils.SendSuccess(c, "Webhook processed successfully", nil)
+}
+
+// processChange stores the inbound messages and applies the status updates of one notification.
+// Inbound messages go to the configured organization; a status update applies to the message
+// it names in whichever organization sent it.
+func (ctrl *WebhookController) processChange(ctx context.Context, value messaging.WebhookValue) error {
+	customers := ctrl.CustomerRepo.ForOrganization(ctrl.Config.OrganizationID)
+	messages := ctrl.MessageRepo.ForOrganization(ctrl.Config.OrganizationID)
+
+	for _, inbound := range value.Messages {
+		if err := ctrl.storeInbound(ctx, customers, messages, value, inbound); err != nil {
+			return err
+		}
+	}
+
+	for _, status := range value.Statuses {
+		message, err := ctrl.MessageRepo.FindAnyMessageByProviderID(ctx, status.ID)
+		if errors.Is(err, utils.ErrNotFound) {
+			// e.g. a message sent from the WhatsApp app rather than through the API
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if !message.ApplyStatus(status.Status, status.Time(), status.Error()) {
+			continue
+		}
+		if err := ctrl.MessageRepo.ForOrganization(message.OrganizationID).UpdateMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeInbound saves a message from a customer, creating a lead customer for unknown numbers if enabled
+func (ctrl *WebhookController) storeInbound(ctx context.Context, customers repositories.CustomerRepositoryInterface, messages repositories.MessageRepositoryInterface, value messaging.WebhookValue, inbound messaging.InboundMessage) error {
+	if _, err := ctrl.MessageRepo.FindAnyMessageByProviderID(ctx, inbound.ID); err == nil {
+		return nil // Already stored by an earlier delivery of this callback
+	} else if !errors.Is(err, utils.ErrNotFound) {
+		return err
+	}
+
+	phone, err := utils.NormalizePhone("+" + inbound.From)
+	if err != nil {
+		utils.WarningContext(ctx, "Ignoring WhatsApp message from invalid number", "message_id", inbound.ID, "from", inbound.From)
+		return nil
+	}
+
+	customer, err := customers.FindCustomerByPhone(ctx, phone)
+	if errors.Is(err, utils.ErrNotFound) {
+		if !ctrl.Config.AutoCreateLeads {
+			utils.WarningContext(ctx, "Ignoring WhatsApp message from unknown number", "message_id", inbound.ID, "from", inbound.From)
+			return nil
+		}
+
+		customer = &models.Customer{Name: value.ContactName(inbound.From), Phone: phone}
+		if customer.Name == "" {
+			customer.Name = customer.Phone
+		}
+		if err := customers.CreateCustomer(ctx, customer); err != nil {
+			return err
+		}
+		ctrl.Metrics.CustomersAdded(metrics.SourceWhatsApp, 1)
+		utils.InfoContext(ctx, "Created lead customer from WhatsApp", "customer_id", customer.ID, "from", inbound.From)
+	} else if err != nil {
+		return err
+	} else if customer.DeletedAt.Valid {
+		utils.WarningContext(ctx, "Ignoring WhatsApp message from deleted customer", "message_id", inbound.ID, "customer_id", customer.ID)
+		return nil
+	}
+
+	sentAt := inbound.Time()
+	providerID := inbound.ID
+	message := models.Message{
+		CustomerID:        customer.ID,
+		Direction:         models.MessageInbound,
+		Type:              inbound.Type,
+		Body:              inbound.Body(),
+		ProviderMessageID: &providerID,
+		Status:            models.MessageStatusReceived,
+		SentAt:            &sentAt,
+	}
+	return messages.CreateMessage(ctx, &message)
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testWebhookConfig = messaging.WebhookConfig{
+	AppSecret:      "app-secret",
+	VerifyToken:    "verify-token",
+	OrganizationID: 1,
+}
+
+func TestWebhookController_VerifyWhatsAppWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "Success - Challenge Echoed",
+			query:      "hub.mode=subscribe&hub.verify_token=verify-token&hub.challenge=1158201444",
+			expectCode: http.StatusOK,
+			expectBody: "1158201444",
+		},
+		{
+			name:       "Failure - Wrong Token",
+			query:      "hub.mode=subscribe&hub.verify_token=guess&hub.challenge=1158201444",
+			expectCode: http.StatusForbidden,
+			expectBody: "Invalid verification token",
+		},
+		{
+			name:       "Failure - Token Prefix",
+			query:      "hub.mode=subscribe&hub.verify_token=verify&hub.challenge=1158201444",
+			expectCode: http.StatusForbidden,
+			expectBody: "Invalid verification token",
+		},
+		{
+			name:       "Failure - Wrong Mode",
+			query:      "hub.mode=unsubscribe&hub.verify_token=verify-token&hub.challenge=1158201444",
+			expectCode: http.StatusForbidden,
+			expectBody: "Invalid verification token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := NewWebhookController(nil, nil, testWebhookConfig)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/webhooks/whatsapp?"+tt.query, nil)
+
+			ctrl.VerifyWhatsAppWebhook(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectBody)
+		})
+	}
+}
+
+func TestWebhookController_ReceiveWhatsAppWebhook_Signature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Only account-level changes, so a verified request never reaches the repositories
+	body := `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"account_update","value":{}}]}]}`
+
+	tests := []struct {
+		name       string
+		config     messaging.WebhookConfig
+		signature  string
+		expectCode int
+		expectMsg  string
+	}{
+		{
+			name:       "Success - Valid Signature",
+			config:     testWebhookConfig,
+			signature:  messaging.Sign("app-secret", []byte(body)),
+			expectCode: http.StatusOK,
+			expectMsg:  "Webhook processed successfully",
+		},
+		{
+			name:       "Failure - Signed With Another Secret",
+			config:     testWebhookConfig,
+			signature:  messaging.Sign("other-secret", []byte(body)),
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Invalid signature",
+		},
+		{
+			name:       "Failure - Missing Signature",
+			config:     testWebhookConfig,
+			expectCode: http.StatusUnauthorized,
+			expectMsg:  "Invalid signature",
+		},
+		{
+			name:       "Failure - Not Configured",
+			config:     messaging.WebhookConfig{VerifyToken: "verify-token"},
+			signature:  messaging.Sign("", []byte(body)),
+			expectCode: http.StatusServiceUnavailable,
+			expectMsg:  "Webhook is not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := NewWebhookController(nil, nil, tt.config)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/webhooks/whatsapp", bytes.NewBufferString(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.signature != "" {
+				c.Request.Header.Set(messaging.SignatureHeader, tt.signature)
+			}
+
+			ctrl.ReceiveWhatsAppWebhook(c)
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+		})
+	}
+}
+
+func TestWebhookController_ProcessChange_Statuses(t *testing.T) {
+	db := newMessageTestDB(t)
+	providerID := func(id string) *string { return &id }
+	require.NoError(t, db.Create(&[]models.Message{
+		{ID: 1, OrganizationID: 1, CustomerID: 1, Direction: models.MessageOutbound, Type: "text", Status: models.MessageStatusSent, ProviderMessageID: providerID("wamid.own")},
+		{ID: 2, OrganizationID: 2, CustomerID: 2, Direction: models.MessageOutbound, Type: "text", Status: models.MessageStatusSent, ProviderMessageID: providerID("wamid.other")},
+		{ID: 3, OrganizationID: 2, CustomerID: 2, Direction: models.MessageOutbound, Type: "text", Status: models.MessageStatusRead, ProviderMessageID: providerID("wamid.read")},
+	}).Error)
+	ctrl := NewWebhookController(repositories.NewCustomerRepository(db), repositories.NewMessageRepository(db), testWebhookConfig)
+
+	var value messaging.WebhookValue
+	require.NoError(t, json.Unmarshal([]byte(`{"statuses":[
+		{"id":"wamid.own","status":"delivered","timestamp":"1700000000"},
+		{"id":"wamid.other","status":"failed","timestamp":"1700000000","errors":[{"code":131026,"title":"Message undeliverable"}]},
+		{"id":"wamid.read","status":"delivered","timestamp":"1700000000"},
+		{"id":"wamid.unknown","status":"delivered","timestamp":"1700000000"}
+	]}`), &value))
+
+	require.NoError(t, ctrl.processChange(context.Background(), value))
+
+	var messages []models.Message
+	require.NoError(t, db.Order("id").Find(&messages).Error)
+	require.Len(t, messages, 3)
+
+	assert.Equal(t, models.MessageStatusDelivered, messages[0].Status)
+	require.NotNil(t, messages[0].DeliveredAt)
+	assert.Equal(t, int64(1700000000), messages[0].DeliveredAt.Unix())
+
+	// Applied in the organization that sent it, not the webhook's
+	assert.Equal(t, uint(2), messages[1].OrganizationID)
+	assert.Equal(t, models.MessageStatusFailed, messages[1].Status)
+	assert.Equal(t, "Message undeliverable", messages[1].Error)
+
+	assert.Equal(t, models.MessageStatusRead, messages[2].Status, "late statuses don't move a message back")
+}
+
+func TestWebhookController_StoreInbound(t *testing.T) {
+	tests := []struct {
+		name            string
+		inbound         string
+		autoCreateLeads bool
+		expectStored    bool
+		expectCustomers int64
+	}{
+		{name: "Known Customer", inbound: `{"id":"wamid.in","from":"6281234567890","timestamp":"1700000000","type":"text","text":{"body":"Halo"}}`, expectStored: true, expectCustomers: 2},
+		{name: "Already Stored", inbound: `{"id":"wamid.dup","from":"6281234567890","timestamp":"1700000000","type":"text","text":{"body":"Halo"}}`, expectStored: true, expectCustomers: 2},
+		{name: "Unknown Number Ignored", inbound: `{"id":"wamid.in","from":"6281311112222","type":"text","text":{"body":"Halo"}}`, expectCustomers: 2},
+		{name: "Unknown Number Becomes Lead", inbound: `{"id":"wamid.in","from":"6281311112222","type":"text","text":{"body":"Halo"}}`, autoCreateLeads: true, expectStored: true, expectCustomers: 3},
+		{name: "Invalid Number Ignored", inbound: `{"id":"wamid.in","from":"12","type":"text","text":{"body":"Halo"}}`, autoCreateLeads: true, expectCustomers: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMessageTestDB(t)
+			dup := "wamid.dup"
+			require.NoError(t, db.Create(&models.Message{OrganizationID: 1, CustomerID: 1, Direction: models.MessageInbound, Type: "text", Body: "Halo", Status: models.MessageStatusReceived, ProviderMessageID: &dup}).Error)
+
+			config := testWebhookConfig
+			config.AutoCreateLeads = tt.autoCreateLeads
+			ctrl := NewWebhookController(repositories.NewCustomerRepository(db), repositories.NewMessageRepository(db), config)
+
+			var value messaging.WebhookValue
+			require.NoError(t, json.Unmarshal([]byte(`{"contacts":[{"wa_id":"6281311112222","profile":{"name":"Rina"}}],"messages":[`+tt.inbound+`]}`), &value))
+			require.NoError(t, ctrl.processChange(context.Background(), value))
+
+			var customers int64
+			require.NoError(t, db.Model(&models.Customer{}).Count(&customers).Error)
+			assert.Equal(t, tt.expectCustomers, customers)
+
+			var stored []models.Message
+			require.NoError(t, db.Where("provider_message_id = ?", value.Messages[0].ID).Find(&stored).Error)
+			if !tt.expectStored {
+				assert.Empty(t, stored)
+				return
+			}
+			require.Len(t, stored, 1, "stored once")
+			assert.Equal(t, uint(1), stored[0].OrganizationID)
+			assert.Equal(t, models.MessageStatusReceived, stored[0].Status)
+			assert.Equal(t, "Halo", stored[0].Body)
+			if tt.autoCreateLeads {
+				var lead models.Customer
+				require.NoError(t, db.First(&lead, stored[0].CustomerID).Error)
+				assert.Equal(t, "Rina", lead.Name)
+				assert.Equal(t, "+6281311112222", lead.Phone)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/metabbe3/go-backend/migrations"
+	"gorm.io/gorm"
+)
+
+// Database checks that a connection to the database can be made
+func Database(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Migrations checks that the schema has every migration this build knows about, so an instance
+// doesn't take traffic against a schema it wasn't written for. A newer schema passes: during a
+// rolling deploy the new release migrates first while old instances keep serving.
+func Migrations(migrator *migrations.Migrator) CheckFunc {
+	return func(ctx context.Context) error {
+		version, err := migrator.WithContext(ctx).Version()
+		if err != nil {
+			return err
+		}
+		if latest := migrator.Latest(); version < latest {
+			return fmt.Errorf("schema is at version %d, expected %d", version, latest)
+		}
+		return nil
+	}
+}
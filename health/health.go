@@ -0,0 +1,176 @@
+// Package health runs the dependency checks behind the readiness probe.
+//
+// Checks are registered on a Registry, which runs them concurrently, each with its own
+// timeout, and caches the report for a short time so frequent probes from several load
+// balancers don't turn into a query storm against the database. A check calling an external
+// API can keep its result longer.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Check statuses, and the overall status of a report
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusDegraded = "degraded" // Only non-critical checks failed
+)
+
+// CheckFunc reports a dependency as healthy by returning nil
+type CheckFunc func(ctx context.Context) error
+
+// Check is a registered dependency check
+type Check struct {
+	Name     string
+	Timeout  time.Duration
+	CacheTTL time.Duration // How long this check's result is reused, when longer than the registry's, e.g. for a rate-limited external API
+	Critical bool          // A failing critical check fails readiness; others only degrade it
+	Run      CheckFunc
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Critical  bool    `json:"critical"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// Healthy reports whether no critical check failed
+func (r Report) Healthy() bool {
+	return r.Status != StatusFail
+}
+
+// Registry holds the checks and the cached report
+type Registry struct {
+	cacheTTL time.Duration
+	checks   []Check
+	results  []cachedResult // Latest result of each check; only touched by the run in progress
+
+	mu       sync.Mutex // Guards the fields below, never held while checks run
+	report   Report
+	cachedAt time.Time
+	running  chan struct{} // Closed when the run in progress finishes; nil when none is
+}
+
+// cachedResult is a check result and when it was obtained
+type cachedResult struct {
+	result    Result
+	checkedAt time.Time
+}
+
+// NewRegistry creates an empty registry whose reports are reused for cacheTTL
+func NewRegistry(cacheTTL time.Duration) *Registry {
+	return &Registry{cacheTTL: cacheTTL}
+}
+
+// Register adds a check. Checks must be registered before the registry is used.
+func (r *Registry) Register(check Check) {
+	r.checks = append(r.checks, check)
+	r.results = append(r.results, cachedResult{})
+}
+
+// Check returns the cached report, running the checks again once the cache has expired.
+// Concurrent callers share one run, which is detached from their contexts: a probe that
+// gives up doesn't fail the report the others wait for. Checks still get their own timeouts.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	if !r.cachedAt.IsZero() && time.Since(r.cachedAt) < r.cacheTTL {
+		defer r.mu.Unlock()
+		return r.report
+	}
+	running := r.running
+	if running == nil {
+		running = make(chan struct{})
+		r.running = running
+		go r.refresh(context.WithoutCancel(ctx), running)
+	}
+	r.mu.Unlock()
+
+	<-running
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report
+}
+
+// refresh runs the checks whose results have expired, stores the new report and closes done
+func (r *Registry) refresh(ctx context.Context, done chan struct{}) {
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for i, check := range r.checks {
+		ttl := max(check.CacheTTL, r.cacheTTL)
+		if cached := r.results[i]; !cached.checkedAt.IsZero() && now.Sub(cached.checkedAt) < ttl {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			r.results[i] = cachedResult{result: run(ctx, check), checkedAt: time.Now()}
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, CheckedAt: now.UTC(), Checks: make(map[string]Result, len(r.checks))}
+	for i, check := range r.checks {
+		result := r.results[i].result
+		report.Checks[check.Name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if check.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	r.mu.Lock()
+	r.report, r.cachedAt = report, time.Now()
+	r.running = nil
+	r.mu.Unlock()
+	close(done)
+}
+
+// run executes one check within its timeout; a panicking check counts as failed
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := Result{Status: StatusOK, Critical: check.Critical}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("panic: %v", recovered)
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+
+	// Checks should honour ctx, but one that doesn't must not hold up the probe
+	select {
+	case err := <-done:
+		if err != nil {
+			result.Status, result.Error = StatusFail, err.Error()
+		}
+	case <-ctx.Done():
+		result.Status, result.Error = StatusFail, fmt.Sprintf("timed out after %s", check.Timeout)
+	}
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	return result
+}
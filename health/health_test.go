@@ -0,0 +1,175 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metabbe3/go-backend/health"
+	"github.com/metabbe3/go-backend/migrations"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error { time.Sleep(time.Second); return nil } // Ignores ctx
+
+	tests := []struct {
+		name         string
+		checks       []health.Check
+		expectStatus string
+		expectErrors map[string]string
+	}{
+		{
+			name: "All Pass",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: ok},
+				{Name: "whatsapp", Run: ok},
+			},
+			expectStatus: health.StatusOK,
+		},
+		{
+			name: "Non-Critical Failure Degrades",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: ok},
+				{Name: "whatsapp", Run: failing},
+			},
+			expectStatus: health.StatusDegraded,
+			expectErrors: map[string]string{"whatsapp": "connection refused"},
+		},
+		{
+			name: "Critical Failure Fails",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: failing},
+				{Name: "whatsapp", Run: failing},
+			},
+			expectStatus: health.StatusFail,
+			expectErrors: map[string]string{"database": "connection refused", "whatsapp": "connection refused"},
+		},
+		{
+			name: "Timeout And Panic Fail",
+			checks: []health.Check{
+				{Name: "slow", Critical: true, Run: hanging},
+				{Name: "broken", Run: func(context.Context) error { panic("nil map") }},
+			},
+			expectStatus: health.StatusFail,
+			expectErrors: map[string]string{"slow": "timed out after 50ms", "broken": "panic: nil map"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := health.NewRegistry(0)
+			for _, check := range tt.checks {
+				check.Timeout = 50 * time.Millisecond
+				registry.Register(check)
+			}
+
+			start := time.Now()
+			report := registry.Check(context.Background())
+			assert.Less(t, time.Since(start), 500*time.Millisecond, "checks run concurrently and time out")
+
+			assert.Equal(t, tt.expectStatus, report.Status)
+			assert.Equal(t, tt.expectStatus != health.StatusFail, report.Healthy())
+			require.Len(t, report.Checks, len(tt.checks))
+			for name, result := range report.Checks {
+				assert.Equal(t, tt.expectErrors[name], result.Error, name)
+				assert.GreaterOrEqual(t, result.LatencyMS, 0.0)
+			}
+		})
+	}
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var runs atomic.Int32
+	registry := health.NewRegistry(time.Hour)
+	registry.Register(health.Check{Name: "database", Timeout: time.Second, Critical: true, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	first := registry.Check(context.Background())
+	second := registry.Check(context.Background())
+	assert.Equal(t, int32(1), runs.Load())
+	assert.Equal(t, first.CheckedAt, second.CheckedAt)
+}
+
+func TestRegistry_CheckCacheTTL(t *testing.T) {
+	var databaseRuns, providerRuns atomic.Int32
+	registry := health.NewRegistry(0)
+	registry.Register(health.Check{Name: "database", Timeout: time.Second, Critical: true, Run: func(context.Context) error {
+		databaseRuns.Add(1)
+		return nil
+	}})
+	registry.Register(health.Check{Name: "whatsapp", Timeout: time.Second, CacheTTL: time.Hour, Run: func(context.Context) error {
+		providerRuns.Add(1)
+		return errors.New("rate limited")
+	}})
+
+	for i := 0; i < 3; i++ {
+		report := registry.Check(context.Background())
+		assert.Equal(t, health.StatusDegraded, report.Status, "a reused result still counts")
+		assert.Equal(t, "rate limited", report.Checks["whatsapp"].Error)
+	}
+	assert.Equal(t, int32(3), databaseRuns.Load())
+	assert.Equal(t, int32(1), providerRuns.Load())
+}
+
+func TestRegistry_CheckDetachedFromCaller(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	registry := health.NewRegistry(time.Hour)
+	registry.Register(health.Check{Name: "database", Timeout: time.Second, Critical: true, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		<-release
+		return ctx.Err()
+	}})
+
+	// The first probe gives up before the checks finish; the one waiting with it must not fail
+	cancelled, cancel := context.WithCancel(context.Background())
+	reports := make(chan health.Report, 2)
+	go func() { reports <- registry.Check(cancelled) }()
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, 5*time.Second, time.Millisecond)
+	go func() { reports <- registry.Check(context.Background()) }()
+	cancel()
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		report := <-reports
+		assert.Equal(t, health.StatusOK, report.Status, report.Checks["database"].Error)
+	}
+	assert.Equal(t, int32(1), runs.Load(), "concurrent probes share one run")
+}
+
+func TestDatabaseAndMigrations(t *testing.T) {
+	db := test.NewTestDB(t)
+	migrator, err := migrations.NewMigrator(db)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, health.Database(db)(ctx))
+	assert.NoError(t, health.Migrations(migrator)(ctx))
+
+	_, err = migrator.Down(1)
+	require.NoError(t, err)
+	latest := migrator.Latest()
+	assert.ErrorContains(t, health.Migrations(migrator)(ctx), fmt.Sprintf("schema is at version %d, expected %d", latest-1, latest))
+
+	// A schema migrated ahead by a newer release still serves this one
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", latest+1, "from_a_newer_release", time.Now()).Error)
+	assert.NoError(t, health.Migrations(migrator)(ctx))
+	require.NoError(t, db.Exec("DELETE FROM schema_migrations WHERE version = ?", latest+1).Error)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	assert.Error(t, health.Database(db)(ctx))
+}
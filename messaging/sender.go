@@ -0,0 +1,99 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Message types supported by senders
+const (
+	TypeText     = "text"
+	TypeTemplate = "template"
+)
+
+// ErrNotConfigured is returned by senders when messaging credentials are missing
+var ErrNotConfigured = errors.New("messaging provider is not configured")
+
+// OutboundMessage is a message to deliver to a single recipient
+type OutboundMessage struct {
+	To       string    // Recipient phone number, international format
+	Type     string    // TypeText or TypeTemplate
+	Text     string    // Body of a text message
+	Template *Template // Template of a template message
+}
+
+// Template is a pre-approved message template with positional body parameters
+type Template struct {
+	Name       string   `json:"name" binding:"required"`
+	Language   string   `json:"language" binding:"required"`
+	Parameters []string `json:"parameters"`
+}
+
+// SendResult is what the provider returns for an accepted message
+type SendResult struct {
+	ProviderMessageID string
+}
+
+// MessageSender delivers outbound messages through a messaging provider
+type MessageSender interface {
+	Send(ctx context.Context, msg OutboundMessage) (SendResult, error)
+}
+
+// Pinger is implemented by senders that can check the provider is reachable and accepts their credentials
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ProviderError is an error reported by the messaging provider's API
+type ProviderError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Validate checks that the message has the fields its type requires
+func (m OutboundMessage) Validate() error {
+	if RecipientID(m.To) == "" {
+		return errors.New("recipient phone number is required")
+	}
+
+	switch m.Type {
+	case TypeText:
+		if strings.TrimSpace(m.Text) == "" {
+			return errors.New("text is required for text messages")
+		}
+	case TypeTemplate:
+		if m.Template == nil || m.Template.Name == "" || m.Template.Language == "" {
+			return errors.New("template name and language are required for template messages")
+		}
+	default:
+		return fmt.Errorf("unsupported message type %q", m.Type)
+	}
+	return nil
+}
+
+// RecipientID converts a phone number to the digits-only form providers expect ("+62 812-3" -> "628123")
+func RecipientID(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// DisabledSender is used when no provider is configured; every send fails with ErrNotConfigured
+type DisabledSender struct{}
+
+// Send always returns ErrNotConfigured
+func (DisabledSender) Send(ctx context.Context, msg OutboundMessage) (SendResult, error) {
+	return SendResult{}, ErrNotConfigured
+}
@@ -0,0 +1,164 @@
+package messaging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the webhook body, keyed with the app secret
+const SignatureHeader = "X-Hub-Signature-256"
+
+// WebhookConfig holds the settings of the inbound WhatsApp webhook
+type WebhookConfig struct {
+	AppSecret       string // Meta app secret used to sign callbacks
+	VerifyToken     string // Token echoed back during the subscription handshake
+	PhoneNumberID   string // When set, callbacks for other phone numbers are ignored
+	OrganizationID  uint   // Organization inbound messages are filed under
+	AutoCreateLeads bool   // Create a customer for messages from unknown numbers
+}
+
+// VerifySignature checks an X-Hub-Signature-256 header ("sha256=<hex>") against the raw body
+func VerifySignature(appSecret string, body []byte, header string) bool {
+	if appSecret == "" {
+		return false
+	}
+
+	if !strings.HasPrefix(header, "sha256=") {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Sign returns the X-Hub-Signature-256 header value for body; used by tests and fake servers
+func Sign(appSecret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookPayload is the body of a WhatsApp Cloud API callback
+type WebhookPayload struct {
+	Object string         `json:"object"`
+	Entry  []WebhookEntry `json:"entry"`
+}
+
+// WebhookEntry groups the changes of one WhatsApp Business Account
+type WebhookEntry struct {
+	ID      string          `json:"id"`
+	Changes []WebhookChange `json:"changes"`
+}
+
+// WebhookChange is a single notification; only the "messages" field is handled
+type WebhookChange struct {
+	Field string       `json:"field"`
+	Value WebhookValue `json:"value"`
+}
+
+// WebhookValue holds inbound messages and status updates for one phone number
+type WebhookValue struct {
+	MessagingProduct string `json:"messaging_product"`
+	Metadata         struct {
+		DisplayPhoneNumber string `json:"display_phone_number"`
+		PhoneNumberID      string `json:"phone_number_id"`
+	} `json:"metadata"`
+	Contacts []WebhookContact `json:"contacts"`
+	Messages []InboundMessage `json:"messages"`
+	Statuses []StatusUpdate   `json:"statuses"`
+}
+
+// WebhookContact is the WhatsApp profile of a sender
+type WebhookContact struct {
+	WaID    string `json:"wa_id"`
+	Profile struct {
+		Name string `json:"name"`
+	} `json:"profile"`
+}
+
+// InboundMessage is a message sent by a customer
+type InboundMessage struct {
+	ID        string `json:"id"`
+	From      string `json:"from"` // Sender phone number, digits only
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Text      *struct {
+		Body string `json:"body"`
+	} `json:"text,omitempty"`
+	Button *struct {
+		Text string `json:"text"`
+	} `json:"button,omitempty"`
+}
+
+// Body returns the readable text of the message, empty for media and other types
+func (m InboundMessage) Body() string {
+	switch {
+	case m.Text != nil:
+		return m.Text.Body
+	case m.Button != nil:
+		return m.Button.Text
+	}
+	return ""
+}
+
+// Time returns when the customer sent the message
+func (m InboundMessage) Time() time.Time {
+	return parseUnixTimestamp(m.Timestamp)
+}
+
+// StatusUpdate reports the delivery status of an outbound message
+type StatusUpdate struct {
+	ID          string `json:"id"` // Provider message ID returned when sending
+	Status      string `json:"status"`
+	Timestamp   string `json:"timestamp"`
+	RecipientID string `json:"recipient_id"`
+	Errors      []struct {
+		Code    int    `json:"code"`
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	} `json:"errors,omitempty"`
+}
+
+// Time returns when the status changed
+func (s StatusUpdate) Time() time.Time {
+	return parseUnixTimestamp(s.Timestamp)
+}
+
+// Error returns the provider's failure reason, if any
+func (s StatusUpdate) Error() string {
+	if len(s.Errors) == 0 {
+		return ""
+	}
+	if s.Errors[0].Message != "" {
+		return s.Errors[0].Message
+	}
+	return s.Errors[0].Title
+}
+
+// ContactName returns the profile name of the sender with the given WhatsApp ID
+func (v WebhookValue) ContactName(waID string) string {
+	for _, contact := range v.Contacts {
+		if contact.WaID == waID {
+			return contact.Profile.Name
+		}
+	}
+	return ""
+}
+
+// parseUnixTimestamp parses the seconds-since-epoch strings used by the Cloud API, defaulting to now
+func parseUnixTimestamp(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Now()
+	}
+	return time.Unix(seconds, 0)
+}
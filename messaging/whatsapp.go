@@ -0,0 +1,206 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults for the WhatsApp Cloud API
+const (
+	DefaultWhatsAppBaseURL    = "https://graph.facebook.com"
+	DefaultWhatsAppAPIVersion = "v21.0"
+)
+
+// WhatsAppConfig holds the credentials of a WhatsApp Cloud API phone number
+type WhatsAppConfig struct {
+	BaseURL       string // Overridable to point at a local fake server
+	APIVersion    string
+	PhoneNumberID string
+	AccessToken   string
+	HTTPClient    *http.Client
+}
+
+// WhatsAppSender sends messages through the WhatsApp Cloud API
+type WhatsAppSender struct {
+	config WhatsAppConfig
+	client *http.Client
+}
+
+// Ensure WhatsAppSender implements MessageSender and Pinger
+var (
+	_ MessageSender = (*WhatsAppSender)(nil)
+	_ Pinger        = (*WhatsAppSender)(nil)
+)
+
+// NewWhatsAppSender creates a WhatsAppSender, filling in defaults for unset config fields
+func NewWhatsAppSender(config WhatsAppConfig) *WhatsAppSender {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultWhatsAppBaseURL
+	}
+	if config.APIVersion == "" {
+		config.APIVersion = DefaultWhatsAppAPIVersion
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	return &WhatsAppSender{config: config, client: client}
+}
+
+// whatsAppRequest is the body of a Cloud API send request
+type whatsAppRequest struct {
+	MessagingProduct string            `json:"messaging_product"`
+	RecipientType    string            `json:"recipient_type"`
+	To               string            `json:"to"`
+	Type             string            `json:"type"`
+	Text             *whatsAppText     `json:"text,omitempty"`
+	Template         *whatsAppTemplate `json:"template,omitempty"`
+}
+
+type whatsAppText struct {
+	Body string `json:"body"`
+}
+
+type whatsAppTemplate struct {
+	Name       string              `json:"name"`
+	Language   whatsAppLanguage    `json:"language"`
+	Components []whatsAppComponent `json:"components,omitempty"`
+}
+
+type whatsAppLanguage struct {
+	Code string `json:"code"`
+}
+
+type whatsAppComponent struct {
+	Type       string              `json:"type"`
+	Parameters []whatsAppParameter `json:"parameters"`
+}
+
+type whatsAppParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// whatsAppResponse covers both the success and the error shape of a Cloud API response
+type whatsAppResponse struct {
+	Messages []struct {
+		ID string `json:"id"`
+	} `json:"messages"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"error"`
+}
+
+// Send delivers a text or template message
+func (s *WhatsAppSender) Send(ctx context.Context, msg OutboundMessage) (SendResult, error) {
+	if s.config.PhoneNumberID == "" || s.config.AccessToken == "" {
+		return SendResult{}, ErrNotConfigured
+	}
+	if err := msg.Validate(); err != nil {
+		return SendResult{}, err
+	}
+
+	payload, err := json.Marshal(buildWhatsAppRequest(msg))
+	if err != nil {
+		return SendResult{}, err
+	}
+
+	url := fmt.Sprintf("%s/%s/%s/messages", strings.TrimRight(s.config.BaseURL, "/"), s.config.APIVersion, s.config.PhoneNumberID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return SendResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.config.AccessToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("whatsapp request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body whatsAppResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return SendResult{}, &ProviderError{StatusCode: resp.StatusCode, Message: "unreadable response from provider"}
+	}
+
+	if resp.StatusCode >= 300 || body.Error != nil || len(body.Messages) == 0 {
+		providerErr := &ProviderError{StatusCode: resp.StatusCode, Message: "message was not accepted"}
+		if body.Error != nil {
+			providerErr.Code = body.Error.Code
+			providerErr.Message = body.Error.Message
+		}
+		return SendResult{}, providerErr
+	}
+
+	return SendResult{ProviderMessageID: body.Messages[0].ID}, nil
+}
+
+// Ping reads the phone number's metadata, which succeeds only if the API is reachable
+// and the access token is valid for the phone number
+func (s *WhatsAppSender) Ping(ctx context.Context) error {
+	if s.config.PhoneNumberID == "" || s.config.AccessToken == "" {
+		return ErrNotConfigured
+	}
+
+	url := fmt.Sprintf("%s/%s/%s?fields=id", strings.TrimRight(s.config.BaseURL, "/"), s.config.APIVersion, s.config.PhoneNumberID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.config.AccessToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("whatsapp request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var body whatsAppResponse
+		providerErr := &ProviderError{StatusCode: resp.StatusCode, Message: "phone number lookup failed"}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != nil {
+			providerErr.Code = body.Error.Code
+			providerErr.Message = body.Error.Message
+		}
+		return providerErr
+	}
+	return nil
+}
+
+// buildWhatsAppRequest maps an OutboundMessage to the Cloud API request body
+func buildWhatsAppRequest(msg OutboundMessage) whatsAppRequest {
+	req := whatsAppRequest{
+		MessagingProduct: "whatsapp",
+		RecipientType:    "individual",
+		To:               RecipientID(msg.To),
+		Type:             msg.Type,
+	}
+
+	switch msg.Type {
+	case TypeText:
+		req.Text = &whatsAppText{Body: msg.Text}
+	case TypeTemplate:
+		template := &whatsAppTemplate{
+			Name:     msg.Template.Name,
+			Language: whatsAppLanguage{Code: msg.Template.Language},
+		}
+		if len(msg.Template.Parameters) > 0 {
+			params := make([]whatsAppParameter, 0, len(msg.Template.Parameters))
+			for _, p := range msg.Template.Parameters {
+				params = append(params, whatsAppParameter{Type: "text", Text: p})
+			}
+			template.Components = []whatsAppComponent{{Type: "body", Parameters: params}}
+		}
+		req.Template = template
+	}
+	return req
+}
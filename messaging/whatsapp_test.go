@@ -0,0 +1,126 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/metabbe3/go-backend/messaging"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhatsAppSender_Send(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        messaging.OutboundMessage
+		failNext   bool
+		expectErr  string
+		expectBody func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name: "Success - Text Message",
+			msg:  messaging.OutboundMessage{To: "+62 812-3456-7890", Type: messaging.TypeText, Text: "Halo!"},
+			expectBody: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "6281234567890", body["to"])
+				assert.Equal(t, "text", body["type"])
+				assert.Equal(t, map[string]interface{}{"body": "Halo!"}, body["text"])
+			},
+		},
+		{
+			name: "Success - Template Message",
+			msg: messaging.OutboundMessage{To: "6281234567890", Type: messaging.TypeTemplate, Template: &messaging.Template{
+				Name: "order_update", Language: "id", Parameters: []string{"INV-1"},
+			}},
+			expectBody: func(t *testing.T, body map[string]interface{}) {
+				template := body["template"].(map[string]interface{})
+				assert.Equal(t, "order_update", template["name"])
+				assert.Equal(t, map[string]interface{}{"code": "id"}, template["language"])
+				assert.Len(t, template["components"], 1)
+			},
+		},
+		{
+			name:      "Failure - Provider Rejects Message",
+			msg:       messaging.OutboundMessage{To: "6281234567890", Type: messaging.TypeText, Text: "Halo!"},
+			failNext:  true,
+			expectErr: "Recipient phone number not in allowed list",
+		},
+		{
+			name:      "Failure - Missing Text",
+			msg:       messaging.OutboundMessage{To: "6281234567890", Type: messaging.TypeText},
+			expectErr: "text is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.NewFakeWhatsAppServer("12345", "secret-token")
+			defer server.Close()
+			if tt.failNext {
+				server.FailNext(http.StatusBadRequest, 131030, "Recipient phone number not in allowed list")
+			}
+
+			sender := messaging.NewWhatsAppSender(messaging.WhatsAppConfig{
+				BaseURL:       server.URL,
+				PhoneNumberID: "12345",
+				AccessToken:   "secret-token",
+			})
+
+			result, err := sender.Send(context.Background(), tt.msg)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "wamid.FAKE1", result.ProviderMessageID)
+			requests := server.Requests()
+			assert.Len(t, requests, 1)
+			tt.expectBody(t, requests[0])
+		})
+	}
+}
+
+func TestWhatsAppSender_ProviderError(t *testing.T) {
+	server := test.NewFakeWhatsAppServer("12345", "secret-token")
+	defer server.Close()
+
+	sender := messaging.NewWhatsAppSender(messaging.WhatsAppConfig{
+		BaseURL:       server.URL,
+		PhoneNumberID: "12345",
+		AccessToken:   "wrong-token",
+	})
+
+	_, err := sender.Send(context.Background(), messaging.OutboundMessage{To: "62812", Type: messaging.TypeText, Text: "Hi"})
+
+	var providerErr *messaging.ProviderError
+	assert.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, http.StatusUnauthorized, providerErr.StatusCode)
+	assert.Equal(t, 190, providerErr.Code)
+
+	_, err = messaging.NewWhatsAppSender(messaging.WhatsAppConfig{}).Send(context.Background(), messaging.OutboundMessage{})
+	assert.ErrorIs(t, err, messaging.ErrNotConfigured)
+}
+
+func TestWhatsAppSender_Ping(t *testing.T) {
+	server := test.NewFakeWhatsAppServer("12345", "secret-token")
+	defer server.Close()
+
+	ping := func(token string) error {
+		return messaging.NewWhatsAppSender(messaging.WhatsAppConfig{
+			BaseURL:       server.URL,
+			PhoneNumberID: "12345",
+			AccessToken:   token,
+		}).Ping(context.Background())
+	}
+
+	assert.NoError(t, ping("secret-token"))
+
+	var providerErr *messaging.ProviderError
+	assert.True(t, errors.As(ping("wrong-token"), &providerErr))
+	assert.Equal(t, 190, providerErr.Code)
+
+	server.FailNext(http.StatusServiceUnavailable, 2, "Service temporarily unavailable")
+	assert.ErrorContains(t, ping("secret-token"), "Service temporarily unavailable")
+}
@@ -0,0 +1,127 @@
+// Package metrics collects the Prometheus metrics served on /metrics: HTTP traffic by route,
+// database query timings and pool usage, and business events.
+//
+// Each Metrics has its own registry, so several applications can live in one process.
+// Its methods are safe to call on a nil *Metrics, which records nothing.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every metric name
+const Namespace = "go_backend"
+
+// UnmatchedRoute labels requests that matched no route, so unknown paths can't grow the label set
+const UnmatchedRoute = "unmatched"
+
+// Sources a customer can be created from
+const (
+	SourceAPI      = "api"
+	SourceImport   = "import"
+	SourceWhatsApp = "whatsapp"
+)
+
+// Metrics holds the application's collectors
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDuration *prometheus.HistogramVec
+	HTTPInFlight        *prometheus.GaugeVec
+	DBQueryDuration     *prometheus.HistogramVec
+	Logins              *prometheus.CounterVec
+	CustomersCreated    *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them, with the Go runtime and process collectors,
+// on a new registry
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace, Subsystem: "http", Name: "requests_total",
+			Help: "HTTP requests handled, by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace, Subsystem: "http", Name: "request_duration_seconds",
+			Help:    "HTTP request latency, by route template and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		HTTPInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace, Subsystem: "http", Name: "requests_in_flight",
+			Help: "HTTP requests being handled, by route template.",
+		}, []string{"route"}),
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace, Subsystem: "db", Name: "query_duration_seconds",
+			Help:    "Database statement latency, by operation, table and outcome.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table", "status"}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace, Subsystem: "auth", Name: "logins_total",
+			Help: "Login attempts, by result (success or failure).",
+		}, []string{"result"}),
+		CustomersCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace, Subsystem: "customers", Name: "created_total",
+			Help: "Customers created, by source (api, import or whatsapp).",
+		}, []string{"source"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests, m.HTTPRequestDuration, m.HTTPInFlight, m.DBQueryDuration, m.Logins, m.CustomersCreated,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// RequestStarted records a request to route as in flight; call the returned function with
+// the response status once it has been handled
+func (m *Metrics) RequestStarted(route, method string) func(status int) {
+	if m == nil {
+		return func(int) {}
+	}
+	if route == "" {
+		route = UnmatchedRoute
+	}
+
+	start := time.Now()
+	inFlight := m.HTTPInFlight.WithLabelValues(route)
+	inFlight.Inc()
+	return func(status int) {
+		inFlight.Dec()
+		m.HTTPRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+		m.HTTPRequestDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// LoginAttempted counts a login by whether it succeeded
+func (m *Metrics) LoginAttempted(succeeded bool) {
+	if m == nil {
+		return
+	}
+	result := "failure"
+	if succeeded {
+		result = "success"
+	}
+	m.Logins.WithLabelValues(result).Inc()
+}
+
+// CustomersAdded counts n customers created from source
+func (m *Metrics) CustomersAdded(source string, n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.CustomersCreated.WithLabelValues(source).Add(float64(n))
+}
@@ -0,0 +1,65 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/metabbe3/go-backend/metrics"
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_RequestStarted(t *testing.T) {
+	m := metrics.New()
+
+	done := m.RequestStarted("/api/customer/:id", "GET")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPInFlight.WithLabelValues("/api/customer/:id")))
+	done(200)
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.HTTPInFlight.WithLabelValues("/api/customer/:id")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/api/customer/:id", "GET", "200")))
+
+	m.RequestStarted("", "GET")(404)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues(metrics.UnmatchedRoute, "GET", "404")))
+}
+
+func TestMetrics_NilRecordsNothing(t *testing.T) {
+	var m *metrics.Metrics
+	assert.NotPanics(t, func() {
+		m.RequestStarted("/livez", "GET")(200)
+		m.LoginAttempted(true)
+		m.CustomersAdded(metrics.SourceAPI, 1)
+	})
+}
+
+// sampleCount returns how many statements were timed with the given labels
+func sampleCount(t *testing.T, m *metrics.Metrics, labels ...string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, m.DBQueryDuration.WithLabelValues(labels...).(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics_InstrumentDB(t *testing.T) {
+	db := test.NewTestDB(t)
+	m := metrics.New()
+	require.NoError(t, m.InstrumentDB(db))
+
+	require.NoError(t, db.Create(&models.Organization{Name: "Acme"}).Error)
+	var organizations []models.Organization
+	require.NoError(t, db.Find(&organizations).Error)
+	assert.Error(t, db.Exec("SELECT * FROM missing_table").Error)
+
+	assert.Equal(t, 3, testutil.CollectAndCount(m.DBQueryDuration))
+	assert.Equal(t, uint64(1), sampleCount(t, m, "create", "organizations", "ok"))
+	assert.Equal(t, uint64(1), sampleCount(t, m, "query", "organizations", "ok"))
+	assert.Equal(t, uint64(1), sampleCount(t, m, "raw", "unknown", "error"))
+
+	// Pool stats are exported alongside
+	count, err := testutil.GatherAndCount(m.Registry, "go_sql_open_connections")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DBTimeout puts a deadline of timeout on the request context, which the repositories run their
+// queries with, so a slow query is cancelled instead of holding a connection. A zero timeout
+// leaves the request unbounded. A deadline can only be shortened, so routes needing a longer
+// timeout than their group must be registered in a group of their own.
+func DBTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
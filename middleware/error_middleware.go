@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+// ErrorHandler renders the errors handlers report with c.Error, so they don't build error
+// responses themselves. Domain errors (utils.AppError) keep their status, code and message;
+// anything else becomes a generic 500.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		RenderError(c)
+	}
+}
+
+// RenderError responds with the last error reported on c, unless a response was already written.
+// Controller tests call it after a handler, in place of the router's ErrorHandler.
+func RenderError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	utils.HandleError(c, c.Errors.Last().Err)
+}
@@ -1,23 +1,23 @@
 package middleware
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/tracing"
 	"github.com/metabbe3/go-backend/utils"
 )
 
-// JWTAuthMiddleware protects routes that require authentication
-func JWTAuthMiddleware() gin.HandlerFunc {
+// JWTAuthMiddleware protects routes that require authentication.
+// Tokens revoked through the store (logout, logout-all) are rejected even if still unexpired.
+func JWTAuthMiddleware(revocations utils.RevocationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
-		// Log request details
-		utils.Info("JWT Middleware: Checking Authorization header")
+		ctx := c.Request.Context()
 
 		if authHeader == "" {
-			utils.Warning("JWT Middleware: Missing Authorization header")
+			utils.WarningContext(ctx, "JWT Middleware: Missing Authorization header")
 			utils.SendUnauthorized(c, "Authorization header is missing")
 			c.Abort()
 			return
@@ -26,27 +26,47 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		// Extract token from "Bearer <token>"
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			utils.Warning("JWT Middleware: Invalid authorization format")
+			utils.WarningContext(ctx, "JWT Middleware: Invalid authorization format")
 			utils.SendUnauthorized(c, "Invalid authorization format")
 			c.Abort()
 			return
 		}
 
 		tokenString := tokenParts[1]
+		_, span := tracing.Start(ctx, "jwt.validate")
 		claims, err := utils.ValidateToken(tokenString)
+		span.End()
 		if err != nil {
-			utils.Error(fmt.Sprintf("JWT Middleware: Invalid or expired token - %v", err))
+			utils.WarningContext(ctx, "JWT Middleware: Invalid or expired token", "error", err)
 			utils.SendUnauthorized(c, "Invalid or expired token")
 			c.Abort()
 			return
 		}
 
+		_, span = tracing.Start(ctx, "jwt.revocation_check")
+		revoked, err := utils.IsRevoked(c.Request.Context(), revocations, claims)
+		span.End()
+		if err != nil {
+			utils.ErrorContext(ctx, "JWT Middleware: Failed to check token revocation", "error", err)
+			utils.SendInternalServerError(c, "Failed to verify token")
+			c.Abort()
+			return
+		}
+		if revoked {
+			utils.WarningContext(ctx, "JWT Middleware: Revoked token used", "user_id", claims.UserID)
+			utils.SendUnauthorized(c, "Token has been revoked")
+			c.Abort()
+			return
+		}
+
 		// Attach user claims to the context
+		c.Set("claims", claims)
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("orgID", claims.OrgID)
 
-		utils.Info(fmt.Sprintf("JWT Middleware: Authentication successful for userID: %d", claims.UserID))
+		utils.DebugContext(ctx, "JWT Middleware: Authentication successful", "user_id", claims.UserID)
 		c.Next()
 	}
 }
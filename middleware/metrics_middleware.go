@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/metrics"
+)
+
+// Metrics records the count, latency and in-flight requests of each route, labelled by its
+// template (e.g. /api/customer/:id) rather than the raw path
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := m.RequestStarted(c.FullPath(), c.Request.Method)
+		c.Next()
+		done(c.Writer.Status())
+	}
+}
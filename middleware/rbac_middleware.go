@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+// RequirePermission allows the request only if the authenticated user's role holds every
+// listed permission. It must run after JWTAuthMiddleware, which puts the role in the context.
+func RequirePermission(policy *utils.PolicyEngine, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+
+		allowed, err := policy.Can(c.Request.Context(), role, permissions...)
+		if err != nil {
+			utils.ErrorContext(c.Request.Context(), "RBAC Middleware: Failed to load permissions", "role", role, "error", err)
+			utils.SendInternalServerError(c, "Failed to check permissions")
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			utils.WarningContext(c.Request.Context(), "RBAC Middleware: Permission denied",
+				"user_id", c.GetUint("userID"), "role", role, "permissions", permissions)
+			utils.SendForbidden(c, "Insufficient permissions")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
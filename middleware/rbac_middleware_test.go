@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticPermissions grants fixed permissions per role
+type staticPermissions map[string][]string
+
+func (s staticPermissions) PermissionsForRole(_ context.Context, role string) ([]string, error) {
+	if role == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	return s[role], nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := utils.NewPolicyEngine(staticPermissions{
+		"owner": {"customers:*"},
+		"user":  {utils.PermCustomersRead},
+	}, time.Minute)
+
+	tests := []struct {
+		name        string
+		role        string
+		permissions []string
+		expectCode  int
+		expectMsg   string
+	}{
+		{name: "Allowed", role: "user", permissions: []string{utils.PermCustomersRead}, expectCode: http.StatusOK},
+		{name: "Allowed By Wildcard", role: "owner", permissions: []string{utils.PermCustomersDelete}, expectCode: http.StatusOK},
+		{name: "Missing Permission", role: "user", permissions: []string{utils.PermCustomersRead, utils.PermCustomersDelete}, expectCode: http.StatusForbidden, expectMsg: "Insufficient permissions"},
+		{name: "No Role", role: "", permissions: []string{utils.PermCustomersRead}, expectCode: http.StatusForbidden, expectMsg: "Insufficient permissions"},
+		{name: "Permissions Unavailable", role: "broken", permissions: []string{utils.PermCustomersRead}, expectCode: http.StatusInternalServerError, expectMsg: "Failed to check permissions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/customers", func(c *gin.Context) {
+				if tt.role != "" {
+					c.Set("role", tt.role)
+				}
+				c.Next()
+			}, RequirePermission(policy, tt.permissions...), func(c *gin.Context) {
+				c.String(http.StatusOK, "handled")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/customers", nil))
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			if tt.expectCode == http.StatusOK {
+				assert.Equal(t, "handled", w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tt.expectMsg)
+				assert.NotContains(t, w.Body.String(), "handled", "the handler must not run")
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/metabbe3/go-backend/utils"
+)
+
+// RequireOrganization allows the request only if its token is scoped to an organization.
+// Users without a membership get organization 0, which every such user shares, so
+// tenant data must never be read or written under it. It must run after JWTAuthMiddleware.
+func RequireOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetUint("orgID") == 0 {
+			utils.WarningContext(c.Request.Context(), "Tenant Middleware: Request without organization",
+				"user_id", c.GetUint("userID"), "path", c.FullPath())
+			utils.SendForbidden(c, "Join or create an organization first")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		orgID      *uint
+		expectCode int
+		expectMsg  string
+	}{
+		{name: "Scoped To Organization", orgID: func() *uint { id := uint(3); return &id }(), expectCode: http.StatusOK, expectMsg: "handled"},
+		{name: "Organization Zero", orgID: new(uint), expectCode: http.StatusForbidden, expectMsg: "Join or create an organization first"},
+		{name: "No Organization Claim", expectCode: http.StatusForbidden, expectMsg: "Join or create an organization first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/customers", func(c *gin.Context) {
+				if tt.orgID != nil {
+					c.Set("orgID", *tt.orgID)
+				}
+				c.Next()
+			}, RequireOrganization(), func(c *gin.Context) {
+				c.String(http.StatusOK, "handled")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/customers", nil))
+
+			assert.Equal(t, tt.expectCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectMsg)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Patterns for the statements that change which objects exist. Identifiers are quoted with
+// backticks in the MySQL and SQLite scripts and with double quotes in the PostgreSQL ones.
+var (
+	createTablePattern = regexp.MustCompile("^CREATE TABLE [`\"](\\w+)[`\"]")
+	columnPattern      = regexp.MustCompile("^[`\"](\\w+)[`\"] ")
+	inlineIndexPattern = regexp.MustCompile("^(?:UNIQUE )?INDEX [`\"](\\w+)[`\"]")
+	createIndexPattern = regexp.MustCompile("^CREATE (?:UNIQUE )?INDEX [`\"](\\w+)[`\"] ON [`\"](\\w+)[`\"]")
+	dropTablePattern   = regexp.MustCompile("^DROP TABLE (?:IF EXISTS )?[`\"](\\w+)[`\"]")
+	dropIndexPattern   = regexp.MustCompile("^DROP INDEX (?:IF EXISTS )?[`\"](\\w+)[`\"]")
+	alterTablePattern  = regexp.MustCompile("^ALTER TABLE [`\"](\\w+)[`\"] (RENAME TO|ADD COLUMN|DROP COLUMN) [`\"](\\w+)[`\"]")
+)
+
+// schemaObject is a table, column or index created by migrations
+type schemaObject struct {
+	Table  string
+	Column string
+	Index  string
+}
+
+func (o schemaObject) String() string {
+	switch {
+	case o.Column != "":
+		return "column " + o.Table + "." + o.Column
+	case o.Index != "":
+		return "index " + o.Index + " on " + o.Table
+	default:
+		return "table " + o.Table
+	}
+}
+
+// Baseline records the migrations up to version as applied without running them, so a database
+// whose schema was created another way, e.g. by AutoMigrate, adopts versioned migrations.
+// It refuses a database that already has applied migrations, and one missing any table, column
+// or index those migrations leave behind. Data changes in the skipped migrations are not checked.
+func (m *Migrator) Baseline(version int64) ([]Migration, error) {
+	var adopted []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			adopted = append(adopted, migration)
+		}
+	}
+	if len(adopted) == 0 || adopted[len(adopted)-1].Version != version {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	err := m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			return errors.New("the database already has applied migrations, baseline only adopts unversioned databases")
+		}
+
+		var missing []string
+		missingTables := make(map[string]bool)
+		for _, object := range schemaAfter(adopted) {
+			if missingTables[object.Table] || m.exists(object) {
+				continue
+			}
+			if object.Column == "" && object.Index == "" {
+				missingTables[object.Table] = true
+			}
+			missing = append(missing, object.String())
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("the schema does not match version %d, missing %s", version, strings.Join(missing, ", "))
+		}
+
+		if err := m.ensureTable(); err != nil {
+			return err
+		}
+		return m.DB.Transaction(func(tx *gorm.DB) error {
+			for _, migration := range adopted {
+				if err := record(tx, migration); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adopted, nil
+}
+
+// exists reports whether the database has a schema object
+func (m *Migrator) exists(object schemaObject) bool {
+	migrator := m.DB.Migrator()
+	switch {
+	case object.Column != "":
+		return migrator.HasColumn(object.Table, object.Column)
+	case object.Index != "":
+		return migrator.HasIndex(object.Table, object.Index)
+	default:
+		return migrator.HasTable(object.Table)
+	}
+}
+
+// schemaAfter replays the up scripts of migrations and lists the tables, columns and indexes
+// they leave behind, in creation order. It follows CREATE and DROP of tables and indexes and
+// ALTER TABLE renames and single column changes. Constraints are not included.
+func schemaAfter(migrations []Migration) []schemaObject {
+	var objects []schemaObject
+	remove := func(dropped func(schemaObject) bool) {
+		kept := objects[:0]
+		for _, object := range objects {
+			if !dropped(object) {
+				kept = append(kept, object)
+			}
+		}
+		objects = kept
+	}
+
+	for _, migration := range migrations {
+		for _, statement := range splitStatements(migration.Up) {
+			lines := strings.Split(statement, "\n")
+			first := strings.TrimSpace(lines[0])
+
+			if match := createTablePattern.FindStringSubmatch(first); match != nil {
+				table := match[1]
+				objects = append(objects, schemaObject{Table: table})
+				for _, line := range lines[1:] {
+					line = strings.TrimSpace(line)
+					if match := inlineIndexPattern.FindStringSubmatch(line); match != nil {
+						objects = append(objects, schemaObject{Table: table, Index: match[1]})
+					} else if match := columnPattern.FindStringSubmatch(line); match != nil {
+						objects = append(objects, schemaObject{Table: table, Column: match[1]})
+					}
+				}
+			} else if match := createIndexPattern.FindStringSubmatch(first); match != nil {
+				objects = append(objects, schemaObject{Table: match[2], Index: match[1]})
+			} else if match := dropTablePattern.FindStringSubmatch(first); match != nil {
+				remove(func(o schemaObject) bool { return o.Table == match[1] })
+			} else if match := dropIndexPattern.FindStringSubmatch(first); match != nil {
+				remove(func(o schemaObject) bool { return o.Index == match[1] })
+			} else if match := alterTablePattern.FindStringSubmatch(first); match != nil {
+				table, name := match[1], match[3]
+				switch match[2] {
+				case "RENAME TO":
+					for i := range objects {
+						if objects[i].Table == table {
+							objects[i].Table = name
+						}
+					}
+				case "ADD COLUMN":
+					objects = append(objects, schemaObject{Table: table, Column: name})
+				case "DROP COLUMN":
+					remove(func(o schemaObject) bool { return o.Table == table && o.Column == name })
+				}
+			}
+		}
+	}
+	return objects
+}
+
+// record marks a migration as applied
+func record(tx *gorm.DB, migration Migration) error {
+	return tx.Exec("INSERT INTO "+versionTable+" (version, name, applied_at) VALUES (?, ?, ?)",
+		migration.Version, migration.Name, time.Now().UTC()).Error
+}
@@ -0,0 +1,106 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/metabbe3/go-backend/config"
+	"github.com/metabbe3/go-backend/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite opens an empty in-memory database, optionally with the schema from AutoMigrate
+func openSQLite(t *testing.T, autoMigrate bool) *gorm.DB {
+	t.Helper()
+	dialector, err := config.NewDialector(config.DatabaseConfig{Driver: config.DriverSQLite, DSN: ":memory:"})
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if autoMigrate {
+		require.NoError(t, config.MigrateDB(db, config.DatabaseConfig{AutoMigrate: true}))
+	}
+	return db
+}
+
+func TestMigrator_Baseline(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoMigrate bool
+		dropTable   string // Dropped after AutoMigrate
+		version     int64
+		expectErr   string
+	}{
+		{name: "Success - AutoMigrate Schema", autoMigrate: true, version: 4},
+		{name: "Failure - Empty Database", version: 1, expectErr: "the schema does not match version 1, missing table users, table organizations,"},
+		{name: "Failure - Schema Behind Version", autoMigrate: true, dropTable: "organization_invitations", version: 4, expectErr: "missing table organization_invitations"},
+		{name: "Failure - Schema Ahead Of Version", autoMigrate: true, version: 2, expectErr: "missing column users.token"},
+		{name: "Failure - Unknown Version", autoMigrate: true, version: 99, expectErr: "unknown migration version 99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSQLite(t, tt.autoMigrate)
+			if tt.dropTable != "" {
+				require.NoError(t, db.Migrator().DropTable(tt.dropTable))
+			}
+			migrator, err := migrations.NewMigrator(db)
+			require.NoError(t, err)
+
+			adopted, err := migrator.Baseline(tt.version)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				version, err := migrator.Version()
+				assert.NoError(t, err)
+				assert.Zero(t, version)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, adopted, int(tt.version))
+
+			version, err := migrator.Version()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.version, version)
+
+			applied, err := migrator.Up()
+			require.NoError(t, err)
+			assert.Len(t, applied, int(migrator.Latest()-tt.version))
+
+			_, err = migrator.Baseline(tt.version)
+			assert.ErrorContains(t, err, "already has applied migrations")
+		})
+	}
+}
+
+func TestMigrator_VersionDoesNotCreateTable(t *testing.T) {
+	db := openSQLite(t, false)
+	migrator, err := migrations.NewMigrator(db)
+	require.NoError(t, err)
+
+	version, err := migrator.Version()
+	assert.NoError(t, err)
+	assert.Zero(t, version)
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.Nil(t, statuses[0].AppliedAt)
+	assert.False(t, db.Migrator().HasTable("schema_migrations"))
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable("schema_migrations"))
+}
+
+func TestMigrator_StrictInitialSchema(t *testing.T) {
+	db := openSQLite(t, true)
+	migrator, err := migrations.NewMigrator(db)
+	require.NoError(t, err)
+
+	_, err = migrator.Up()
+	assert.ErrorContains(t, err, "migration 1_initial_schema", "an unversioned schema is adopted with Baseline, never silently")
+}
@@ -0,0 +1,361 @@
+// Package migrations applies the versioned SQL migrations embedded in the binary.
+//
+// Migrations live in one directory per SQL dialect as pairs of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql. Applied versions are recorded
+// in the schema_migrations table, and an advisory lock makes sure only one instance
+// migrates at a time.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// lockName identifies the MySQL advisory lock held while migrating
+const lockName = "schema_migrations"
+
+// lockKey identifies the PostgreSQL advisory lock held while migrating
+const lockKey int64 = 7_203_611_455_001
+
+// versionTable records the applied migrations
+const versionTable = "schema_migrations"
+
+// DefaultLockTimeout is how long a migrator waits for another instance to finish migrating
+const DefaultLockTimeout = 5 * time.Minute
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations of a database's dialect
+type Migrator struct {
+	DB          *gorm.DB
+	Dialect     string
+	LockTimeout time.Duration
+	migrations  []Migration
+}
+
+// NewMigrator loads the embedded migrations for the database's dialect
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Dialect: dialect, LockTimeout: DefaultLockTimeout, migrations: migrations}, nil
+}
+
+// Load reads and pairs the embedded migrations of a dialect, sorted by version
+func Load(dialect string) ([]Migration, error) {
+	names, err := fs.Glob(files, dialect+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, name := range names {
+		version, label, direction, err := parseFilename(path.Base(name))
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: label}
+			byVersion[version] = migration
+		} else if migration.Name != label {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, migration.Name, label)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseFilename splits "0002_add_column.up.sql" into 2, "add_column" and "up"
+func parseFilename(name string) (int64, string, string, error) {
+	base := strings.TrimSuffix(name, ".sql")
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", fmt.Errorf("migration %s: expected .up.sql or .down.sql", name)
+	}
+	base = strings.TrimSuffix(base, direction)
+
+	prefix, label, ok := strings.Cut(base, "_")
+	if !ok || label == "" {
+		return 0, "", "", fmt.Errorf("migration %s: expected <version>_<name>", name)
+	}
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %s: invalid version %q", name, prefix)
+	}
+	return version, label, strings.TrimPrefix(direction, "."), nil
+}
+
+// WithContext returns a copy of the migrator whose queries use ctx
+func (m *Migrator) WithContext(ctx context.Context) *Migrator {
+	clone := *m
+	clone.DB = m.DB.WithContext(ctx)
+	return &clone
+}
+
+// Latest returns the version of the newest known migration
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the newest applied migration, 0 if none
+func (m *Migrator) Version() (int64, error) {
+	if !m.DB.Migrator().HasTable(versionTable) {
+		return 0, nil
+	}
+
+	var version sql.NullInt64
+	if err := m.DB.Raw("SELECT MAX(version) FROM schema_migrations").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version.Int64, nil
+}
+
+// Status lists every known migration and when it was applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns the ones applied
+func (m *Migrator) Up() ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		if err := m.ensureTable(); err != nil {
+			return err
+		}
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(migration, migration.Up, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the given number of most recently applied migrations and returns the ones reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.run(migration, migration.Down, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// run executes one direction of a migration and records it. Statements run in a transaction,
+// though MySQL commits DDL implicitly, so a failed MySQL migration may need manual cleanup.
+func (m *Migrator) run(migration Migration, script string, up bool) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range splitStatements(script) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		if up {
+			return record(tx, migration)
+		}
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+	})
+}
+
+// applied returns the applied versions and when they were applied, none before the first Up
+func (m *Migrator) applied() (map[int64]time.Time, error) {
+	if !m.DB.Migrator().HasTable(versionTable) {
+		return map[int64]time.Time{}, nil
+	}
+
+	var rows []struct {
+		Version   int64
+		AppliedAt time.Time
+	}
+	if err := m.DB.Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// ensureTable creates schema_migrations if needed. Only the writing commands call it,
+// so reading the version, e.g. for a health check, never changes the schema.
+func (m *Migrator) ensureTable() error {
+	return m.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+}
+
+// withLock runs fn while holding the database's advisory migration lock. Advisory locks
+// belong to a session, so the lock is taken and released on one dedicated connection.
+func (m *Migrator) withLock(fn func() error) error {
+	if m.Dialect == "sqlite" {
+		// SQLite has no advisory locks; its database-level write lock serializes migrations
+		return fn()
+	}
+
+	db, err := m.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch m.Dialect {
+	case "mysql":
+		var acquired sql.NullInt64
+		timeout := int(m.LockTimeout / time.Second)
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, timeout).Scan(&acquired); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		if acquired.Int64 != 1 {
+			return fmt.Errorf("acquire migration lock: timed out after %s, another instance is migrating", m.LockTimeout)
+		}
+		defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+	case "postgres":
+		// pg_advisory_lock can't time out, so poll the non-blocking variant instead
+		deadline := time.Now().Add(m.LockTimeout)
+		for {
+			var acquired bool
+			if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&acquired); err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			if acquired {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("acquire migration lock: timed out after %s, another instance is migrating", m.LockTimeout)
+			}
+			time.Sleep(time.Second)
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+	default:
+		return fmt.Errorf("migration locking is not supported for dialect %q", m.Dialect)
+	}
+
+	return fn()
+}
+
+// splitStatements splits a script on semicolons that end a line, dropping "--" comment lines
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
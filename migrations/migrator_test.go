@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load("mysql")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "versions should have no gaps")
+		assert.NotEmpty(t, splitStatements(migration.Up))
+		assert.NotEmpty(t, splitStatements(migration.Down))
+	}
+
+	_, err = Load("oracle")
+	assert.ErrorContains(t, err, `no migrations for dialect "oracle"`)
+}
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name          string
+		expectVersion int64
+		expectLabel   string
+		expectDir     string
+		expectErr     string
+	}{
+		{name: "0001_initial_schema.up.sql", expectVersion: 1, expectLabel: "initial_schema", expectDir: "up"},
+		{name: "0012_drop_user_token.down.sql", expectVersion: 12, expectLabel: "drop_user_token", expectDir: "down"},
+		{name: "0001_initial_schema.sql", expectErr: "expected .up.sql or .down.sql"},
+		{name: "initial.up.sql", expectErr: "expected <version>_<name>"},
+		{name: "v1_initial.up.sql", expectErr: `invalid version "v1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, label, direction, err := parseFilename(tt.name)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectVersion, version)
+			assert.Equal(t, tt.expectLabel, label)
+			assert.Equal(t, tt.expectDir, direction)
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Comment line; not a statement
+CREATE TABLE a (
+  id int -- trailing comments stay
+);
+
+DROP TABLE b;
+INSERT INTO c VALUES ('x;y')`
+
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\n  id int -- trailing comments stay\n)",
+		"DROP TABLE b",
+		"INSERT INTO c VALUES ('x;y')",
+	}, splitStatements(script))
+}
+
+func TestSchemaAfter(t *testing.T) {
+	script := "CREATE TABLE `a` (\n  `id` bigint,\n  `name` text,\n  PRIMARY KEY (`id`),\n  UNIQUE INDEX `idx_a_name` (`name`),\n  CONSTRAINT `fk_a` FOREIGN KEY (`id`) REFERENCES `b` (`id`)\n);\n" +
+		"CREATE INDEX \"idx_c_x\" ON \"c\" (\"x\");\nALTER TABLE `d` ADD COLUMN `e` int;"
+
+	assert.Equal(t, []schemaObject{
+		{Table: "a"},
+		{Table: "a", Column: "id"},
+		{Table: "a", Column: "name"},
+		{Table: "a", Index: "idx_a_name"},
+		{Table: "c", Index: "idx_c_x"},
+		{Table: "d", Column: "e"},
+	}, schemaAfter([]Migration{{Up: script}}))
+
+	// Later migrations drop and rename what earlier ones created
+	changes := "ALTER TABLE `a` DROP COLUMN `name`;\nDROP INDEX \"idx_c_x\";\nDROP TABLE IF EXISTS `d`;\nALTER TABLE `a` RENAME TO `f`;"
+	assert.Equal(t, []schemaObject{
+		{Table: "f"},
+		{Table: "f", Column: "id"},
+		{Table: "f", Index: "idx_a_name"},
+	}, schemaAfter([]Migration{{Up: script}, {Up: changes}}))
+
+	for _, dialect := range []string{"mysql", "postgres", "sqlite"} {
+		migrations, err := Load(dialect)
+		assert.NoError(t, err)
+		assert.Contains(t, schemaAfter(migrations[:1]), schemaObject{Table: "customers", Index: "idx_customers_org_phone"}, dialect)
+
+		latest := schemaAfter(migrations)
+		assert.Contains(t, latest, schemaObject{Table: "users", Column: "email"}, dialect)
+		assert.Contains(t, latest, schemaObject{Table: "users", Index: "idx_users_deleted_at"}, dialect)
+		assert.NotContains(t, latest, schemaObject{Table: "users", Column: "token"}, dialect)
+	}
+}
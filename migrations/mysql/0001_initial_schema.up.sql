@@ -0,0 +1,141 @@
+-- Baseline schema, matching what AutoMigrate created before versioned migrations.
+-- Databases created by AutoMigrate adopt it with `migrate baseline 1`, which checks the schema first.
+
+CREATE TABLE `users` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` longtext NOT NULL,
+  `email` varchar(191) NOT NULL,
+  `password` longtext NOT NULL,
+  `role` varchar(191) DEFAULT 'user',
+  `token` varchar(191),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_users_deleted_at` (`deleted_at`),
+  CONSTRAINT `uni_users_email` UNIQUE (`email`),
+  CONSTRAINT `uni_users_token` UNIQUE (`token`)
+);
+
+CREATE TABLE `organizations` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` longtext NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_organizations_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE `organization_members` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `organization_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `role` varchar(50) NOT NULL DEFAULT 'user',
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_org_members_org_user` (`organization_id`, `user_id`),
+  INDEX `idx_organization_members_user_id` (`user_id`),
+  CONSTRAINT `fk_organization_members_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `customers` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `organization_id` bigint unsigned NOT NULL,
+  `name` longtext NOT NULL,
+  `email` varchar(191) DEFAULT NULL,
+  `phone` varchar(20) NOT NULL,
+  `address` varchar(191) DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_customers_deleted_at` (`deleted_at`),
+  INDEX `idx_customers_organization_id` (`organization_id`),
+  UNIQUE INDEX `idx_customers_org_email` (`organization_id`, `email`),
+  UNIQUE INDEX `idx_customers_org_phone` (`organization_id`, `phone`)
+);
+
+CREATE TABLE `refresh_tokens` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `organization_id` bigint unsigned,
+  `token_hash` varchar(64) NOT NULL,
+  `family_id` varchar(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `revoked_at` datetime(3) NULL,
+  `replaced_by_id` bigint unsigned,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_refresh_tokens_user_id` (`user_id`),
+  UNIQUE INDEX `idx_refresh_tokens_token_hash` (`token_hash`),
+  INDEX `idx_refresh_tokens_family_id` (`family_id`)
+);
+
+CREATE TABLE `revoked_tokens` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `jti` varchar(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_revoked_tokens_jti` (`jti`),
+  INDEX `idx_revoked_tokens_expires_at` (`expires_at`)
+);
+
+CREATE TABLE `user_token_revocations` (
+  `user_id` bigint unsigned,
+  `revoked_at` datetime(3) NOT NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`user_id`)
+);
+
+CREATE TABLE `roles` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` varchar(50) NOT NULL,
+  `description` varchar(191) DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_roles_name` (`name`)
+);
+
+CREATE TABLE `permissions` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_permissions_name` (`name`)
+);
+
+CREATE TABLE `role_permissions` (
+  `role_id` bigint unsigned,
+  `permission_id` bigint unsigned,
+  PRIMARY KEY (`role_id`, `permission_id`),
+  CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`),
+  CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions` (`id`)
+);
+
+CREATE TABLE `messages` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `organization_id` bigint unsigned NOT NULL,
+  `customer_id` bigint unsigned NOT NULL,
+  `direction` varchar(10) NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `body` text,
+  `template_name` varchar(100) DEFAULT NULL,
+  `provider_message_id` varchar(128),
+  `status` varchar(20) NOT NULL,
+  `error` text,
+  `sent_at` datetime(3) NULL,
+  `delivered_at` datetime(3) NULL,
+  `read_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_messages_organization_id` (`organization_id`),
+  INDEX `idx_messages_customer_id` (`customer_id`),
+  UNIQUE INDEX `idx_messages_provider_message_id` (`provider_message_id`),
+  INDEX `idx_messages_status` (`status`)
+);
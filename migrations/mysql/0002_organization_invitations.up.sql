@@ -0,0 +1,14 @@
+CREATE TABLE `organization_invitations` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `organization_id` bigint unsigned NOT NULL,
+  `email` varchar(191) NOT NULL,
+  `role` varchar(50) NOT NULL DEFAULT 'user',
+  `invited_by_id` bigint unsigned NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_org_invitations_org_email` (`organization_id`, `email`),
+  INDEX `idx_organization_invitations_email` (`email`),
+  CONSTRAINT `fk_organization_invitations_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations` (`id`) ON DELETE CASCADE
+);
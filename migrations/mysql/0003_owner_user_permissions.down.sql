@@ -0,0 +1,3 @@
+INSERT INTO `role_permissions` (`role_id`, `permission_id`)
+SELECT r.`id`, p.`id` FROM `roles` r, `permissions` p
+WHERE r.`name` = 'owner' AND p.`name` = 'users:*';
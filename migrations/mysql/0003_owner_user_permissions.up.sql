@@ -0,0 +1,5 @@
+-- Owners manage memberships, not accounts: users are global, so users:write and
+-- users:delete on an owner let them take over accounts of other organizations.
+DELETE FROM `role_permissions`
+WHERE `role_id` IN (SELECT `id` FROM `roles` WHERE `name` = 'owner')
+  AND `permission_id` IN (SELECT `id` FROM `permissions` WHERE `name` IN ('users:*', 'users:write', 'users:delete'));
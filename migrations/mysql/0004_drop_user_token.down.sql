@@ -0,0 +1,2 @@
+ALTER TABLE `users` ADD COLUMN `token` varchar(191);
+ALTER TABLE `users` ADD CONSTRAINT `uni_users_token` UNIQUE (`token`);
@@ -0,0 +1,3 @@
+-- The token column held the last issued access token and is no longer written: access tokens
+-- are revoked by their jti. Dropping the column drops its unique constraint too.
+ALTER TABLE `users` DROP COLUMN `token`;
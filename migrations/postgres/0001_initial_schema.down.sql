@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "messages";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "user_token_revocations";
+DROP TABLE IF EXISTS "revoked_tokens";
+DROP TABLE IF EXISTS "refresh_tokens";
+DROP TABLE IF EXISTS "customers";
+DROP TABLE IF EXISTS "organization_members";
+DROP TABLE IF EXISTS "organizations";
+DROP TABLE IF EXISTS "users";
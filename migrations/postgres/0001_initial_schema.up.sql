@@ -0,0 +1,141 @@
+-- Baseline schema, matching what AutoMigrate creates for these models.
+-- Databases created by AutoMigrate adopt it with `migrate baseline 1`, which checks the schema first.
+
+CREATE TABLE "users" (
+  "id" bigserial,
+  "name" text NOT NULL,
+  "email" text NOT NULL,
+  "password" text NOT NULL,
+  "role" text DEFAULT 'user',
+  "token" text,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uni_users_email" UNIQUE ("email"),
+  CONSTRAINT "uni_users_token" UNIQUE ("token")
+);
+CREATE INDEX "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE "organizations" (
+  "id" bigserial,
+  "name" text NOT NULL,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_organizations_deleted_at" ON "organizations" ("deleted_at");
+
+CREATE TABLE "organization_members" (
+  "id" bigserial,
+  "organization_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "role" varchar(50) NOT NULL DEFAULT 'user',
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_organization_members_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations" ("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "idx_org_members_org_user" ON "organization_members" ("organization_id", "user_id");
+CREATE INDEX "idx_organization_members_user_id" ON "organization_members" ("user_id");
+
+CREATE TABLE "customers" (
+  "id" bigserial,
+  "organization_id" bigint NOT NULL,
+  "name" text NOT NULL,
+  "email" text DEFAULT NULL,
+  "phone" varchar(20) NOT NULL,
+  "address" text DEFAULT NULL,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_customers_deleted_at" ON "customers" ("deleted_at");
+CREATE INDEX "idx_customers_organization_id" ON "customers" ("organization_id");
+CREATE UNIQUE INDEX "idx_customers_org_email" ON "customers" ("organization_id", "email");
+CREATE UNIQUE INDEX "idx_customers_org_phone" ON "customers" ("organization_id", "phone");
+
+CREATE TABLE "refresh_tokens" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "organization_id" bigint,
+  "token_hash" varchar(64) NOT NULL,
+  "family_id" varchar(64) NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz,
+  "replaced_by_id" bigint,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+CREATE UNIQUE INDEX "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+
+CREATE TABLE "revoked_tokens" (
+  "id" bigserial,
+  "jti" varchar(64) NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_revoked_tokens_jti" ON "revoked_tokens" ("jti");
+CREATE INDEX "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+
+CREATE TABLE "user_token_revocations" (
+  "user_id" bigint,
+  "revoked_at" timestamptz NOT NULL,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("user_id")
+);
+
+CREATE TABLE "roles" (
+  "id" bigserial,
+  "name" varchar(50) NOT NULL,
+  "description" text DEFAULT NULL,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE "permissions" (
+  "id" bigserial,
+  "name" varchar(100) NOT NULL,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE "role_permissions" (
+  "role_id" bigint,
+  "permission_id" bigint,
+  PRIMARY KEY ("role_id", "permission_id"),
+  CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id"),
+  CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id")
+);
+
+CREATE TABLE "messages" (
+  "id" bigserial,
+  "organization_id" bigint NOT NULL,
+  "customer_id" bigint NOT NULL,
+  "direction" varchar(10) NOT NULL,
+  "type" varchar(20) NOT NULL,
+  "body" text,
+  "template_name" varchar(100) DEFAULT NULL,
+  "provider_message_id" varchar(128),
+  "status" varchar(20) NOT NULL,
+  "error" text,
+  "sent_at" timestamptz,
+  "delivered_at" timestamptz,
+  "read_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_messages_organization_id" ON "messages" ("organization_id");
+CREATE INDEX "idx_messages_customer_id" ON "messages" ("customer_id");
+CREATE UNIQUE INDEX "idx_messages_provider_message_id" ON "messages" ("provider_message_id");
+CREATE INDEX "idx_messages_status" ON "messages" ("status");
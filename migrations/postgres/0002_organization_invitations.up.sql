@@ -0,0 +1,14 @@
+CREATE TABLE "organization_invitations" (
+  "id" bigserial,
+  "organization_id" bigint NOT NULL,
+  "email" varchar(191) NOT NULL,
+  "role" varchar(50) NOT NULL DEFAULT 'user',
+  "invited_by_id" bigint NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_organization_invitations_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations" ("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "idx_org_invitations_org_email" ON "organization_invitations" ("organization_id", "email");
+CREATE INDEX "idx_organization_invitations_email" ON "organization_invitations" ("email");
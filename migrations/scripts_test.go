@@ -0,0 +1,38 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/metabbe3/go-backend/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigration_DropUserToken(t *testing.T) {
+	db := openSQLite(t, false)
+	migrator, err := migrations.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	_, err = migrator.Down(1)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec("INSERT INTO users (name, email, password, token) VALUES ('Alice', 'alice@example.com', 'x', 'token-a'), ('Bob', 'bob@example.com', 'x', NULL), ('Dan', 'dan@example.com', 'x', NULL)").Error)
+	require.NoError(t, db.Exec("DELETE FROM users WHERE email = 'dan@example.com'").Error)
+
+	// SQLite rebuilds the table, which must keep the rows, the id sequence and the email constraint
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasColumn("users", "token"))
+	assert.True(t, db.Migrator().HasIndex("users", "idx_users_deleted_at"))
+
+	var emails []string
+	require.NoError(t, db.Table("users").Order("id").Pluck("email", &emails).Error)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, emails)
+
+	assert.Error(t, db.Exec("INSERT INTO users (name, email, password) VALUES ('Alice', 'alice@example.com', 'x')").Error)
+	require.NoError(t, db.Exec("INSERT INTO users (name, email, password) VALUES ('Carol', 'carol@example.com', 'x')").Error)
+	var id int
+	require.NoError(t, db.Table("users").Select("id").Where("email = ?", "carol@example.com").Scan(&id).Error)
+	assert.Equal(t, 4, id, "the id of the deleted user isn't reused")
+}
@@ -0,0 +1,13 @@
+CREATE TABLE `organization_invitations` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `organization_id` integer NOT NULL,
+  `email` text NOT NULL,
+  `role` text NOT NULL DEFAULT 'user',
+  `invited_by_id` integer NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `fk_organization_invitations_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations` (`id`) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX `idx_org_invitations_org_email` ON `organization_invitations` (`organization_id`, `email`);
+CREATE INDEX `idx_organization_invitations_email` ON `organization_invitations` (`email`);
@@ -0,0 +1,2 @@
+ALTER TABLE `users` ADD COLUMN `token` text;
+CREATE UNIQUE INDEX `uni_users_token` ON `users` (`token`);
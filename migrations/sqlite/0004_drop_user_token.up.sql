@@ -0,0 +1,22 @@
+-- The token column held the last issued access token and is no longer written: access tokens
+-- are revoked by their jti. SQLite can't drop a UNIQUE column, so the table is rebuilt.
+-- No table references users, so dropping it doesn't touch other rows.
+CREATE TABLE `users_new` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `email` text NOT NULL,
+  `password` text NOT NULL,
+  `role` text DEFAULT 'user',
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+INSERT INTO `users_new` (`id`, `name`, `email`, `password`, `role`, `created_at`, `updated_at`, `deleted_at`)
+SELECT `id`, `name`, `email`, `password`, `role`, `created_at`, `updated_at`, `deleted_at` FROM `users`;
+-- Keep the id sequence so ids of deleted users, which revocations may still name, aren't reused
+DELETE FROM `sqlite_sequence` WHERE `name` = 'users_new';
+INSERT INTO `sqlite_sequence` (`name`, `seq`) SELECT 'users_new', `seq` FROM `sqlite_sequence` WHERE `name` = 'users';
+DROP TABLE `users`;
+ALTER TABLE `users_new` RENAME TO `users`;
+CREATE INDEX `idx_users_deleted_at` ON `users` (`deleted_at`);
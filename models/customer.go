@@ -8,12 +8,13 @@ import (
 
 // Customer struct represents a customer in the system
 type Customer struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"not null" json:"name"`             // Mandatory name
-	Email     string         `gorm:"unique;default:null" json:"email"` // Optional email (nullable)
-	Phone     string         `gorm:"not null" json:"phone"`            // Mandatory phone number for WhatsApp
-	Address   string         `gorm:"default:null" json:"address"`      // Optional address (nullable)
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	OrganizationID uint           `gorm:"not null;index;uniqueIndex:idx_customers_org_email;uniqueIndex:idx_customers_org_phone" json:"organization_id"` // Owning tenant
+	Name           string         `gorm:"not null" json:"name"`                                                                                          // Mandatory name
+	Email          string         `gorm:"uniqueIndex:idx_customers_org_email;default:null" json:"email"`                                                 // Optional email (nullable), unique per organization
+	Phone          string         `gorm:"size:20;not null;uniqueIndex:idx_customers_org_phone" json:"phone"`                                             // Mandatory phone number for WhatsApp in E.164, unique per organization
+	Address        string         `gorm:"default:null" json:"address"`                                                                                   // Optional address (nullable)
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// Message directions
+const (
+	MessageOutbound = "outbound"
+	MessageInbound  = "inbound"
+)
+
+// Message delivery statuses, in the order a message normally goes through them
+const (
+	MessageStatusQueued    = "queued"
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+	MessageStatusFailed    = "failed"
+	MessageStatusReceived  = "received" // Inbound messages
+)
+
+// Message is a WhatsApp message exchanged with a customer
+type Message struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID    uint       `gorm:"not null;index" json:"organization_id"`
+	CustomerID        uint       `gorm:"not null;index" json:"customer_id"`
+	Direction         string     `gorm:"size:10;not null" json:"direction"`
+	Type              string     `gorm:"size:20;not null" json:"type"`
+	Body              string     `gorm:"type:text" json:"body"`
+	TemplateName      string     `gorm:"size:100;default:null" json:"template_name,omitempty"`
+	ProviderMessageID *string    `gorm:"size:128;uniqueIndex" json:"provider_message_id,omitempty"` // Set once the provider accepted the message
+	Status            string     `gorm:"size:20;not null;index" json:"status"`
+	Error             string     `gorm:"type:text" json:"error,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt       *time.Time `json:"delivered_at,omitempty"`
+	ReadAt            *time.Time `json:"read_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// statusRank orders the delivery statuses of outbound messages
+var statusRank = map[string]int{
+	MessageStatusQueued:    0,
+	MessageStatusSent:      1,
+	MessageStatusDelivered: 2,
+	MessageStatusRead:      3,
+}
+
+// ApplyStatus records a delivery status reported by the provider and reports whether it changed the message.
+// Callbacks can arrive out of order, so a message never moves back (a late "delivered" after "read" is ignored).
+func (m *Message) ApplyStatus(status string, at time.Time, reason string) bool {
+	current, known := statusRank[m.Status]
+
+	switch status {
+	case MessageStatusFailed:
+		if m.Status == MessageStatusFailed || (known && current >= statusRank[MessageStatusDelivered]) {
+			return false
+		}
+		m.Status = MessageStatusFailed
+		m.Error = reason
+		return true
+	case MessageStatusSent, MessageStatusDelivered, MessageStatusRead:
+		if known && current >= statusRank[status] {
+			return false
+		}
+	default:
+		return false
+	}
+
+	m.Status = status
+	switch status {
+	case MessageStatusSent:
+		if m.SentAt == nil {
+			m.SentAt = &at
+		}
+	case MessageStatusDelivered:
+		m.DeliveredAt = &at
+	case MessageStatusRead:
+		m.ReadAt = &at
+		if m.DeliveredAt == nil {
+			m.DeliveredAt = &at
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessage_ApplyStatus(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	earlier := at.Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		message       Message
+		status        string
+		reason        string
+		expectChanged bool
+		expectStatus  string
+		check         func(t *testing.T, m Message)
+	}{
+		{
+			name:          "Queued To Sent Records SentAt",
+			message:       Message{Status: MessageStatusQueued},
+			status:        MessageStatusSent,
+			expectChanged: true,
+			expectStatus:  MessageStatusSent,
+			check:         func(t *testing.T, m Message) { assert.Equal(t, at, *m.SentAt) },
+		},
+		{
+			name:          "Sent Keeps Earlier SentAt",
+			message:       Message{Status: MessageStatusQueued, SentAt: &earlier},
+			status:        MessageStatusSent,
+			expectChanged: true,
+			expectStatus:  MessageStatusSent,
+			check:         func(t *testing.T, m Message) { assert.Equal(t, earlier, *m.SentAt) },
+		},
+		{
+			name:          "Delivered",
+			message:       Message{Status: MessageStatusSent},
+			status:        MessageStatusDelivered,
+			expectChanged: true,
+			expectStatus:  MessageStatusDelivered,
+			check:         func(t *testing.T, m Message) { assert.Equal(t, at, *m.DeliveredAt) },
+		},
+		{
+			name:          "Read Implies Delivered",
+			message:       Message{Status: MessageStatusSent},
+			status:        MessageStatusRead,
+			expectChanged: true,
+			expectStatus:  MessageStatusRead,
+			check: func(t *testing.T, m Message) {
+				assert.Equal(t, at, *m.ReadAt)
+				assert.Equal(t, at, *m.DeliveredAt)
+			},
+		},
+		{
+			name:         "Late Delivered After Read Ignored",
+			message:      Message{Status: MessageStatusRead},
+			status:       MessageStatusDelivered,
+			expectStatus: MessageStatusRead,
+		},
+		{
+			name:         "Repeated Status Ignored",
+			message:      Message{Status: MessageStatusDelivered},
+			status:       MessageStatusDelivered,
+			expectStatus: MessageStatusDelivered,
+		},
+		{
+			name:          "Failed Records Reason",
+			message:       Message{Status: MessageStatusSent},
+			status:        MessageStatusFailed,
+			reason:        "Message undeliverable",
+			expectChanged: true,
+			expectStatus:  MessageStatusFailed,
+			check:         func(t *testing.T, m Message) { assert.Equal(t, "Message undeliverable", m.Error) },
+		},
+		{
+			name:         "Failed After Delivered Ignored",
+			message:      Message{Status: MessageStatusDelivered},
+			status:       MessageStatusFailed,
+			reason:       "Message undeliverable",
+			expectStatus: MessageStatusDelivered,
+		},
+		{
+			name:         "Unknown Status Ignored",
+			message:      Message{Status: MessageStatusSent},
+			status:       "deleted",
+			expectStatus: MessageStatusSent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.message
+			assert.Equal(t, tt.expectChanged, message.ApplyStatus(tt.status, at, tt.reason))
+			assert.Equal(t, tt.expectStatus, message.Status)
+			if tt.check != nil {
+				tt.check(t, message)
+			}
+		})
+	}
+}
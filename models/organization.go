@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization is a tenant: a client business whose customers and users are isolated from other tenants
+type Organization struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"not null" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// OrganizationMember links a user to an organization with a role that applies within that organization
+type OrganizationMember struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	OrganizationID uint         `gorm:"not null;uniqueIndex:idx_org_members_org_user" json:"organization_id"`
+	UserID         uint         `gorm:"not null;uniqueIndex:idx_org_members_org_user;index" json:"user_id"`
+	Role           string       `gorm:"size:50;not null;default:user" json:"role"`
+	Organization   Organization `gorm:"constraint:OnDelete:CASCADE" json:"organization,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// OrganizationInvitation offers a user membership of an organization. The membership only
+// exists once the user owning Email accepts; until then the inviter can't touch the account.
+type OrganizationInvitation struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	OrganizationID uint         `gorm:"not null;uniqueIndex:idx_org_invitations_org_email" json:"organization_id"`
+	Email          string       `gorm:"size:191;not null;uniqueIndex:idx_org_invitations_org_email;index" json:"email"`
+	Role           string       `gorm:"size:50;not null;default:user" json:"role"`
+	InvitedByID    uint         `gorm:"not null" json:"invited_by_id"`
+	ExpiresAt      time.Time    `gorm:"not null" json:"expires_at"`
+	Organization   Organization `gorm:"constraint:OnDelete:CASCADE" json:"organization,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// IsExpired reports whether the invitation can no longer be accepted
+func (i *OrganizationInvitation) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
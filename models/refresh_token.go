@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken stores a hashed, opaque refresh token issued to a user.
+// Tokens issued by rotating one another share a FamilyID so the whole
+// chain can be revoked when reuse of an old token is detected.
+type RefreshToken struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	OrganizationID uint       `json:"organization_id"`                       // Organization the session is scoped to
+	TokenHash      string     `gorm:"size:64;uniqueIndex;not null" json:"-"` // SHA-256 of the opaque token
+	FamilyID       string     `gorm:"size:64;index;not null" json:"family_id"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID   *uint      `json:"replaced_by_id,omitempty"` // Token issued when this one was rotated
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IsRevoked reports whether the token has been revoked or rotated
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsRotated reports whether the token was revoked by exchanging it for a new one, as opposed
+// to a logout; presenting a rotated token again is a sign it was stolen
+func (t *RefreshToken) IsRotated() bool {
+	return t.ReplacedByID != nil
+}
+
+// IsExpired reports whether the token is past its expiry time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
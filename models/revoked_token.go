@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken records a single access token (by jti) that was revoked before its expiry
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	JTI       string    `gorm:"column:jti;size:64;uniqueIndex;not null" json:"jti"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"` // Row can be purged after this
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserTokenRevocation records a "logout everywhere": tokens issued before RevokedAt are invalid
+type UserTokenRevocation struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	RevokedAt time.Time `gorm:"not null" json:"revoked_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -13,7 +13,6 @@ type User struct {
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
 	Role      string         `gorm:"default:user" json:"role"`
-	Token     string         `gorm:"unique" json:"-"` // Stores active JWT token
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomerSortFields maps the sort fields accepted by the API to their columns.
+// Only these columns can ever reach an ORDER BY clause.
+var CustomerSortFields = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"phone":      "phone",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// CustomerQuery describes how GetAllCustomers filters, sorts and pages customers
+type CustomerQuery struct {
+	ListOptions
+	Search        string     // Free text matched against name, email, phone and address
+	CreatedFrom   *time.Time // Inclusive lower bound on created_at
+	CreatedBefore *time.Time // Exclusive upper bound on created_at
+	HasEmail      *bool      // Only customers with (true) or without (false) an email
+}
+
+// Validate checks that the query only refers to whitelisted sort fields and sane bounds
+func (q CustomerQuery) Validate() error {
+	if err := q.ListOptions.validate(CustomerSortFields); err != nil {
+		return err
+	}
+	// Keyset comparisons can't step over NULLs, so nullable columns can't back a cursor
+	if q.Keyset != nil && q.SortBy == "email" {
+		return fmt.Errorf("sorting by email is not supported with cursor pagination")
+	}
+	if q.CreatedFrom != nil && q.CreatedBefore != nil && !q.CreatedFrom.Before(*q.CreatedBefore) {
+		return fmt.Errorf("created_from must be before created_to")
+	}
+	return nil
+}
+
+// filters applies the search and filter conditions of the query
+func (q CustomerQuery) filters(db *gorm.DB) *gorm.DB {
+	if search := strings.TrimSpace(q.Search); search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
+		db = db.Where(
+			"(LOWER(name) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!' OR phone LIKE ? ESCAPE '!' OR LOWER(address) LIKE ? ESCAPE '!')",
+			pattern, pattern, pattern, pattern,
+		)
+	}
+	if q.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *q.CreatedFrom)
+	}
+	if q.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *q.CreatedBefore)
+	}
+	if q.HasEmail != nil {
+		if *q.HasEmail {
+			db = db.Where("email IS NOT NULL AND email <> ''")
+		} else {
+			db = db.Where("(email IS NULL OR email = '')")
+		}
+	}
+	return db
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally ('!' is the escape character)
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
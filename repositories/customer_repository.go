@@ -1,23 +1,32 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/metabbe3/go-backend/models"
 	"gorm.io/gorm"
 )
 
-// CustomerRepository is a concrete implementation of the CustomerRepositoryInterface
+// CustomerRepository is a concrete implementation of the CustomerRepositoryInterface.
+// Customers always belong to an organization: a repository returned by ForOrganization
+// only reads and writes that organization's rows.
 type CustomerRepository struct {
-	DB *gorm.DB
+	DB             *gorm.DB
+	OrganizationID uint
 }
 
 // CustomerRepositoryInterface defines the methods to interact with the Customer model
 type CustomerRepositoryInterface interface {
-	CreateCustomer(customer *models.Customer) error
-	FindCustomerByID(id uint) (*models.Customer, error)
-	FindCustomerByPhone(phone string) (*models.Customer, error)
-	UpdateCustomer(customer *models.Customer) error
-	DeleteCustomer(id uint) error
-	GetAllCustomers(limit, offset int) ([]models.Customer, int64, error) // Returning totalCount as int64
+	ForOrganization(orgID uint) CustomerRepositoryInterface
+	CreateCustomer(ctx context.Context, customer *models.Customer) error
+	FindCustomerByID(ctx context.Context, id uint) (*models.Customer, error)
+	FindCustomerByPhone(ctx context.Context, phone string) (*models.Customer, error)
+	UpdateCustomer(ctx context.Context, customer *models.Customer) error
+	DeleteCustomer(ctx context.Context, id uint) error
+	GetAllCustomers(ctx context.Context, query CustomerQuery) ([]models.Customer, PageResult, error)
+	ExportCustomers(ctx context.Context, query CustomerQuery, batchSize int, fn func([]models.Customer) error) error
+	FindCustomersByContacts(ctx context.Context, phones, emails []string) ([]models.Customer, error)
+	ImportCustomers(ctx context.Context, creates, updates []models.Customer, batchSize int) error
 }
 
 // NewCustomerRepository creates and returns a new instance of CustomerRepository
@@ -25,53 +34,157 @@ func NewCustomerRepository(db *gorm.DB) *CustomerRepository {
 	return &CustomerRepository{DB: db}
 }
 
+// ForOrganization returns a repository scoped to the given organization
+func (r *CustomerRepository) ForOrganization(orgID uint) CustomerRepositoryInterface {
+	return &CustomerRepository{DB: r.DB, OrganizationID: orgID}
+}
+
+// scoped returns a query bound to ctx and restricted to the repository's organization
+func (r *CustomerRepository) scoped(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Scopes(organizationScope(r.OrganizationID))
+}
+
 // CreateCustomer saves a new customer in the database
-func (r *CustomerRepository) CreateCustomer(customer *models.Customer) error {
-	return r.DB.Create(customer).Error
+func (r *CustomerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) error {
+	customer.OrganizationID = r.OrganizationID
+	return translateError(r.DB.WithContext(ctx).Create(customer).Error, "Customer")
 }
 
 // FindCustomerByID retrieves a customer by their ID
-func (r *CustomerRepository) FindCustomerByID(id uint) (*models.Customer, error) {
+func (r *CustomerRepository) FindCustomerByID(ctx context.Context, id uint) (*models.Customer, error) {
 	var customer models.Customer
-	if err := r.DB.First(&customer, id).Error; err != nil {
-		return nil, err
+	if err := r.scoped(ctx).First(&customer, id).Error; err != nil {
+		return nil, translateError(err, "Customer")
 	}
 	return &customer, nil
 }
 
-// FindCustomerByPhone retrieves a customer by their phone number
-func (r *CustomerRepository) FindCustomerByPhone(phone string) (*models.Customer, error) {
+// FindCustomerByPhone retrieves the customer holding a phone number, including a soft-deleted
+// one: deleted customers keep their number in the unique index, so callers checking whether a
+// number is free must see them. Check DeletedAt before treating the customer as live.
+func (r *CustomerRepository) FindCustomerByPhone(ctx context.Context, phone string) (*models.Customer, error) {
 	var customer models.Customer
-	if err := r.DB.Where("phone = ?", phone).First(&customer).Error; err != nil {
-		return nil, err
+	if err := r.scoped(ctx).Unscoped().Where("phone = ?", phone).First(&customer).Error; err != nil {
+		return nil, translateError(err, "Customer")
 	}
 	return &customer, nil
 }
 
-// UpdateCustomer updates the customer's details in the database
-func (r *CustomerRepository) UpdateCustomer(customer *models.Customer) error {
-	return r.DB.Save(customer).Error
+// UpdateCustomer updates the customer's details in the database.
+// Only a customer of the repository's organization can be updated, and it can't be moved to another one.
+func (r *CustomerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) error {
+	customer.OrganizationID = r.OrganizationID
+	result := r.scoped(ctx).Model(customer).Updates(customerColumns(customer))
+	if result.Error != nil {
+		return translateError(result.Error, "Customer")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "Customer")
+	}
+	return nil
+}
+
+// customerColumns lists every column an update writes, zero values included. A missing email
+// is stored as NULL, as on create, so email-less customers don't collide on idx_customers_org_email.
+func customerColumns(customer *models.Customer) map[string]interface{} {
+	var email interface{}
+	if customer.Email != "" {
+		email = customer.Email
+	}
+	return map[string]interface{}{
+		"organization_id": customer.OrganizationID,
+		"name":            customer.Name,
+		"email":           email,
+		"phone":           customer.Phone,
+		"address":         customer.Address,
+		"deleted_at":      customer.DeletedAt,
+	}
 }
 
 // DeleteCustomer deletes a customer by their ID
-func (r *CustomerRepository) DeleteCustomer(id uint) error {
-	return r.DB.Delete(&models.Customer{}, id).Error
+func (r *CustomerRepository) DeleteCustomer(ctx context.Context, id uint) error {
+	result := r.scoped(ctx).Delete(&models.Customer{}, id)
+	if result.Error != nil {
+		return translateError(result.Error, "Customer")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "Customer")
+	}
+	return nil
 }
 
-// GetAllCustomers retrieves all customers from the database with limit, offset, and total count
-func (r *CustomerRepository) GetAllCustomers(limit, offset int) ([]models.Customer, int64, error) {
-	var customers []models.Customer
-	var totalCount int64
+// GetAllCustomers retrieves one page of the customers matching the query
+func (r *CustomerRepository) GetAllCustomers(ctx context.Context, query CustomerQuery) ([]models.Customer, PageResult, error) {
+	if err := query.Validate(); err != nil {
+		return nil, PageResult{}, err
+	}
 
-	// Get the total count of customers
-	if err := r.DB.Model(&models.Customer{}).Count(&totalCount).Error; err != nil {
-		return nil, 0, err
+	base := r.scoped(ctx).Model(&models.Customer{}).Scopes(query.filters)
+	return fetchPage[models.Customer](base, query.ListOptions, query.sortColumn(CustomerSortFields))
+}
+
+// ExportCustomers passes every customer matching the query's filters to fn, batchSize rows
+// at a time in ID order, so large exports never hold all rows in memory. Pagination and sort
+// options are ignored. An error from fn stops the export.
+func (r *CustomerRepository) ExportCustomers(ctx context.Context, query CustomerQuery, batchSize int, fn func([]models.Customer) error) error {
+	if err := query.Validate(); err != nil {
+		return err
 	}
 
-	// Get the customers with limit and offset
-	if err := r.DB.Limit(limit).Offset(offset).Find(&customers).Error; err != nil {
-		return nil, 0, err
+	var batch []models.Customer
+	return r.scoped(ctx).Scopes(query.filters).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// FindCustomersByContacts retrieves the customers, including soft-deleted ones, whose phone or
+// email is in the given lists. Deleted customers are returned because they still hold their
+// phone and email in the unique indexes. Emails must be lowercase; they match case-insensitively.
+func (r *CustomerRepository) FindCustomersByContacts(ctx context.Context, phones, emails []string) ([]models.Customer, error) {
+	var customers []models.Customer
+	query := r.scoped(ctx).Unscoped()
+	switch {
+	case len(phones) > 0 && len(emails) > 0:
+		query = query.Where(r.DB.Where("phone IN ?", phones).Or("LOWER(email) IN ?", emails))
+	case len(phones) > 0:
+		query = query.Where("phone IN ?", phones)
+	case len(emails) > 0:
+		query = query.Where("LOWER(email) IN ?", emails)
+	default:
+		return customers, nil
 	}
 
-	return customers, totalCount, nil
+	err := query.Find(&customers).Error
+	return customers, err
+}
+
+// ImportCustomers inserts new customers in batches and updates existing ones, all in one
+// transaction so a failed import leaves no partial changes. Updates are saved as given,
+// DeletedAt included: a soft-deleted customer is restored only if the caller cleared it.
+func (r *CustomerRepository) ImportCustomers(ctx context.Context, creates, updates []models.Customer, batchSize int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(creates) > 0 {
+			for i := range creates {
+				creates[i].OrganizationID = r.OrganizationID
+			}
+			if err := tx.CreateInBatches(creates, batchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range updates {
+			customer := &updates[i]
+			customer.OrganizationID = r.OrganizationID
+			result := tx.Unscoped().Scopes(organizationScope(r.OrganizationID)).Model(customer).
+				Updates(customerColumns(customer))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		return nil
+	})
+	return translateError(err, "Customer")
 }
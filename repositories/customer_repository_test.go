@@ -0,0 +1,330 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedCustomers creates customers in an organization, one second apart so created_at sorts are stable
+func seedCustomers(t *testing.T, repo repositories.CustomerRepositoryInterface, customers ...models.Customer) []models.Customer {
+	t.Helper()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range customers {
+		customers[i].CreatedAt = start.Add(time.Duration(i) * time.Second)
+		require.NoError(t, repo.CreateCustomer(context.Background(), &customers[i]))
+	}
+	return customers
+}
+
+func TestCustomerRepository_TenantIsolation(t *testing.T) {
+	db := test.NewTestDB(t)
+	base := repositories.NewCustomerRepository(db)
+	orgA, orgB := base.ForOrganization(1), base.ForOrganization(2)
+
+	customers := seedCustomers(t, orgA, models.Customer{Name: "Budi", Phone: "+6281234567890"})
+	seedCustomers(t, orgB, models.Customer{Name: "Siti", Phone: "+6281234567890"}) // Same phone, other tenant
+
+	found, err := orgA.FindCustomerByPhone(context.Background(), "+6281234567890")
+	require.NoError(t, err)
+	assert.Equal(t, "Budi", found.Name)
+
+	_, err = orgB.FindCustomerByID(context.Background(), customers[0].ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	moved := customers[0]
+	moved.Name = "Hijacked"
+	assert.ErrorIs(t, orgB.UpdateCustomer(context.Background(), &moved), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, orgB.DeleteCustomer(context.Background(), customers[0].ID), gorm.ErrRecordNotFound)
+
+	found, err = orgA.FindCustomerByID(context.Background(), customers[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Budi", found.Name)
+	assert.Equal(t, uint(1), found.OrganizationID)
+}
+
+func TestCustomerRepository_UniquePhone(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewCustomerRepository(db).ForOrganization(1)
+
+	customers := seedCustomers(t, repo, models.Customer{Name: "Budi", Phone: "+6281234567890"})
+	err := repo.CreateCustomer(context.Background(), &models.Customer{Name: "Budi Lagi", Phone: "+6281234567890"})
+	assert.Error(t, err)
+
+	// A deleted customer still holds its number, and the lookup used to check it must say so
+	require.NoError(t, repo.DeleteCustomer(context.Background(), customers[0].ID))
+	found, err := repo.FindCustomerByPhone(context.Background(), "+6281234567890")
+	require.NoError(t, err)
+	assert.True(t, found.DeletedAt.Valid)
+	assert.ErrorIs(t, repo.CreateCustomer(context.Background(), &models.Customer{Name: "Budi Lagi", Phone: "+6281234567890"}), utils.ErrConflict)
+}
+
+func TestCustomerRepository_UpdateCustomersWithoutEmail(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewCustomerRepository(db).ForOrganization(1)
+
+	customers := seedCustomers(t, repo,
+		models.Customer{Name: "Budi", Phone: "+6281234567890"},
+		models.Customer{Name: "Siti", Email: "siti@example.com", Phone: "+6281298765432"},
+	)
+	for i := range customers {
+		customers[i].Name += " Updated"
+		customers[i].Email = ""
+		require.NoError(t, repo.UpdateCustomer(context.Background(), &customers[i]), "emails are optional, so both can lack one")
+	}
+
+	var nullEmails int64
+	require.NoError(t, db.Model(&models.Customer{}).Where("email IS NULL").Count(&nullEmails).Error)
+	assert.Equal(t, int64(2), nullEmails, "a cleared email is stored as NULL, as on create")
+}
+
+func TestCustomerRepository_GetAllCustomers(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewCustomerRepository(db).ForOrganization(1)
+
+	seedCustomers(t, repo,
+		models.Customer{Name: "Budi Santoso", Email: "budi@example.com", Phone: "+6281200000001", Address: "Bandung"},
+		models.Customer{Name: "Siti Aminah", Phone: "+6281200000002", Address: "Jakarta"},
+		models.Customer{Name: "Andi 100%", Email: "andi@example.com", Phone: "+6281200000003"},
+		models.Customer{Name: "Dewi", Email: "dewi@example.com", Phone: "+6281200000004", Address: "bandung barat"},
+	)
+	seedCustomers(t, repositories.NewCustomerRepository(db).ForOrganization(2),
+		models.Customer{Name: "Budi Other Tenant", Phone: "+6281200000001"},
+	)
+
+	hasEmail := true
+	tests := []struct {
+		name        string
+		query       repositories.CustomerQuery
+		expectNames []string
+		expectTotal int64
+	}{
+		{
+			name:        "All Of The Organization",
+			query:       repositories.CustomerQuery{ListOptions: repositories.ListOptions{Limit: 10}},
+			expectNames: []string{"Budi Santoso", "Siti Aminah", "Andi 100%", "Dewi"},
+			expectTotal: 4,
+		},
+		{
+			name:        "Search Is Case-Insensitive Across Columns",
+			query:       repositories.CustomerQuery{ListOptions: repositories.ListOptions{Limit: 10}, Search: "BANDUNG"},
+			expectNames: []string{"Budi Santoso", "Dewi"},
+			expectTotal: 2,
+		},
+		{
+			name:        "Search Wildcards Match Literally",
+			query:       repositories.CustomerQuery{ListOptions: repositories.ListOptions{Limit: 10}, Search: "100%"},
+			expectNames: []string{"Andi 100%"},
+			expectTotal: 1,
+		},
+		{
+			name:        "Has Email Sorted By Name Descending",
+			query:       repositories.CustomerQuery{ListOptions: repositories.ListOptions{Limit: 10, SortBy: "name", SortDesc: true}, HasEmail: &hasEmail},
+			expectNames: []string{"Dewi", "Budi Santoso", "Andi 100%"},
+			expectTotal: 3,
+		},
+		{
+			name:        "Offset Page",
+			query:       repositories.CustomerQuery{ListOptions: repositories.ListOptions{Limit: 2, Offset: 2}},
+			expectNames: []string{"Andi 100%", "Dewi"},
+			expectTotal: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customers, result, err := repo.GetAllCustomers(context.Background(), tt.query)
+			require.NoError(t, err)
+
+			var names []string
+			for _, customer := range customers {
+				names = append(names, customer.Name)
+			}
+			assert.Equal(t, tt.expectNames, names)
+			require.NotNil(t, result.TotalCount)
+			assert.Equal(t, tt.expectTotal, *result.TotalCount)
+		})
+	}
+}
+
+func TestCustomerRepository_KeysetPagination(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewCustomerRepository(db).ForOrganization(1)
+
+	customers := seedCustomers(t, repo,
+		models.Customer{Name: "A", Phone: "+6281200000001"},
+		models.Customer{Name: "B", Phone: "+6281200000002"},
+		models.Customer{Name: "C", Phone: "+6281200000003"},
+		models.Customer{Name: "D", Phone: "+6281200000004"},
+		models.Customer{Name: "E", Phone: "+6281200000005"},
+	)
+
+	for _, sortBy := range []string{"id", "name", "created_at"} {
+		t.Run(sortBy, func(t *testing.T) {
+			keyset := func(c models.Customer, backward bool) *repositories.Keyset {
+				value := c.Name
+				if sortBy == "created_at" {
+					value = c.CreatedAt.Format(time.RFC3339Nano)
+				}
+				return &repositories.Keyset{Value: value, ID: c.ID, Backward: backward}
+			}
+			page := func(k *repositories.Keyset) ([]string, bool) {
+				rows, result, err := repo.GetAllCustomers(context.Background(), repositories.CustomerQuery{ListOptions: repositories.ListOptions{
+					Limit: 2, SortBy: sortBy, SortDesc: true, Keyset: k, SkipCount: true,
+				}})
+				require.NoError(t, err)
+				var names []string
+				for _, row := range rows {
+					names = append(names, row.Name)
+				}
+				return names, result.HasMore
+			}
+
+			names, more := page(keyset(models.Customer{Name: "~", ID: 1 << 30, CreatedAt: time.Now()}, false))
+			assert.Equal(t, []string{"E", "D"}, names)
+			assert.True(t, more)
+
+			names, more = page(keyset(customers[3], false))
+			assert.Equal(t, []string{"C", "B"}, names)
+			assert.True(t, more)
+
+			names, more = page(keyset(customers[1], false))
+			assert.Equal(t, []string{"A"}, names)
+			assert.False(t, more)
+
+			names, more = page(keyset(customers[0], true))
+			assert.Equal(t, []string{"C", "B"}, names)
+			assert.True(t, more)
+		})
+	}
+}
+
+func TestCustomerRepository_ImportCustomers(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewCustomerRepository(db).ForOrganization(1)
+
+	existing := seedCustomers(t, repo,
+		models.Customer{Name: "Budi", Email: "budi@example.com", Phone: "+6281200000001"},
+		models.Customer{Name: "Siti", Phone: "+6281200000002"},
+	)
+	require.NoError(t, repo.DeleteCustomer(context.Background(), existing[1].ID))
+
+	require.NoError(t, db.Model(&existing[0]).Update("email", "Budi@Example.com").Error)
+
+	found, err := repo.FindCustomersByContacts(context.Background(), []string{"+6281200000002"}, []string{"budi@example.com"})
+	require.NoError(t, err)
+	assert.Len(t, found, 2, "soft-deleted customers still hold their phone, and emails match in any case")
+
+	// A deleted customer stays deleted unless the update clears DeletedAt
+	require.NoError(t, repo.ImportCustomers(context.Background(), nil, []models.Customer{found[1]}, 1))
+	_, err = repo.FindCustomerByID(context.Background(), existing[1].ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	creates := []models.Customer{{Name: "Andi", Phone: "+6281200000003"}, {Name: "Dewi", Phone: "+6281200000004"}}
+	updates := []models.Customer{found[0], found[1]}
+	updates[0].Name = "Updated"
+	updates[1].Name = "Updated"
+	updates[1].DeletedAt = gorm.DeletedAt{}
+	require.NoError(t, repo.ImportCustomers(context.Background(), creates, updates, 1))
+
+	customers, _, err := repo.GetAllCustomers(context.Background(), repositories.CustomerQuery{ListOptions: repositories.ListOptions{Limit: 10}})
+	require.NoError(t, err)
+	var names []string
+	for _, customer := range customers {
+		names = append(names, customer.Name)
+		assert.Equal(t, uint(1), customer.OrganizationID)
+	}
+	assert.Equal(t, []string{"Updated", "Updated", "Andi", "Dewi"}, names, "the deleted customer is restored")
+
+	// Rows without an email are stored with NULL, so any number of them can be upserted
+	withoutEmail, err := repo.FindCustomersByContacts(context.Background(), []string{"+6281200000001", "+6281200000003", "+6281200000004"}, nil)
+	require.NoError(t, err)
+	require.Len(t, withoutEmail, 3)
+	for i := range withoutEmail {
+		withoutEmail[i].Email = ""
+	}
+	require.NoError(t, repo.ImportCustomers(context.Background(), []models.Customer{{Name: "Eka", Phone: "+6281200000005"}}, withoutEmail, 1))
+	var nullEmails int64
+	require.NoError(t, db.Model(&models.Customer{}).Where("email IS NULL").Count(&nullEmails).Error)
+	assert.Equal(t, int64(5), nullEmails)
+
+	// A failing row rolls back the whole import
+	err = repo.ImportCustomers(context.Background(), []models.Customer{{Name: "New", Phone: "+6281200000009"}, {Name: "Dup", Phone: "+6281200000003"}}, nil, 10)
+	assert.Error(t, err)
+	_, err = repo.FindCustomerByPhone(context.Background(), "+6281200000009")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCustomerRepository_ExportCustomers(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewCustomerRepository(db).ForOrganization(1)
+
+	seedCustomers(t, repo,
+		models.Customer{Name: "A", Email: "a@example.com", Phone: "+6281200000001"},
+		models.Customer{Name: "B", Phone: "+6281200000002"},
+		models.Customer{Name: "C", Email: "c@example.com", Phone: "+6281200000003"},
+		models.Customer{Name: "D", Email: "d@example.com", Phone: "+6281200000004"},
+	)
+	seedCustomers(t, repositories.NewCustomerRepository(db).ForOrganization(2), models.Customer{Name: "X", Email: "x@example.com", Phone: "+6281200000001"})
+
+	hasEmail := true
+	var batches [][]string
+	err := repo.ExportCustomers(context.Background(), repositories.CustomerQuery{HasEmail: &hasEmail}, 2, func(customers []models.Customer) error {
+		var names []string
+		for _, customer := range customers {
+			names = append(names, customer.Name)
+		}
+		batches = append(batches, names)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"A", "C"}, {"D"}}, batches)
+
+	stop := errors.New("client went away")
+	err = repo.ExportCustomers(context.Background(), repositories.CustomerQuery{}, 1, func([]models.Customer) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestBackfillCustomerPhones(t *testing.T) {
+	db := test.NewTestDB(t)
+
+	// Raw phones as they were stored before normalization
+	raw := []models.Customer{
+		{OrganizationID: 1, Name: "Local Format", Phone: "0812-0000-0001"},
+		{OrganizationID: 1, Name: "Normalized", Phone: "+6281200000002"},
+		{OrganizationID: 1, Name: "Same As Normalized", Phone: "0812 0000 0002"},
+		{OrganizationID: 1, Name: "Same As Local", Phone: "62812-0000-0001"},
+		{OrganizationID: 2, Name: "Other Tenant", Phone: "081200000001"},
+		{OrganizationID: 1, Name: "Garbage", Phone: "n/a"},
+	}
+	require.NoError(t, db.Create(&raw).Error)
+
+	report, err := repositories.BackfillCustomerPhones(context.Background(), db, "ID", true)
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Scanned)
+	assert.Equal(t, 2, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Len(t, report.Invalid, 1)
+	require.Len(t, report.Collisions, 2)
+	assert.Equal(t, raw[1].ID, report.Collisions[0].ConflictsWith)
+	assert.Equal(t, raw[0].ID, report.Collisions[1].ConflictsWith)
+
+	var unchanged models.Customer
+	require.NoError(t, db.First(&unchanged, raw[0].ID).Error)
+	assert.Equal(t, "0812-0000-0001", unchanged.Phone, "dry run writes nothing")
+
+	_, err = repositories.BackfillCustomerPhones(context.Background(), db, "ID", false)
+	require.NoError(t, err)
+	var phones []string
+	require.NoError(t, db.Model(&models.Customer{}).Order("id").Pluck("phone", &phones).Error)
+	assert.Equal(t, []string{"+6281200000001", "+6281200000002", "0812 0000 0002", "62812-0000-0001", "+6281200000001", "n/a"}, phones)
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/metabbe3/go-backend/utils"
+	"gorm.io/gorm"
+)
+
+// violation is the kind of constraint a database error reports
+type violation int
+
+const (
+	noViolation         violation = iota
+	uniqueViolation               // A unique index already holds the value
+	referencedViolation           // Deleting a row other rows still point to
+	foreignKeyViolation           // Pointing to a row that doesn't exist
+	checkViolation                // A check constraint or column size
+)
+
+// Driver error codes of each violation, e.g. MySQL 1062 (ER_DUP_ENTRY) for a duplicate key
+var (
+	mysqlViolations = map[uint16]violation{
+		1062: uniqueViolation,
+		1451: referencedViolation,
+		1452: foreignKeyViolation,
+		1406: checkViolation, // Data too long
+		3819: checkViolation,
+	}
+	postgresViolations = map[string]violation{
+		"23505": uniqueViolation,
+		"23503": foreignKeyViolation,
+		"23514": checkViolation,
+		"22001": checkViolation, // Value too long
+	}
+	sqliteViolations = map[int]violation{
+		2067: uniqueViolation, // SQLITE_CONSTRAINT_UNIQUE
+		1555: uniqueViolation, // SQLITE_CONSTRAINT_PRIMARYKEY
+		787:  foreignKeyViolation,
+		275:  checkViolation,
+	}
+	// Errors of dialectors opened with gorm.Config.TranslateError
+	gormViolations = map[error]violation{
+		gorm.ErrDuplicatedKey:           uniqueViolation,
+		gorm.ErrForeignKeyViolated:      foreignKeyViolation,
+		gorm.ErrCheckConstraintViolated: checkViolation,
+	}
+)
+
+// translateError turns database errors into domain errors naming the resource, e.g.
+// "Customer not found" or "Customer already exists", keeping the original as the cause.
+// Errors that mean nothing to clients, such as a lost connection, are returned as they are.
+func translateError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.NotFound(resource + " not found").Wrap(err)
+	}
+
+	switch violationOf(err) {
+	case uniqueViolation:
+		return utils.Conflict(resource + " already exists").Wrap(err)
+	case referencedViolation:
+		return utils.Conflict(resource + " is still referenced by other records").Wrap(err)
+	case foreignKeyViolation:
+		return utils.Validation(resource+" refers to a record that doesn't exist", nil).Wrap(err)
+	case checkViolation:
+		return utils.Validation("Invalid "+resource+" data", nil).Wrap(err)
+	}
+	return err
+}
+
+// violationOf finds the constraint reported by a MySQL, PostgreSQL or SQLite error
+func violationOf(err error) violation {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlViolations[mysqlErr.Number]
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// PostgreSQL reports both sides of a foreign key as 23503; deleting the referenced row
+		// is told apart by its message
+		if pgErr.Code == "23503" && strings.HasPrefix(pgErr.Message, "update or delete on table") {
+			return referencedViolation
+		}
+		return postgresViolations[pgErr.Code]
+	}
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		return sqliteViolations[sqliteErr.Code()]
+	}
+	for target, v := range gormViolations {
+		if errors.Is(err, target) {
+			return v
+		}
+	}
+	return noViolation
+}
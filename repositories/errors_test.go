@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// sqliteError stands in for the SQLite driver's error, which reports an extended result code
+type sqliteError int
+
+func (e sqliteError) Error() string { return fmt.Sprintf("sqlite error %d", int(e)) }
+func (e sqliteError) Code() int     { return int(e) }
+
+func TestTranslateError(t *testing.T) {
+	connectionLost := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name       string
+		err        error
+		expectKind error // The domain error it matches; nil if returned as it is
+		expectMsg  string
+	}{
+		{name: "Nil", err: nil},
+		{name: "Record Not Found", err: gorm.ErrRecordNotFound, expectKind: utils.ErrNotFound, expectMsg: "Customer not found"},
+		{name: "Already Translated", err: utils.Forbidden("No access"), expectKind: utils.ErrForbidden, expectMsg: "No access"},
+		{name: "MySQL Duplicate Entry", err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, expectKind: utils.ErrConflict, expectMsg: "Customer already exists"},
+		{name: "MySQL Row Is Referenced", err: &mysql.MySQLError{Number: 1451}, expectKind: utils.ErrConflict, expectMsg: "Customer is still referenced by other records"},
+		{name: "MySQL Missing Parent", err: &mysql.MySQLError{Number: 1452}, expectKind: utils.ErrValidation, expectMsg: "Customer refers to a record that doesn't exist"},
+		{name: "MySQL Data Too Long", err: &mysql.MySQLError{Number: 1406}, expectKind: utils.ErrValidation, expectMsg: "Invalid Customer data"},
+		{name: "MySQL Check Constraint", err: &mysql.MySQLError{Number: 3819}, expectKind: utils.ErrValidation, expectMsg: "Invalid Customer data"},
+		{name: "MySQL Other", err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}},
+		{name: "PostgreSQL Unique Violation", err: &pgconn.PgError{Code: "23505"}, expectKind: utils.ErrConflict, expectMsg: "Customer already exists"},
+		{
+			name:       "PostgreSQL Missing Parent",
+			err:        &pgconn.PgError{Code: "23503", Message: `insert or update on table "customers" violates foreign key constraint "fk_customers_organization"`},
+			expectKind: utils.ErrValidation,
+			expectMsg:  "Customer refers to a record that doesn't exist",
+		},
+		{
+			name:       "PostgreSQL Row Is Referenced",
+			err:        &pgconn.PgError{Code: "23503", Message: `update or delete on table "customers" violates foreign key constraint "fk_messages_customer" on table "messages"`},
+			expectKind: utils.ErrConflict,
+			expectMsg:  "Customer is still referenced by other records",
+		},
+		{name: "PostgreSQL Check Violation", err: &pgconn.PgError{Code: "23514"}, expectKind: utils.ErrValidation, expectMsg: "Invalid Customer data"},
+		{name: "PostgreSQL Value Too Long", err: &pgconn.PgError{Code: "22001"}, expectKind: utils.ErrValidation, expectMsg: "Invalid Customer data"},
+		{name: "SQLite Unique Violation", err: sqliteError(2067), expectKind: utils.ErrConflict, expectMsg: "Customer already exists"},
+		{name: "SQLite Primary Key Violation", err: sqliteError(1555), expectKind: utils.ErrConflict, expectMsg: "Customer already exists"},
+		{name: "SQLite Foreign Key Violation", err: sqliteError(787), expectKind: utils.ErrValidation, expectMsg: "Customer refers to a record that doesn't exist"},
+		{name: "SQLite Check Violation", err: sqliteError(275), expectKind: utils.ErrValidation, expectMsg: "Invalid Customer data"},
+		{name: "GORM Translated Duplicate", err: gorm.ErrDuplicatedKey, expectKind: utils.ErrConflict, expectMsg: "Customer already exists"},
+		{name: "GORM Translated Foreign Key", err: gorm.ErrForeignKeyViolated, expectKind: utils.ErrValidation, expectMsg: "Customer refers to a record that doesn't exist"},
+		{name: "Wrapped Driver Error", err: fmt.Errorf("create customer: %w", &mysql.MySQLError{Number: 1062}), expectKind: utils.ErrConflict, expectMsg: "Customer already exists"},
+		{name: "Connection Error", err: connectionLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translated := translateError(tt.err, "Customer")
+			if tt.err == nil {
+				assert.NoError(t, translated)
+				return
+			}
+			if tt.expectKind == nil {
+				assert.Same(t, tt.err, translated, "errors that mean nothing to clients are returned as they are")
+				return
+			}
+
+			assert.ErrorIs(t, translated, tt.expectKind)
+			assert.ErrorIs(t, translated, tt.err, "the original error is kept as the cause")
+			var appErr *utils.AppError
+			if assert.ErrorAs(t, translated, &appErr) {
+				assert.Equal(t, tt.expectMsg, appErr.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"github.com/metabbe3/go-backend/models"
+	"gorm.io/gorm"
+)
+
+// MessageSortFields maps the sort fields accepted by the API to their columns
+var MessageSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+// MessageRepositoryInterface defines the methods to interact with the Message model
+type MessageRepositoryInterface interface {
+	ForOrganization(orgID uint) MessageRepositoryInterface
+	CreateMessage(ctx context.Context, message *models.Message) error
+	UpdateMessage(ctx context.Context, message *models.Message) error
+	FindMessageByProviderID(ctx context.Context, providerMessageID string) (*models.Message, error)
+	FindAnyMessageByProviderID(ctx context.Context, providerMessageID string) (*models.Message, error)
+	GetMessagesForCustomer(ctx context.Context, customerID uint, opts ListOptions) ([]models.Message, PageResult, error)
+}
+
+// MessageRepository is a concrete implementation of the MessageRepositoryInterface,
+// scoped to an organization like CustomerRepository
+type MessageRepository struct {
+	DB             *gorm.DB
+	OrganizationID uint
+}
+
+// NewMessageRepository creates a new instance of MessageRepository
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{DB: db}
+}
+
+// ForOrganization returns a repository scoped to the given organization
+func (r *MessageRepository) ForOrganization(orgID uint) MessageRepositoryInterface {
+	return &MessageRepository{DB: r.DB, OrganizationID: orgID}
+}
+
+// scoped returns a query bound to ctx and restricted to the repository's organization
+func (r *MessageRepository) scoped(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Scopes(organizationScope(r.OrganizationID))
+}
+
+// CreateMessage saves a new message in the database
+func (r *MessageRepository) CreateMessage(ctx context.Context, message *models.Message) error {
+	message.OrganizationID = r.OrganizationID
+	return translateError(r.DB.WithContext(ctx).Create(message).Error, "Message")
+}
+
+// UpdateMessage saves changes to a message, e.g. its delivery status
+func (r *MessageRepository) UpdateMessage(ctx context.Context, message *models.Message) error {
+	message.OrganizationID = r.OrganizationID
+	result := r.scoped(ctx).Model(message).Select("*").Omit("id", "created_at").Updates(message)
+	if result.Error != nil {
+		return translateError(result.Error, "Message")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "Message")
+	}
+	return nil
+}
+
+// FindMessageByProviderID retrieves a message by the ID the provider assigned to it
+func (r *MessageRepository) FindMessageByProviderID(ctx context.Context, providerMessageID string) (*models.Message, error) {
+	var message models.Message
+	if err := r.scoped(ctx).Where("provider_message_id = ?", providerMessageID).First(&message).Error; err != nil {
+		return nil, translateError(err, "Message")
+	}
+	return &message, nil
+}
+
+// FindAnyMessageByProviderID retrieves a message by the ID the provider assigned to it,
+// whichever organization it belongs to. Provider IDs are unique across organizations, so
+// webhooks, which arrive without a tenant, use it to find the organization of a message.
+func (r *MessageRepository) FindAnyMessageByProviderID(ctx context.Context, providerMessageID string) (*models.Message, error) {
+	var message models.Message
+	if err := r.DB.WithContext(ctx).Where("provider_message_id = ?", providerMessageID).First(&message).Error; err != nil {
+		return nil, translateError(err, "Message")
+	}
+	return &message, nil
+}
+
+// GetMessagesForCustomer retrieves one page of a customer's message history
+func (r *MessageRepository) GetMessagesForCustomer(ctx context.Context, customerID uint, opts ListOptions) ([]models.Message, PageResult, error) {
+	if err := opts.validate(MessageSortFields); err != nil {
+		return nil, PageResult{}, err
+	}
+
+	base := r.scoped(ctx).Model(&models.Message{}).Where("customer_id = ?", customerID)
+	return fetchPage[models.Message](base, opts, opts.sortColumn(MessageSortFields))
+}
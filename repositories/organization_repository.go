@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepositoryInterface defines the methods to interact with organizations and their members
+type OrganizationRepositoryInterface interface {
+	CreateOrganization(ctx context.Context, org *models.Organization, ownerID uint, ownerRole string) error
+	CreateOrganizationWithOwner(ctx context.Context, org *models.Organization, owner *models.User, ownerRole string) error
+	FindOrganizationByID(ctx context.Context, id uint) (*models.Organization, error)
+	GetMemberships(ctx context.Context, userID uint) ([]models.OrganizationMember, error)
+	FindMembership(ctx context.Context, orgID, userID uint) (*models.OrganizationMember, error)
+	GetMembers(ctx context.Context, orgID uint) ([]models.OrganizationMember, error)
+	UpdateMemberRole(ctx context.Context, orgID, userID uint, role string) error
+	RemoveMember(ctx context.Context, orgID, userID uint) error
+	CreateInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error
+	FindInvitationByID(ctx context.Context, id uint) (*models.OrganizationInvitation, error)
+	GetInvitations(ctx context.Context, orgID uint) ([]models.OrganizationInvitation, error)
+	GetInvitationsForEmail(ctx context.Context, email string) ([]models.OrganizationInvitation, error)
+	AcceptInvitation(ctx context.Context, invitation *models.OrganizationInvitation, userID uint) (*models.OrganizationMember, error)
+	DeleteInvitation(ctx context.Context, id uint) error
+}
+
+// OrganizationRepository is a concrete implementation of the OrganizationRepositoryInterface
+type OrganizationRepository struct {
+	DB *gorm.DB
+}
+
+// NewOrganizationRepository creates a new instance of OrganizationRepository
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{DB: db}
+}
+
+// CreateOrganization saves a new organization and makes ownerID its first member
+func (r *OrganizationRepository) CreateOrganization(ctx context.Context, org *models.Organization, ownerID uint, ownerRole string) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createOrganization(tx, org, ownerID, ownerRole)
+	})
+	return translateError(err, "Organization")
+}
+
+// CreateOrganizationWithOwner saves a new user and a new organization with that user as its
+// first member in one transaction, so a failure leaves no account without its organization
+func (r *OrganizationRepository) CreateOrganizationWithOwner(ctx context.Context, org *models.Organization, owner *models.User, ownerRole string) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(owner).Error; err != nil {
+			return translateError(err, "User")
+		}
+		return createOrganization(tx, org, owner.ID, ownerRole)
+	})
+	return translateError(err, "Organization")
+}
+
+// createOrganization saves an organization and its first member within a transaction
+func createOrganization(tx *gorm.DB, org *models.Organization, ownerID uint, ownerRole string) error {
+	if err := tx.Create(org).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         ownerID,
+		Role:           ownerRole,
+	}).Error
+}
+
+// FindOrganizationByID retrieves an organization by ID
+func (r *OrganizationRepository) FindOrganizationByID(ctx context.Context, id uint) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.DB.WithContext(ctx).First(&org, id).Error; err != nil {
+		return nil, translateError(err, "Organization")
+	}
+	return &org, nil
+}
+
+// GetMemberships retrieves the organizations a user belongs to, oldest membership first
+func (r *OrganizationRepository) GetMemberships(ctx context.Context, userID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	if err := r.DB.WithContext(ctx).Preload("Organization").Where("user_id = ?", userID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// FindMembership retrieves a user's membership in an organization
+func (r *OrganizationRepository) FindMembership(ctx context.Context, orgID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	if err := r.DB.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error; err != nil {
+		return nil, translateError(err, "Member")
+	}
+	return &member, nil
+}
+
+// GetMembers retrieves all members of an organization
+func (r *OrganizationRepository) GetMembers(ctx context.Context, orgID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	if err := r.DB.WithContext(ctx).Where("organization_id = ?", orgID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of a member within an organization
+func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID uint, role string) error {
+	result := r.DB.WithContext(ctx).Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return translateError(result.Error, "Member")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "Member")
+	}
+	return nil
+}
+
+// RemoveMember removes a user from an organization
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID uint) error {
+	result := r.DB.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrganizationMember{})
+	if result.Error != nil {
+		return translateError(result.Error, "Member")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "Member")
+	}
+	return nil
+}
+
+// CreateInvitation saves a new invitation; an organization has at most one per email
+func (r *OrganizationRepository) CreateInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	return translateError(r.DB.WithContext(ctx).Create(invitation).Error, "Invitation")
+}
+
+// FindInvitationByID retrieves an invitation by ID
+func (r *OrganizationRepository) FindInvitationByID(ctx context.Context, id uint) (*models.OrganizationInvitation, error) {
+	var invitation models.OrganizationInvitation
+	if err := r.DB.WithContext(ctx).Preload("Organization").First(&invitation, id).Error; err != nil {
+		return nil, translateError(err, "Invitation")
+	}
+	return &invitation, nil
+}
+
+// GetInvitations retrieves the invitations an organization has sent, oldest first
+func (r *OrganizationRepository) GetInvitations(ctx context.Context, orgID uint) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	if err := r.DB.WithContext(ctx).Where("organization_id = ?", orgID).Order("id").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// GetInvitationsForEmail retrieves the unexpired invitations sent to an email address
+func (r *OrganizationRepository) GetInvitationsForEmail(ctx context.Context, email string) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	err := r.DB.WithContext(ctx).Preload("Organization").
+		Where("email = ? AND expires_at > ?", strings.ToLower(email), time.Now()).
+		Order("id").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation makes userID a member with the invited role and removes the invitation,
+// in one transaction so an invitation is used at most once
+func (r *OrganizationRepository) AcceptInvitation(ctx context.Context, invitation *models.OrganizationInvitation, userID uint) (*models.OrganizationMember, error) {
+	member := models.OrganizationMember{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+	}
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.OrganizationInvitation{}, invitation.ID)
+		if result.Error != nil {
+			return translateError(result.Error, "Invitation")
+		}
+		if result.RowsAffected == 0 {
+			return translateError(gorm.ErrRecordNotFound, "Invitation")
+		}
+		return translateError(tx.Create(&member).Error, "Member")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// DeleteInvitation removes an invitation, whether declined or cancelled
+func (r *OrganizationRepository) DeleteInvitation(ctx context.Context, id uint) error {
+	result := r.DB.WithContext(ctx).Delete(&models.OrganizationInvitation{}, id)
+	if result.Error != nil {
+		return translateError(result.Error, "Invitation")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "Invitation")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationRepository_CreateOrganizationWithOwner(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	owner := newUser("owner")
+	org := &models.Organization{Name: "Acme"}
+	require.NoError(t, repo.CreateOrganizationWithOwner(ctx, org, owner, utils.RoleOwner))
+	member, err := repo.FindMembership(ctx, org.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, utils.RoleOwner, member.Role)
+
+	err = repo.CreateOrganizationWithOwner(ctx, &models.Organization{Name: "Acme Again"}, newUser("owner"), utils.RoleOwner)
+	assert.ErrorIs(t, err, utils.ErrConflict)
+	var appErr *utils.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "User already exists", appErr.Message)
+
+	// A failure after the account is saved rolls the account back, so registering can be retried
+	require.NoError(t, db.Migrator().DropTable("organization_members"))
+	assert.Error(t, repo.CreateOrganizationWithOwner(ctx, &models.Organization{Name: "Globex"}, newUser("retry"), utils.RoleOwner))
+	_, err = repositories.NewUserRepository(db).FindByEmail(ctx, "retry@example.com")
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+}
+
+func TestOrganizationRepository_Invitations(t *testing.T) {
+	db := test.NewTestDB(t)
+	seedOrganizations(t, db)
+	repo := repositories.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	user := newUser("member")
+	require.NoError(t, db.Create(user).Error)
+
+	invitation := &models.OrganizationInvitation{OrganizationID: 1, Email: "member@example.com", Role: "owner", InvitedByID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.CreateInvitation(ctx, invitation))
+	require.NoError(t, repo.CreateInvitation(ctx, &models.OrganizationInvitation{OrganizationID: 2, Email: "member@example.com", InvitedByID: 1, ExpiresAt: time.Now().Add(-time.Hour)}))
+
+	duplicate := &models.OrganizationInvitation{OrganizationID: 1, Email: "member@example.com", InvitedByID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	assert.ErrorIs(t, repo.CreateInvitation(ctx, duplicate), utils.ErrConflict)
+
+	pending, err := repo.GetInvitationsForEmail(ctx, "Member@Example.com")
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "expired invitations aren't pending")
+	assert.Equal(t, uint(1), pending[0].OrganizationID)
+
+	member, err := repo.AcceptInvitation(ctx, invitation, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", member.Role)
+
+	stored, err := repo.FindMembership(ctx, 1, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", stored.Role)
+
+	_, err = repo.AcceptInvitation(ctx, invitation, user.ID)
+	assert.ErrorIs(t, err, utils.ErrNotFound, "an invitation is used at most once")
+
+	_, err = repo.FindInvitationByID(ctx, invitation.ID)
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+}
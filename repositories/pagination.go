@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListOptions holds the sorting and pagination shared by list queries
+type ListOptions struct {
+	SortBy    string // Key of the entity's sort field whitelist; defaults to "id"
+	SortDesc  bool
+	Limit     int
+	Offset    int     // Ignored when Keyset is set
+	Keyset    *Keyset // Set for cursor (keyset) pagination
+	SkipCount bool    // Don't run the COUNT(*) query
+}
+
+// Keyset is the boundary row a cursor points at
+type Keyset struct {
+	Value    string // Sort key of the boundary row, as encoded in the cursor
+	ID       uint   // ID of the boundary row, used as tie-breaker
+	Backward bool   // Fetch the rows before the boundary instead of after it
+}
+
+// PageResult describes a fetched page beyond its rows
+type PageResult struct {
+	TotalCount *int64 // Nil when the count was skipped
+	HasMore    bool   // More rows exist in the fetch direction
+}
+
+// timeSortColumns are sort columns whose cursor values are RFC 3339 timestamps
+var timeSortColumns = map[string]bool{"created_at": true, "updated_at": true}
+
+// validate checks the options against a sort field whitelist
+func (o ListOptions) validate(sortFields map[string]string) error {
+	if o.SortBy != "" {
+		if _, ok := sortFields[o.SortBy]; !ok {
+			return fmt.Errorf("unsupported sort field %q", o.SortBy)
+		}
+	}
+	if o.Limit < 0 || o.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	return nil
+}
+
+// sortColumn returns the whitelisted column to sort by
+func (o ListOptions) sortColumn(sortFields map[string]string) string {
+	if column, ok := sortFields[o.SortBy]; ok {
+		return column
+	}
+	return "id"
+}
+
+// fetchPage counts and fetches one page of rows from query, sorted by column with the ID as tie-breaker.
+// One extra row is fetched to know whether more rows follow.
+func fetchPage[T any](query *gorm.DB, opts ListOptions, column string) ([]T, PageResult, error) {
+	var result PageResult
+
+	if !opts.SkipCount {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, result, err
+		}
+		result.TotalCount = &total
+	}
+
+	// Walking backwards means reading in the opposite order and flipping the rows afterwards
+	desc := opts.SortDesc
+	backward := opts.Keyset != nil && opts.Keyset.Backward
+	if backward {
+		desc = !desc
+	}
+
+	q := query.Session(&gorm.Session{})
+	if opts.Keyset != nil {
+		condition, args, err := keysetCondition(column, desc, opts.Keyset)
+		if err != nil {
+			return nil, result, err
+		}
+		q = q.Where(condition, args...)
+	} else {
+		q = q.Offset(opts.Offset)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	if column != "id" {
+		q = q.Order(column + " " + direction)
+	}
+	q = q.Order("id " + direction)
+
+	var rows []T
+	if err := q.Limit(opts.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, result, err
+	}
+
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		result.HasMore = true
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	return rows, result, nil
+}
+
+// keysetCondition builds the WHERE clause selecting rows past the keyset in the read direction
+func keysetCondition(column string, desc bool, keyset *Keyset) (string, []interface{}, error) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+
+	if column == "id" {
+		return "id " + op + " ?", []interface{}{keyset.ID}, nil
+	}
+
+	var value interface{} = keyset.Value
+	if timeSortColumns[column] {
+		t, err := time.Parse(time.RFC3339Nano, keyset.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid cursor value for %s", column)
+		}
+		value = t
+	}
+
+	condition := fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op)
+	return condition, []interface{}{value, value, keyset.ID}, nil
+}
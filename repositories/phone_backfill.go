@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/utils"
+	"gorm.io/gorm"
+)
+
+// PhoneBackfillReport summarizes a run of BackfillCustomerPhones
+type PhoneBackfillReport struct {
+	Scanned    int
+	Updated    int
+	Unchanged  int
+	Invalid    []PhoneBackfillIssue // Phones that could not be parsed; left as they are
+	Collisions []PhoneBackfillIssue // Customers whose normalized phone is taken in their organization
+}
+
+// PhoneBackfillIssue describes a customer the backfill could not normalize
+type PhoneBackfillIssue struct {
+	CustomerID     uint
+	OrganizationID uint
+	Phone          string
+	Normalized     string
+	ConflictsWith  uint // Customer already holding Normalized, for collisions
+}
+
+// backfillBatchSize is how many customers are read per query during the backfill
+const backfillBatchSize = 500
+
+// BackfillCustomerPhones rewrites the phone of every customer, across all organizations and
+// including soft-deleted ones, to E.164 in the given default region. Invalid phones and
+// collisions are reported and left untouched so they can be fixed by hand; in a dry run
+// nothing is written. The unique phone index can only be created once the report is clean.
+func BackfillCustomerPhones(ctx context.Context, db *gorm.DB, region string, dryRun bool) (PhoneBackfillReport, error) {
+	db = db.WithContext(ctx)
+	var report PhoneBackfillReport
+	var entries []PhoneBackfillIssue
+	owners := make(map[phoneKey]uint)
+
+	var batch []models.Customer
+	err := db.Unscoped().Model(&models.Customer{}).Select("id", "organization_id", "phone").
+		FindInBatches(&batch, backfillBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, customer := range batch {
+				entry := PhoneBackfillIssue{CustomerID: customer.ID, OrganizationID: customer.OrganizationID, Phone: customer.Phone}
+
+				normalized, err := utils.NormalizePhoneInRegion(customer.Phone, region)
+				if err != nil {
+					report.Invalid = append(report.Invalid, entry)
+					continue
+				}
+				entry.Normalized = normalized
+				entries = append(entries, entry)
+
+				// A row that is already normalized keeps its phone; otherwise the oldest row wins
+				key := phoneKey{customer.OrganizationID, normalized}
+				if _, taken := owners[key]; !taken || customer.Phone == normalized {
+					owners[key] = customer.ID
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return report, err
+	}
+
+	updates := make(map[uint]string)
+	for _, entry := range entries {
+		report.Scanned++
+		owner := owners[phoneKey{entry.OrganizationID, entry.Normalized}]
+		switch {
+		case owner != entry.CustomerID:
+			entry.ConflictsWith = owner
+			report.Collisions = append(report.Collisions, entry)
+		case entry.Phone == entry.Normalized:
+			report.Unchanged++
+		default:
+			updates[entry.CustomerID] = entry.Normalized
+		}
+	}
+	report.Scanned += len(report.Invalid)
+	report.Updated = len(updates)
+
+	if dryRun || len(updates) == 0 {
+		return report, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for id, phone := range updates {
+			if err := tx.Unscoped().Model(&models.Customer{}).Where("id = ?", id).UpdateColumn("phone", phone).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+// phoneKey identifies a phone number within an organization
+type phoneKey struct {
+	organizationID uint
+	phone          string
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"gorm.io/gorm"
+)
+
+// Errors of RotateRefreshToken when the current token stopped being active after it was read
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used") // Rotated by a concurrent refresh
+	ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")      // Revoked by a logout
+)
+
+// RefreshTokenRepositoryInterface defines the methods to interact with the RefreshToken model
+type RefreshTokenRepositoryInterface interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, current *models.RefreshToken, next *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uint) error
+}
+
+// RefreshTokenRepository is a concrete implementation of the RefreshTokenRepositoryInterface
+type RefreshTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+// CreateRefreshToken saves a new refresh token in the database
+func (r *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return translateError(r.DB.WithContext(ctx).Create(token).Error, "Refresh token")
+}
+
+// FindByTokenHash retrieves a refresh token by the hash of its opaque value
+func (r *RefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, translateError(err, "Refresh token")
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken revokes the current token and stores its replacement in one transaction.
+// The revoke only succeeds while the current token is still active, so two concurrent
+// refreshes with the same token cannot both win; the loser gets ErrRefreshTokenReused,
+// or ErrRefreshTokenRevoked if a logout revoked the token in the meantime.
+func (r *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, current *models.RefreshToken, next *models.RefreshToken) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var stored models.RefreshToken
+			if err := tx.Select("replaced_by_id").First(&stored, current.ID).Error; err != nil {
+				return err
+			}
+			if !stored.IsRotated() {
+				return ErrRefreshTokenRevoked
+			}
+			return ErrRefreshTokenReused
+		}
+
+		current.RevokedAt = &now
+		current.ReplacedByID = &next.ID
+		return nil
+	})
+	return translateError(err, "Refresh token")
+}
+
+// RevokeFamily revokes every active token that belongs to the given family
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every active refresh token issued to the user
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,73 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenRepository_Rotate(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewRefreshTokenRepository(db)
+	expires := time.Now().Add(time.Hour)
+
+	first := &models.RefreshToken{UserID: 1, TokenHash: "hash-1", FamilyID: "family", ExpiresAt: expires}
+	require.NoError(t, repo.CreateRefreshToken(context.Background(), first))
+
+	second := &models.RefreshToken{UserID: 1, TokenHash: "hash-2", FamilyID: "family", ExpiresAt: expires}
+	require.NoError(t, repo.RotateRefreshToken(context.Background(), first, second))
+	assert.True(t, first.IsRevoked())
+	assert.Equal(t, second.ID, *first.ReplacedByID)
+
+	// Presenting the first token again loses, and its replacement is rolled back
+	stale, err := repo.FindByTokenHash(context.Background(), "hash-1")
+	require.NoError(t, err)
+	third := &models.RefreshToken{UserID: 1, TokenHash: "hash-3", FamilyID: "family", ExpiresAt: expires}
+	assert.ErrorIs(t, repo.RotateRefreshToken(context.Background(), stale, third), repositories.ErrRefreshTokenReused)
+	_, err = repo.FindByTokenHash(context.Background(), "hash-3")
+	assert.Error(t, err)
+
+	require.NoError(t, repo.RevokeFamily(context.Background(), "family"))
+	current, err := repo.FindByTokenHash(context.Background(), "hash-2")
+	require.NoError(t, err)
+	assert.True(t, current.IsRevoked())
+	assert.False(t, current.IsRotated(), "a logout is not a rotation")
+
+	// A token revoked by a logout after it was read is reported as revoked, not reused
+	loggedOut := &models.RefreshToken{ID: current.ID}
+	fourth := &models.RefreshToken{UserID: 1, TokenHash: "hash-4", FamilyID: "family", ExpiresAt: expires}
+	assert.ErrorIs(t, repo.RotateRefreshToken(context.Background(), loggedOut, fourth), repositories.ErrRefreshTokenRevoked)
+}
+
+func TestRevocationRepository(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewRevocationRepository(db)
+
+	require.NoError(t, repo.RevokeToken(context.Background(), "jti-1", time.Now().Add(time.Hour)))
+	require.NoError(t, repo.RevokeToken(context.Background(), "jti-1", time.Now().Add(time.Hour)), "revoking twice is a no-op")
+	require.NoError(t, repo.RevokeToken(context.Background(), "jti-expired", time.Now().Add(-time.Minute)))
+
+	revoked, err := repo.IsTokenRevoked(context.Background(), "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsTokenRevoked(context.Background(), "jti-expired")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	at, err := repo.UserTokensRevokedAt(context.Background(), 7)
+	require.NoError(t, err)
+	assert.True(t, at.IsZero())
+
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.RevokeUserTokens(context.Background(), 7, first))
+	require.NoError(t, repo.RevokeUserTokens(context.Background(), 7, first.Add(time.Hour)))
+	at, err = repo.UserTokensRevokedAt(context.Background(), 7)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(first.Add(time.Hour)), "got %s", at)
+}
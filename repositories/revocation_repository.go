@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationRepository is a database-backed implementation of utils.RevocationStore,
+// shared by every application instance pointing at the same database
+type RevocationRepository struct {
+	DB *gorm.DB
+}
+
+// Ensure RevocationRepository implements utils.RevocationStore
+var _ utils.RevocationStore = (*RevocationRepository)(nil)
+
+// NewRevocationRepository creates a new instance of RevocationRepository
+func NewRevocationRepository(db *gorm.DB) *RevocationRepository {
+	return &RevocationRepository{DB: db}
+}
+
+// RevokeToken stores the jti until the token expires and purges entries that already did
+func (r *RevocationRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+	})
+}
+
+// IsTokenRevoked reports whether the token with the given jti was revoked
+func (r *RevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeUserTokens revokes every token issued to the user before revokedAt
+func (r *RevocationRepository) RevokeUserTokens(ctx context.Context, userID uint, revokedAt time.Time) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_at", "updated_at"}),
+	}).Create(&models.UserTokenRevocation{UserID: userID, RevokedAt: revokedAt}).Error
+}
+
+// UserTokensRevokedAt returns when the user's tokens were last revoked
+func (r *RevocationRepository) UserTokensRevokedAt(ctx context.Context, userID uint) (time.Time, error) {
+	var revocation models.UserTokenRevocation
+	if err := r.DB.WithContext(ctx).First(&revocation, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return revocation.RevokedAt, nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/utils"
+	"gorm.io/gorm"
+)
+
+// RoleRepositoryInterface defines the methods to interact with the Role and Permission models
+type RoleRepositoryInterface interface {
+	CreateRole(ctx context.Context, role *models.Role, permissions []string) error
+	FindRoleByName(ctx context.Context, name string) (*models.Role, error)
+	GetAllRoles(ctx context.Context) ([]models.Role, error)
+	SetRolePermissions(ctx context.Context, role *models.Role, permissions []string) error
+	PermissionsForRole(ctx context.Context, name string) ([]string, error)
+	SeedRoles(ctx context.Context, defaults map[string][]string) error
+}
+
+// RoleRepository is a concrete implementation of the RoleRepositoryInterface
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+// NewRoleRepository creates a new instance of RoleRepository
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{DB: db}
+}
+
+// CreateRole saves a new role together with its permissions, so a failure leaves no role behind
+func (r *RoleRepository) CreateRole(ctx context.Context, role *models.Role, permissions []string) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return setPermissions(tx, role, permissions)
+	})
+	return translateError(err, "Role")
+}
+
+// FindRoleByName retrieves a role and its permissions by name
+func (r *RoleRepository) FindRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.DB.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, translateError(err, "Role")
+	}
+	return &role, nil
+}
+
+// GetAllRoles retrieves every role with its permissions
+func (r *RoleRepository) GetAllRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.DB.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return nil, translateError(err, "Role")
+	}
+	return roles, nil
+}
+
+// SetRolePermissions replaces the permissions of a role, creating missing permission rows
+func (r *RoleRepository) SetRolePermissions(ctx context.Context, role *models.Role, permissions []string) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return setPermissions(tx, role, permissions)
+	})
+	return translateError(err, "Role")
+}
+
+// setPermissions replaces the permissions of a role within tx
+func setPermissions(tx *gorm.DB, role *models.Role, permissions []string) error {
+	perms := make([]models.Permission, 0, len(permissions))
+	for _, name := range permissions {
+		perm := models.Permission{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&perm).Error; err != nil {
+			return err
+		}
+		perms = append(perms, perm)
+	}
+
+	if err := tx.Model(role).Association("Permissions").Replace(perms); err != nil {
+		return err
+	}
+	role.Permissions = perms
+	return nil
+}
+
+// PermissionsForRole returns the permission names granted to a role; unknown roles have none
+func (r *RoleRepository) PermissionsForRole(ctx context.Context, name string) ([]string, error) {
+	role, err := r.FindRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return role.PermissionNames(), nil
+}
+
+// SeedRoles creates the given roles with their permissions if they don't exist yet.
+// Existing roles are left untouched so changes made by admins survive restarts; permissions
+// a release adds to a built-in role are granted by a versioned migration instead.
+func (r *RoleRepository) SeedRoles(ctx context.Context, defaults map[string][]string) error {
+	for name, permissions := range defaults {
+		_, err := r.FindRoleByName(ctx, name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, utils.ErrNotFound) {
+			return err
+		}
+
+		role := models.Role{Name: name}
+		if err := r.CreateRole(ctx, &role, permissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRepository_SeedRoles(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewRoleRepository(db)
+
+	require.NoError(t, repo.SeedRoles(context.Background(), map[string][]string{
+		"admin": {"users:read", "users:write"},
+		"user":  {"users:read"},
+	}))
+
+	permissions, err := repo.PermissionsForRole(context.Background(), "admin")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"users:read", "users:write"}, permissions)
+
+	// Edits survive a later seed, removed default permissions included
+	role, err := repo.FindRoleByName(context.Background(), "user")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetRolePermissions(context.Background(), role, []string{"customers:read"}))
+	require.NoError(t, repo.SeedRoles(context.Background(), map[string][]string{"user": {"users:read", "messages:read"}}))
+
+	permissions, err = repo.PermissionsForRole(context.Background(), "user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customers:read"}, permissions)
+
+	permissions, err = repo.PermissionsForRole(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Permission{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count, "permission rows are shared between roles")
+}
+
+func TestRoleRepository_CreateRole(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewRoleRepository(db)
+
+	role := &models.Role{Name: "support"}
+	require.NoError(t, repo.CreateRole(context.Background(), role, []string{"customers:read"}))
+	permissions, err := repo.PermissionsForRole(context.Background(), "support")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customers:read"}, permissions)
+
+	err = repo.CreateRole(context.Background(), &models.Role{Name: "support"}, nil)
+	assert.ErrorIs(t, err, utils.ErrConflict)
+
+	// A failure while granting permissions leaves no role without permissions behind
+	require.NoError(t, db.Migrator().DropTable("role_permissions"))
+	assert.Error(t, repo.CreateRole(context.Background(), &models.Role{Name: "auditor"}, []string{"customers:read"}))
+	_, err = repo.FindRoleByName(context.Background(), "auditor")
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+)
+
+// organizationScope restricts a query to rows owned by the organization
+func organizationScope(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("organization_id = ?", orgID)
+	}
+}
+
+// memberScope restricts a users query to members of the organization
+func memberScope(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("users.id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Table("organization_members").
+			Select("user_id").
+			Where("organization_id = ?", orgID))
+	}
+}
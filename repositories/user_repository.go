@@ -1,22 +1,38 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/metabbe3/go-backend/models"
 	"gorm.io/gorm"
 )
 
 // UserRepositoryInterface defines the methods to interact with the User model
 type UserRepositoryInterface interface {
-	CreateUser(user *models.User) error
-	FindByEmail(email string) (*models.User, error)
-	UpdateUser(user *models.User) error
-	DeleteUser(id uint) error
-	GetAllUsers(limit, offset int) ([]models.User, int, error) // Updated
+	ForOrganization(orgID uint) UserRepositoryInterface
+	CreateUser(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id uint) error
+	GetAllUsers(ctx context.Context, opts ListOptions) ([]models.User, PageResult, error)
+}
+
+// UserSortFields maps the sort fields accepted by the API to their columns
+var UserSortFields = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
 }
 
-// UserRepository is a concrete implementation of the UserRepositoryInterface
+// UserRepository is a concrete implementation of the UserRepositoryInterface.
+// Users are global accounts (login works across organizations); a repository
+// returned by ForOrganization only sees users that are members of that organization.
 type UserRepository struct {
-	DB *gorm.DB
+	DB             *gorm.DB
+	OrganizationID uint
+	scopedToOrg    bool
 }
 
 // NewUserRepository creates a new instance of UserRepository
@@ -24,44 +40,110 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{DB: db}
 }
 
-// CreateUser saves a new user in the database
-func (r *UserRepository) CreateUser(user *models.User) error {
-	return r.DB.Create(user).Error
+// ForOrganization returns a repository scoped to members of the given organization
+func (r *UserRepository) ForOrganization(orgID uint) UserRepositoryInterface {
+	return &UserRepository{DB: r.DB, OrganizationID: orgID, scopedToOrg: true}
+}
+
+// query returns a users query bound to ctx, restricted to organization members when scoped
+func (r *UserRepository) query(ctx context.Context) *gorm.DB {
+	db := r.DB.WithContext(ctx)
+	if !r.scopedToOrg {
+		return db
+	}
+	return db.Scopes(memberScope(r.OrganizationID))
+}
+
+// CreateUser saves a new user in the database.
+// When scoped, the user also becomes a member of the organization.
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	if !r.scopedToOrg {
+		return translateError(r.DB.WithContext(ctx).Create(user).Error, "User")
+	}
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: r.OrganizationID,
+			UserID:         user.ID,
+			Role:           user.Role,
+		}).Error
+	})
+	return translateError(err, "User")
 }
 
 // FindByEmail retrieves a user by email
-func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.DB.Where("email = ?", email).First(&user).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err, "User")
 	}
 	return &user, nil
 }
 
-// UpdateUser updates user details (e.g., saving JWT token)
-func (r *UserRepository) UpdateUser(user *models.User) error {
-	return r.DB.Save(user).Error
+// FindByID retrieves a user by ID
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.query(ctx).First(&user, id).Error; err != nil {
+		return nil, translateError(err, "User")
+	}
+	return &user, nil
 }
 
-// DeleteUser deletes a user by their ID
-func (r *UserRepository) DeleteUser(id uint) error {
-	return r.DB.Delete(&models.User{}, id).Error
-}
+// UpdateUser updates user details (e.g., password or role)
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	if !r.scopedToOrg {
+		return translateError(r.DB.WithContext(ctx).Save(user).Error, "User")
+	}
 
-// GetAllUsers retrieves all users from the database with limit, offset, and total count
-func (r *UserRepository) GetAllUsers(limit, offset int) ([]models.User, int, error) {
-	var users []models.User
-	var totalCount int64
+	result := r.query(ctx).Model(user).Select("*").Omit("id", "created_at").Updates(user)
+	if result.Error != nil {
+		return translateError(result.Error, "User")
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound, "User")
+	}
+	return nil
+}
 
-	// Fetch the users with limit and offset
-	if err := r.DB.Limit(limit).Offset(offset).Find(&users).Error; err != nil {
-		return nil, 0, err
+// DeleteUser deletes a user by their ID.
+// When scoped, the user is removed from the organization and the account itself
+// is only deleted once it no longer belongs to any organization.
+func (r *UserRepository) DeleteUser(ctx context.Context, id uint) error {
+	if !r.scopedToOrg {
+		return translateError(r.DB.WithContext(ctx).Delete(&models.User{}, id).Error, "User")
 	}
 
-	// Get the total count of users
-	if err := r.DB.Model(&models.User{}).Count(&totalCount).Error; err != nil {
-		return nil, 0, err
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("organization_id = ? AND user_id = ?", r.OrganizationID, id).
+			Delete(&models.OrganizationMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.OrganizationMember{}).Where("user_id = ?", id).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return nil
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+	return translateError(err, "User")
+}
+
+// GetAllUsers retrieves one page of users
+func (r *UserRepository) GetAllUsers(ctx context.Context, opts ListOptions) ([]models.User, PageResult, error) {
+	if err := opts.validate(UserSortFields); err != nil {
+		return nil, PageResult{}, err
 	}
 
-	return users, int(totalCount), nil
+	base := r.query(ctx).Model(&models.User{})
+	return fetchPage[models.User](base, opts, opts.sortColumn(UserSortFields))
 }
@@ -0,0 +1,120 @@
+package repositories_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newUser returns a user with a distinct email
+func newUser(name string) *models.User {
+	return &models.User{
+		Name:     name,
+		Email:    fmt.Sprintf("%s@example.com", name),
+		Password: "hashed",
+		Role:     "user",
+	}
+}
+
+// seedOrganizations creates organizations 1 and 2, which memberships reference
+func seedOrganizations(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	require.NoError(t, db.Create(&[]models.Organization{{ID: 1, Name: "Acme"}, {ID: 2, Name: "Globex"}}).Error)
+}
+
+func TestUserRepository_OrganizationMembers(t *testing.T) {
+	db := test.NewTestDB(t)
+	seedOrganizations(t, db)
+	base := repositories.NewUserRepository(db)
+	orgA, orgB := base.ForOrganization(1), base.ForOrganization(2)
+
+	alice, bob := newUser("alice"), newUser("bob")
+	require.NoError(t, orgA.CreateUser(context.Background(), alice))
+	require.NoError(t, orgB.CreateUser(context.Background(), bob))
+	require.NoError(t, db.Create(&models.OrganizationMember{OrganizationID: 2, UserID: alice.ID, Role: "user"}).Error)
+
+	users, result, err := orgB.GetAllUsers(context.Background(), repositories.ListOptions{Limit: 10, SortBy: "name"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Name)
+	assert.Equal(t, int64(2), *result.TotalCount)
+
+	_, err = orgA.FindByEmail(context.Background(), "bob@example.com")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+	found, err := base.FindByEmail(context.Background(), "bob@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, found.ID, "login looks users up across organizations")
+
+	bob.Name = "Hijacked"
+	assert.ErrorIs(t, orgA.UpdateUser(context.Background(), bob), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, orgA.DeleteUser(context.Background(), bob.ID), gorm.ErrRecordNotFound)
+}
+
+func TestUserRepository_DeleteUser(t *testing.T) {
+	db := test.NewTestDB(t)
+	seedOrganizations(t, db)
+	base := repositories.NewUserRepository(db)
+
+	alice := newUser("alice")
+	require.NoError(t, base.ForOrganization(1).CreateUser(context.Background(), alice))
+	require.NoError(t, db.Create(&models.OrganizationMember{OrganizationID: 2, UserID: alice.ID, Role: "user"}).Error)
+
+	// Leaving one organization keeps the account for the other
+	require.NoError(t, base.ForOrganization(1).DeleteUser(context.Background(), alice.ID))
+	_, err := base.FindByID(context.Background(), alice.ID)
+	require.NoError(t, err)
+	_, err = base.ForOrganization(1).FindByID(context.Background(), alice.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Leaving the last one deletes the account
+	require.NoError(t, base.ForOrganization(2).DeleteUser(context.Background(), alice.ID))
+	_, err = base.FindByID(context.Background(), alice.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestUserRepository_UniqueEmail(t *testing.T) {
+	db := test.NewTestDB(t)
+	seedOrganizations(t, db)
+	repo := repositories.NewUserRepository(db).ForOrganization(1)
+
+	require.NoError(t, repo.CreateUser(context.Background(), newUser("alice")))
+	duplicate := newUser("alice")
+	err := repo.CreateUser(context.Background(), duplicate)
+	assert.ErrorIs(t, err, utils.ErrConflict, "the driver's unique violation becomes a conflict")
+	var appErr *utils.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "User already exists", appErr.Message)
+	assert.Equal(t, utils.CodeConflict, appErr.Code)
+
+	var members int64
+	require.NoError(t, db.Model(&models.OrganizationMember{}).Count(&members).Error)
+	assert.Equal(t, int64(1), members, "a failed create adds no membership")
+}
+
+func TestUserRepository_ContextCancellation(t *testing.T) {
+	db := test.NewTestDB(t)
+	repo := repositories.NewUserRepository(db)
+	alice := newUser("alice")
+	require.NoError(t, repo.CreateUser(context.Background(), alice))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := repo.FindByID(cancelled, alice.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	_, _, err = repo.ForOrganization(1).GetAllUsers(expired, repositories.ListOptions{Limit: 10})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, repo.UpdateUser(expired, alice), context.DeadlineExceeded)
+}
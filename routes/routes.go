@@ -1,59 +1,121 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
-	"github.com/metabbe3/go-backend/config"
 	"github.com/metabbe3/go-backend/controllers"
 	"github.com/metabbe3/go-backend/middleware"
-	"github.com/metabbe3/go-backend/repositories"
-	"github.com/metabbe3/go-backend/utils" // Import PasswordHasher package
+	"github.com/metabbe3/go-backend/utils"
 )
 
-// SetupRoutes initializes all routes
-func SetupRoutes(router *gin.Engine) {
-	// Ensure DB is initialized
-	if config.DB == nil {
-		panic("Database connection is not initialized")
-	}
+// Dependencies are what the routes are bound to
+type Dependencies struct {
+	Auth         *controllers.AuthController
+	User         *controllers.UserController
+	Customer     *controllers.CustomerController
+	Role         *controllers.RoleController
+	Organization *controllers.OrganizationController
+	Message      *controllers.MessageController
+	Webhook      *controllers.WebhookController
+	Health       *controllers.HealthController
+	Policy       *utils.PolicyEngine   // Resolves role permissions for RequirePermission
+	Revocations  utils.RevocationStore // Checked by the JWT middleware
 
-	// Initialize repositories with the global DB instance
-	userRepo := repositories.NewUserRepository(config.DB)
-	customerRepo := repositories.NewCustomerRepository(config.DB)
+	DBTimeout     time.Duration // Deadline for the queries of a request; zero disables it
+	BulkDBTimeout time.Duration // Replaces DBTimeout for customer imports and exports
+}
 
-	// Initialize controllers with repositories and utils
-	authController := controllers.NewAuthController(userRepo, utils.BcryptHasher{})
-	userController := controllers.NewUserController(userRepo, utils.BcryptHasher{})
-	customerController := controllers.NewCustomerController(customerRepo)
+// SetupRoutes initializes all routes
+func SetupRoutes(router *gin.Engine, deps Dependencies) {
+	jwtAuth := middleware.JWTAuthMiddleware(deps.Revocations)
+	tenantOnly := middleware.RequireOrganization()
+	dbTimeout := middleware.DBTimeout(deps.DBTimeout)
+	can := func(permissions ...string) gin.HandlerFunc {
+		return middleware.RequirePermission(deps.Policy, permissions...)
+	}
 
 	// Public routes
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	router.GET("/livez", deps.Health.Livez)   // Liveness probe
+	router.GET("/readyz", deps.Health.Readyz) // Readiness probe with dependency checks
+	router.GET("/health", deps.Health.Livez)  // Kept for existing monitors
+
+	webhooks := router.Group("/webhooks", dbTimeout)
+	{
+		webhooks.GET("/whatsapp", deps.Webhook.VerifyWhatsAppWebhook)   // Subscription handshake
+		webhooks.POST("/whatsapp", deps.Webhook.ReceiveWhatsAppWebhook) // Inbound messages and statuses
+	}
 
 	// Auth routes
-	auth := router.Group("/auth")
+	auth := router.Group("/auth", dbTimeout)
+	{
+		auth.POST("/login", deps.Auth.LoginUser)       // Login user
+		auth.POST("/register", deps.Auth.RegisterUser) // Register new user
+		auth.POST("/refresh", deps.Auth.RefreshToken)  // Rotate refresh token
+
+		auth.POST("/logout", jwtAuth, deps.Auth.LogoutUser)                      // Revoke current token
+		auth.POST("/logout-all", jwtAuth, deps.Auth.LogoutAll)                   // Revoke all tokens of the user
+		auth.POST("/switch-organization", jwtAuth, deps.Auth.SwitchOrganization) // Re-scope tokens to another organization
+	}
+
+	// Bulk customer routes, whose queries and transfers may run longer than a regular request's.
+	// Their handlers lift the server's read and write timeouts up to BulkDBTimeout.
+	bulk := router.Group("/api", middleware.DBTimeout(deps.BulkDBTimeout), jwtAuth, tenantOnly)
 	{
-		auth.POST("/login", authController.LoginUser)       // Login user
-		auth.POST("/register", authController.RegisterUser) // Register new user
+		bulk.GET("/customers/export", can(utils.PermCustomersRead), deps.Customer.ExportCustomers)   // Download as CSV/JSONL/XLSX
+		bulk.POST("/customers/import", can(utils.PermCustomersWrite), deps.Customer.ImportCustomers) // Bulk import from CSV/XLSX
 	}
 
 	// Protected API routes (JWT required)
-	api := router.Group("/api")
-	api.Use(middleware.JWTAuthMiddleware()) // Apply JWT middleware to the API group
+	api := router.Group("/api", dbTimeout, jwtAuth)
 	{
-		// User routes
-		api.POST("/user", userController.CreateUser)       // Create user
-		api.GET("/user/:email", userController.GetUser)    // Get user by ID
-		api.PUT("/user/:email", userController.UpdateUser) // Update user by ID
-		api.DELETE("/user/:id", userController.DeleteUser) // Delete user by ID
-		api.GET("/users", userController.GetAllUsers)      // Get all users
-
-		// Customer routes
-		api.POST("/customer", customerController.CreateCustomer)       // Create customer
-		api.GET("/customer/:id", customerController.GetCustomer)       // Get customer by ID
-		api.PUT("/customer/:id", customerController.UpdateCustomer)    // Update customer by ID
-		api.DELETE("/customer/:id", customerController.DeleteCustomer) // Delete customer by ID
-		api.GET("/customers", customerController.GetAllCustomers)      // Get all customers
+		// Account routes, which act on the caller's own account rather than a tenant
+		api.PUT("/user/:email", deps.User.UpdateUser)                    // Update own account; admins any
+		api.POST("/organizations", deps.Organization.CreateOrganization) // Create organization
+		api.GET("/organizations", deps.Organization.GetMyOrganizations)  // List my organizations
+
+		// Invitation routes, for the invited user
+		api.GET("/invitations", deps.Organization.GetMyInvitations)               // List my pending invitations
+		api.POST("/invitations/:id/accept", deps.Organization.AcceptInvitation)   // Join the organization
+		api.POST("/invitations/:id/decline", deps.Organization.DeclineInvitation) // Turn the invitation down
+
+		// Tenant routes, which need a token scoped to an organization
+		tenant := api.Group("", tenantOnly)
+		{
+			// User routes
+			tenant.POST("/user", can(utils.PermUsersWrite), deps.User.CreateUser)        // Create user
+			tenant.GET("/user/:email", can(utils.PermUsersRead), deps.User.GetUser)      // Get user by ID
+			tenant.DELETE("/user/:id", can(utils.PermUsersDelete), deps.User.DeleteUser) // Delete user by ID
+			tenant.GET("/users", can(utils.PermUsersRead), deps.User.GetAllUsers)        // Get all users
+
+			// Customer routes
+			tenant.POST("/customer", can(utils.PermCustomersWrite), deps.Customer.CreateCustomer)        // Create customer
+			tenant.GET("/customer/:id", can(utils.PermCustomersRead), deps.Customer.GetCustomer)         // Get customer by ID
+			tenant.PUT("/customer/:id", can(utils.PermCustomersWrite), deps.Customer.UpdateCustomer)     // Update customer by ID
+			tenant.DELETE("/customer/:id", can(utils.PermCustomersDelete), deps.Customer.DeleteCustomer) // Delete customer by ID
+			tenant.GET("/customers", can(utils.PermCustomersRead), deps.Customer.GetAllCustomers)        // Get all customers
+
+			// Customer messaging routes
+			tenant.POST("/customer/:id/messages", can(utils.PermMessagesSend), deps.Message.SendMessage) // Send WhatsApp message
+			tenant.GET("/customer/:id/messages", can(utils.PermMessagesRead), deps.Message.GetMessages)  // Message history
+
+			// Organization member routes
+			tenant.GET("/organization/members", can(utils.PermUsersRead), deps.Organization.GetMembers)                      // List members of current organization
+			tenant.PUT("/organization/members/:userId", can(utils.PermMembersManage), deps.Organization.UpdateMemberRole)    // Change member role
+			tenant.DELETE("/organization/members/:userId", can(utils.PermMembersManage), deps.Organization.RemoveMember)     // Remove member
+			tenant.GET("/organization/invitations", can(utils.PermMembersManage), deps.Organization.GetInvitations)          // List sent invitations
+			tenant.POST("/organization/invitations", can(utils.PermMembersManage), deps.Organization.InviteMember)           // Invite by email
+			tenant.DELETE("/organization/invitations/:id", can(utils.PermMembersManage), deps.Organization.CancelInvitation) // Cancel invitation
+		}
+
+		// Admin routes
+		admin := api.Group("/admin", can(utils.PermRolesManage))
+		{
+			admin.GET("/roles", deps.Role.GetAllRoles)                             // List roles
+			admin.POST("/roles", deps.Role.CreateRole)                             // Create role
+			admin.PUT("/roles/:name/permissions", deps.Role.UpdateRolePermissions) // Replace role permissions
+			admin.PUT("/users/:id/role", deps.Role.AssignUserRole)                 // Assign role to user
+		}
 
 		// Dashboard route
 		api.GET("/dashboard", func(c *gin.Context) {
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/metabbe3/go-backend/config"
+	"github.com/metabbe3/go-backend/migrations"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewTestDB opens a database with the schema migrated, for tests that need real SQL.
+//
+// By default it is a private in-memory SQLite database. Set TEST_DB_DRIVER (mysql or postgres)
+// and TEST_DB_DSN to run the same tests against a server; the schema is then migrated down
+// after each test, so use a throwaway database and run packages one at a time (go test -p 1).
+func NewTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	driver := os.Getenv("TEST_DB_DRIVER")
+	dsn := os.Getenv("TEST_DB_DSN")
+	if driver == "" {
+		driver, dsn = config.DriverSQLite, ":memory:?_pragma=foreign_keys(1)"
+	}
+
+	dialector, err := config.NewDialector(config.DatabaseConfig{Driver: driver, DSN: dsn})
+	if err != nil {
+		t.Fatalf("test database: %v", err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("test database: %v", err)
+	}
+	if driver == config.DriverSQLite {
+		// Every connection to ":memory:" is a separate database
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("test database: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if driver != config.DriverSQLite {
+			if _, err := migrator.Down(int(migrator.Latest())); err != nil {
+				t.Errorf("test database cleanup: %v", err)
+			}
+			db.Exec("DROP TABLE schema_migrations")
+		}
+		sqlDB.Close()
+	})
+	return db
+}
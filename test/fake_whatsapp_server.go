@@ -0,0 +1,112 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// FakeWhatsAppServer is a local stand-in for the WhatsApp Cloud API messages and phone number endpoints.
+// Point a messaging.WhatsAppSender's BaseURL at URL() to exercise it without network access.
+type FakeWhatsAppServer struct {
+	*httptest.Server
+
+	PhoneNumberID string
+	AccessToken   string
+
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	failWith *fakeWhatsAppError
+	nextID   int
+}
+
+type fakeWhatsAppError struct {
+	status  int
+	code    int
+	message string
+}
+
+// NewFakeWhatsAppServer starts a fake Cloud API accepting the given phone number ID and token
+func NewFakeWhatsAppServer(phoneNumberID, accessToken string) *FakeWhatsAppServer {
+	f := &FakeWhatsAppServer{PhoneNumberID: phoneNumberID, AccessToken: accessToken}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+// FailNext makes the following requests fail with the given Cloud API error
+func (f *FakeWhatsAppServer) FailNext(status, code int, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failWith = &fakeWhatsAppError{status: status, code: code, message: message}
+}
+
+// Requests returns the decoded bodies of all accepted requests
+func (f *FakeWhatsAppServer) Requests() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]interface{}(nil), f.requests...)
+}
+
+func (f *FakeWhatsAppServer) handle(w http.ResponseWriter, r *http.Request) {
+	isPhoneLookup := r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/"+f.PhoneNumberID)
+	isSend := r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/"+f.PhoneNumberID+"/messages")
+	if !isPhoneLookup && !isSend {
+		writeFakeError(w, http.StatusNotFound, 100, "Unsupported request")
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+f.AccessToken {
+		writeFakeError(w, http.StatusUnauthorized, 190, "Invalid OAuth access token")
+		return
+	}
+
+	if isPhoneLookup {
+		f.mu.Lock()
+		failure := f.failWith
+		f.mu.Unlock()
+		if failure != nil {
+			writeFakeError(w, failure.status, failure.code, failure.message)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": f.PhoneNumberID})
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeError(w, http.StatusBadRequest, 100, "Invalid JSON")
+		return
+	}
+
+	f.mu.Lock()
+	failure := f.failWith
+	if failure == nil {
+		f.requests = append(f.requests, body)
+		f.nextID++
+	}
+	id := fmt.Sprintf("wamid.FAKE%d", f.nextID)
+	f.mu.Unlock()
+
+	if failure != nil {
+		writeFakeError(w, failure.status, failure.code, failure.message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"contacts":          []map[string]string{{"input": body["to"].(string), "wa_id": body["to"].(string)}},
+		"messages":          []map[string]string{{"id": id}},
+	})
+}
+
+func writeFakeError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": "OAuthException", "code": code},
+	})
+}
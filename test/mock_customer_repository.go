@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCustomerRepository implements CustomerRepositoryInterface
+type MockCustomerRepository struct {
+	mock.Mock
+	OrganizationID uint // Last organization the mock was scoped to
+}
+
+// Ensure MockCustomerRepository implements CustomerRepositoryInterface
+var _ repositories.CustomerRepositoryInterface = (*MockCustomerRepository)(nil)
+
+// ForOrganization records the organization and returns the same mock
+func (m *MockCustomerRepository) ForOrganization(orgID uint) repositories.CustomerRepositoryInterface {
+	m.OrganizationID = orgID
+	return m
+}
+
+// CreateCustomer mocks the CreateCustomer function
+func (m *MockCustomerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) error {
+	args := m.Called(ctx, customer)
+	return args.Error(0)
+}
+
+// FindCustomerByID mocks the FindCustomerByID function
+func (m *MockCustomerRepository) FindCustomerByID(ctx context.Context, id uint) (*models.Customer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Customer), args.Error(1)
+}
+
+// FindCustomerByPhone mocks the FindCustomerByPhone function
+func (m *MockCustomerRepository) FindCustomerByPhone(ctx context.Context, phone string) (*models.Customer, error) {
+	args := m.Called(ctx, phone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Customer), args.Error(1)
+}
+
+// UpdateCustomer mocks the UpdateCustomer function
+func (m *MockCustomerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) error {
+	args := m.Called(ctx, customer)
+	return args.Error(0)
+}
+
+// DeleteCustomer mocks the DeleteCustomer function
+func (m *MockCustomerRepository) DeleteCustomer(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// GetAllCustomers mocks the GetAllCustomers function
+func (m *MockCustomerRepository) GetAllCustomers(ctx context.Context, query repositories.CustomerQuery) ([]models.Customer, repositories.PageResult, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, repositories.PageResult{}, args.Error(2)
+	}
+	return args.Get(0).([]models.Customer), args.Get(1).(repositories.PageResult), args.Error(2)
+}
+
+// ExportCustomers mocks the ExportCustomers function. A []models.Customer first return
+// value is passed to fn as a single batch before the mocked error is returned.
+func (m *MockCustomerRepository) ExportCustomers(ctx context.Context, query repositories.CustomerQuery, batchSize int, fn func([]models.Customer) error) error {
+	args := m.Called(ctx, query, batchSize, fn)
+	if batch, ok := args.Get(0).([]models.Customer); ok {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// FindCustomersByContacts mocks the FindCustomersByContacts function
+func (m *MockCustomerRepository) FindCustomersByContacts(ctx context.Context, phones, emails []string) ([]models.Customer, error) {
+	args := m.Called(ctx, phones, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Customer), args.Error(1)
+}
+
+// ImportCustomers mocks the ImportCustomers function
+func (m *MockCustomerRepository) ImportCustomers(ctx context.Context, creates, updates []models.Customer, batchSize int) error {
+	args := m.Called(ctx, creates, updates, batchSize)
+	return args.Error(0)
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOrganizationRepository implements OrganizationRepositoryInterface
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockOrganizationRepository implements OrganizationRepositoryInterface
+var _ repositories.OrganizationRepositoryInterface = (*MockOrganizationRepository)(nil)
+
+// CreateOrganization mocks the CreateOrganization function
+func (m *MockOrganizationRepository) CreateOrganization(ctx context.Context, org *models.Organization, ownerID uint, ownerRole string) error {
+	args := m.Called(ctx, org, ownerID, ownerRole)
+	return args.Error(0)
+}
+
+// CreateOrganizationWithOwner mocks the CreateOrganizationWithOwner function
+func (m *MockOrganizationRepository) CreateOrganizationWithOwner(ctx context.Context, org *models.Organization, owner *models.User, ownerRole string) error {
+	args := m.Called(ctx, org, owner, ownerRole)
+	return args.Error(0)
+}
+
+// FindOrganizationByID mocks the FindOrganizationByID function
+func (m *MockOrganizationRepository) FindOrganizationByID(ctx context.Context, id uint) (*models.Organization, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+// GetMemberships mocks the GetMemberships function
+func (m *MockOrganizationRepository) GetMemberships(ctx context.Context, userID uint) ([]models.OrganizationMember, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrganizationMember), args.Error(1)
+}
+
+// FindMembership mocks the FindMembership function
+func (m *MockOrganizationRepository) FindMembership(ctx context.Context, orgID, userID uint) (*models.OrganizationMember, error) {
+	args := m.Called(ctx, orgID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrganizationMember), args.Error(1)
+}
+
+// GetMembers mocks the GetMembers function
+func (m *MockOrganizationRepository) GetMembers(ctx context.Context, orgID uint) ([]models.OrganizationMember, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrganizationMember), args.Error(1)
+}
+
+// UpdateMemberRole mocks the UpdateMemberRole function
+func (m *MockOrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID uint, role string) error {
+	args := m.Called(ctx, orgID, userID, role)
+	return args.Error(0)
+}
+
+// RemoveMember mocks the RemoveMember function
+func (m *MockOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID uint) error {
+	args := m.Called(ctx, orgID, userID)
+	return args.Error(0)
+}
+
+// CreateInvitation mocks the CreateInvitation function
+func (m *MockOrganizationRepository) CreateInvitation(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	args := m.Called(ctx, invitation)
+	return args.Error(0)
+}
+
+// FindInvitationByID mocks the FindInvitationByID function
+func (m *MockOrganizationRepository) FindInvitationByID(ctx context.Context, id uint) (*models.OrganizationInvitation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrganizationInvitation), args.Error(1)
+}
+
+// GetInvitations mocks the GetInvitations function
+func (m *MockOrganizationRepository) GetInvitations(ctx context.Context, orgID uint) ([]models.OrganizationInvitation, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrganizationInvitation), args.Error(1)
+}
+
+// GetInvitationsForEmail mocks the GetInvitationsForEmail function
+func (m *MockOrganizationRepository) GetInvitationsForEmail(ctx context.Context, email string) ([]models.OrganizationInvitation, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OrganizationInvitation), args.Error(1)
+}
+
+// AcceptInvitation mocks the AcceptInvitation function
+func (m *MockOrganizationRepository) AcceptInvitation(ctx context.Context, invitation *models.OrganizationInvitation, userID uint) (*models.OrganizationMember, error) {
+	args := m.Called(ctx, invitation, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OrganizationMember), args.Error(1)
+}
+
+// DeleteInvitation mocks the DeleteInvitation function
+func (m *MockOrganizationRepository) DeleteInvitation(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"context"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRefreshTokenRepository implements RefreshTokenRepositoryInterface
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRefreshTokenRepository implements RefreshTokenRepositoryInterface
+var _ repositories.RefreshTokenRepositoryInterface = (*MockRefreshTokenRepository)(nil)
+
+// CreateRefreshToken mocks the CreateRefreshToken function
+func (m *MockRefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+// FindByTokenHash mocks the FindByTokenHash function
+func (m *MockRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+// RotateRefreshToken mocks the RotateRefreshToken function
+func (m *MockRefreshTokenRepository) RotateRefreshToken(ctx context.Context, current *models.RefreshToken, next *models.RefreshToken) error {
+	args := m.Called(ctx, current, next)
+	return args.Error(0)
+}
+
+// RevokeFamily mocks the RevokeFamily function
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+// RevokeAllForUser mocks the RevokeAllForUser function
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/repositories"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRoleRepository implements RoleRepositoryInterface
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRoleRepository implements RoleRepositoryInterface
+var _ repositories.RoleRepositoryInterface = (*MockRoleRepository)(nil)
+
+// CreateRole mocks the CreateRole function
+func (m *MockRoleRepository) CreateRole(ctx context.Context, role *models.Role, permissions []string) error {
+	args := m.Called(ctx, role, permissions)
+	return args.Error(0)
+}
+
+// FindRoleByName mocks the FindRoleByName function
+func (m *MockRoleRepository) FindRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+// GetAllRoles mocks the GetAllRoles function
+func (m *MockRoleRepository) GetAllRoles(ctx context.Context) ([]models.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+// SetRolePermissions mocks the SetRolePermissions function
+func (m *MockRoleRepository) SetRolePermissions(ctx context.Context, role *models.Role, permissions []string) error {
+	args := m.Called(ctx, role, permissions)
+	return args.Error(0)
+}
+
+// PermissionsForRole mocks the PermissionsForRole function
+func (m *MockRoleRepository) PermissionsForRole(ctx context.Context, name string) ([]string, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// SeedRoles mocks the SeedRoles function
+func (m *MockRoleRepository) SeedRoles(ctx context.Context, defaults map[string][]string) error {
+	args := m.Called(ctx, defaults)
+	return args.Error(0)
+}
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"context"
+
 	"github.com/metabbe3/go-backend/models"
 	"github.com/metabbe3/go-backend/repositories"
 	"github.com/stretchr/testify/mock"
@@ -9,20 +11,27 @@ import (
 // MockUserRepository implements UserRepositoryInterface
 type MockUserRepository struct {
 	mock.Mock
+	OrganizationID uint // Last organization the mock was scoped to
 }
 
 // Ensure MockUserRepository implements UserRepositoryInterface
 var _ repositories.UserRepositoryInterface = (*MockUserRepository)(nil)
 
+// ForOrganization records the organization and returns the same mock
+func (m *MockUserRepository) ForOrganization(orgID uint) repositories.UserRepositoryInterface {
+	m.OrganizationID = orgID
+	return m
+}
+
 // CreateUser mocks the CreateUser function
-func (m *MockUserRepository) CreateUser(user *models.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
 // FindByEmail mocks the FindByEmail function
-func (m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
-	args := m.Called(email)
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
 	// If the first return value is nil, return the error as the second return value
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -30,25 +39,34 @@ func (m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+// FindByID mocks the FindByID function
+func (m *MockUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 // UpdateUser mocks the UpdateUser function
-func (m *MockUserRepository) UpdateUser(user *models.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
 // DeleteUser mocks the DeleteUser function
-func (m *MockUserRepository) DeleteUser(id uint) error {
-	args := m.Called(id)
+func (m *MockUserRepository) DeleteUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
 // GetAllUsers mocks the GetAllUsers function
-func (m *MockUserRepository) GetAllUsers(limit, offset int) ([]models.User, int, error) {
-	args := m.Called(limit, offset)
+func (m *MockUserRepository) GetAllUsers(ctx context.Context, opts repositories.ListOptions) ([]models.User, repositories.PageResult, error) {
+	args := m.Called(ctx, opts)
 	// If the first return value is nil, return an empty slice and the error
 	if args.Get(0) == nil {
-		return nil, 0, args.Error(1)
+		return nil, repositories.PageResult{}, args.Error(1)
 	}
-	// Return the users, total count and error if applicable
-	return args.Get(0).([]models.User), args.Int(1), args.Error(2)
+	// Return the users, page information and error if applicable
+	return args.Get(0).([]models.User), args.Get(1).(repositories.PageResult), args.Error(2)
 }
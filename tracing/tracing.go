@@ -0,0 +1,74 @@
+// Package tracing sets up OpenTelemetry tracing: the tracer provider and its OTLP exporter,
+// W3C trace context propagation, and spans around GORM statements.
+//
+// Spans are started from the tracer provider of the span already in the context, so code
+// below the HTTP middleware needs no tracer of its own, and nothing is traced outside a
+// traced request.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName identifies the application's instrumentation
+const ScopeName = "github.com/metabbe3/go-backend"
+
+// Span exporters
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp" // OTLP over HTTP
+)
+
+// Options configures the tracer provider
+type Options struct {
+	ServiceName string
+	Environment string
+	Endpoint    string  // OTLP/HTTP endpoint URL, e.g. http://collector:4318; the exporter's default when empty
+	SampleRatio float64 // Fraction of new traces recorded; a sampled parent is always followed
+}
+
+// Propagator reads and writes the W3C traceparent and baggage headers
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// NewProvider creates a tracer provider that batches spans to the OTLP endpoint.
+// Shut it down to flush the remaining spans.
+func NewProvider(opts Options) (*sdktrace.TracerProvider, error) {
+	var exporterOpts []otlptracehttp.Option
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+	return NewProviderWithExporter(opts, sdktrace.WithBatcher(exporter)), nil
+}
+
+// NewProviderWithExporter creates a tracer provider sending spans through the given
+// processor option, e.g. sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) in tests
+func NewProviderWithExporter(opts Options, processor sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(opts.ServiceName),
+			attribute.String("deployment.environment", opts.Environment),
+		)),
+	)
+}
+
+// Start starts a span as a child of the span in ctx, using the same tracer provider.
+// Without a span in ctx the returned span records nothing.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(ScopeName)
+	return tracer.Start(ctx, name, opts...)
+}
@@ -0,0 +1,72 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metabbe3/go-backend/models"
+	"github.com/metabbe3/go-backend/test"
+	"github.com/metabbe3/go-backend/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// attributes indexes the attributes of a span by key
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestInstrumentDB(t *testing.T) {
+	db := test.NewTestDB(t)
+	require.NoError(t, tracing.InstrumentDB(db))
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProviderWithExporter(tracing.Options{ServiceName: "test", SampleRatio: 1}, sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	// Outside a traced request nothing is recorded
+	require.NoError(t, db.Create(&models.Organization{Name: "Untraced"}).Error)
+	assert.Empty(t, exporter.GetSpans())
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, db.WithContext(ctx).Create(&models.Organization{Name: "Acme"}).Error)
+	var organization models.Organization
+	require.Error(t, db.WithContext(ctx).Where("name = ?", "Nobody").First(&organization).Error)
+	require.Error(t, db.WithContext(ctx).Exec("SELECT * FROM missing_table").Error)
+	root.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+	tests := []struct {
+		name         string
+		table        string
+		statement    string
+		expectStatus codes.Code
+	}{
+		{name: "gorm.create", table: "organizations", statement: "INSERT INTO `organizations`", expectStatus: codes.Unset},
+		{name: "gorm.query", table: "organizations", statement: "SELECT * FROM `organizations` WHERE name = ?", expectStatus: codes.Unset}, // Not found is not a failure
+		{name: "gorm.raw", table: "", statement: "SELECT * FROM missing_table", expectStatus: codes.Error},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		assert.Equal(t, tt.name, span.Name)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Equal(t, root.SpanContext().SpanID(), span.Parent.SpanID(), tt.name)
+		assert.Equal(t, tt.expectStatus, span.Status.Code, tt.name)
+
+		attrs := attributes(span)
+		assert.Equal(t, "sqlite", attrs["db.system"].AsString())
+		assert.Equal(t, tt.table, attrs["db.collection.name"].AsString())
+		assert.Contains(t, attrs["db.query.text"].AsString(), tt.statement)
+		assert.NotContains(t, attrs["db.query.text"].AsString(), "Nobody", "values are not recorded")
+	}
+}
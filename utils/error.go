@@ -2,50 +2,132 @@ package utils
 
 import (
 	"errors"
-	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AppError structure for handling custom errors
+// Kinds of domain errors. An AppError matches its kind with errors.Is, e.g.
+// errors.Is(err, utils.ErrNotFound), whatever its message and code.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Error codes sent to clients in the error_code field. They are part of the API:
+// clients branch on them, so existing codes must never change meaning.
+const (
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeBadRequest   = "bad_request"         // Other 4xx responses
+	CodeUpstream     = "upstream_error"      // A provider such as WhatsApp failed
+	CodeUnavailable  = "service_unavailable" // A feature is disabled or a dependency is down
+	CodeInternal     = "internal_error"
+)
+
+// kindStatus is the HTTP status each kind of error is rendered with
+var kindStatus = map[error]int{
+	ErrNotFound:     http.StatusNotFound,
+	ErrConflict:     http.StatusConflict,
+	ErrValidation:   http.StatusBadRequest,
+	ErrUnauthorized: http.StatusUnauthorized,
+	ErrForbidden:    http.StatusForbidden,
+}
+
+// AppError is an error with a message that is safe to show to clients. Kind is one of
+// the Err* kinds above, or nil for internal errors; Err is the underlying cause, which is
+// logged but never sent.
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Kind    error
+	Code    string
+	Message string
+	Details interface{} // Sent as "errors", e.g. the fields that failed validation
+	Err     error
+}
+
+// NotFound returns an error for a resource that doesn't exist or isn't visible to the caller
+func NotFound(message string) *AppError {
+	return &AppError{Kind: ErrNotFound, Code: CodeNotFound, Message: message}
 }
 
-// NewError creates a new AppError
-func NewError(code int, message string) *AppError {
-	return &AppError{
-		Code:    code,
-		Message: message,
+// Conflict returns an error for a change that clashes with existing data, e.g. a duplicate email
+func Conflict(message string) *AppError {
+	return &AppError{Kind: ErrConflict, Code: CodeConflict, Message: message}
+}
+
+// Validation returns an error for invalid input, with details on what is wrong
+func Validation(message string, details interface{}) *AppError {
+	return &AppError{Kind: ErrValidation, Code: CodeValidation, Message: message, Details: details}
+}
+
+// Unauthorized returns an error for a missing or invalid credential
+func Unauthorized(message string) *AppError {
+	return &AppError{Kind: ErrUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+// Forbidden returns an error for an authenticated caller lacking the right to act
+func Forbidden(message string) *AppError {
+	return &AppError{Kind: ErrForbidden, Code: CodeForbidden, Message: message}
+}
+
+// Internal wraps an unexpected error with a message that is safe to show to clients.
+// Domain errors are returned unchanged, so their status and code survive.
+func Internal(err error, message string) error {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return err
 	}
+	return &AppError{Code: CodeInternal, Message: message, Err: err}
 }
 
-// Error implements the error interface
+// Wrap returns a copy of the error carrying cause as its underlying error
+func (e *AppError) Wrap(cause error) *AppError {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+// Error implements the error interface; the cause is included for logs
 func (e *AppError) Error() string {
-	return fmt.Sprintf("Error %d: %s", e.Code, e.Message)
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes the kind and the cause to errors.Is and errors.As
+func (e *AppError) Unwrap() []error {
+	var errs []error
+	for _, err := range []error{e.Kind, e.Err} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
 }
 
-// HandleError logs and responds with an error
+// Status is the HTTP status the error is rendered with
+func (e *AppError) Status() int {
+	if status, ok := kindStatus[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// HandleError responds with err in the format the client prefers. Domain errors keep their
+// status, code and message; anything else is hidden behind a generic 500 and logged.
 func HandleError(c *gin.Context, err error) {
 	var appErr *AppError
-	if errors.As(err, &appErr) {
-		// Custom application error
-		log.Printf("App Error: %s", appErr.Error())
-		c.JSON(appErr.Code, gin.H{
-			"success": false,
-			"message": appErr.Message,
-			"code":    appErr.Code,
-		})
-	} else {
-		// Generic internal server error
-		log.Printf("Internal Server Error: %s", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Internal Server Error",
-			"code":    http.StatusInternalServerError,
-		})
+	if !errors.As(err, &appErr) {
+		ErrorContext(c.Request.Context(), "Internal server error", "error", err)
+		appErr = &AppError{Code: CodeInternal, Message: "Internal Server Error", Err: err}
 	}
+
+	sendErrorResponse(c, appErr.Status(), appErr.Code, appErr.Message, appErr.Details)
 }
@@ -3,33 +3,51 @@ package utils
 import (
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWT Secret Key
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// jwtSecret signs access tokens; set it with SetJWTSecret at startup
+var jwtSecret []byte
+
+// SetJWTSecret sets the key access tokens are signed and verified with
+func SetJWTSecret(secret string) {
+	jwtSecret = []byte(secret)
+}
+
+// AccessTokenTTL is how long an access token stays valid. Access tokens are
+// kept short-lived; clients renew them with a refresh token.
+var AccessTokenTTL = 15 * time.Minute
 
 // Claims struct (Custom JWT Payload)
+// RegisteredClaims.ID carries the token's unique "jti", used to revoke a single token.
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
-	Role     string `json:"role"`
+	Role     string `json:"role"`   // Effective role within OrgID
+	OrgID    uint   `json:"org_id"` // Organization the token is scoped to (0 = none)
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a JWT token for authentication
-func GenerateToken(userID uint, username, role string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
+// GenerateToken creates a JWT token for authentication, scoped to an organization
+func GenerateToken(userID uint, username, role string, orgID uint) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
+
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
 
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
+		OrgID:    orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFileMode is the permission of log files and their backups: readable by the owner's group,
+// never by other users, since log lines carry emails and phone numbers
+const LogFileMode os.FileMode = 0640
+
+// logBackupTimeFormat names rotated files, e.g. app-20261017T153000.000.log; it sorts chronologically
+const logBackupTimeFormat = "20060102T150405.000"
+
+// RotationOptions configures log file rotation and retention. Zero values disable each rule.
+type RotationOptions struct {
+	MaxSize    int64         // Rotate before the file grows past this many bytes
+	Interval   time.Duration // Rotate at every multiple of this interval (UTC), e.g. 24h for midnight
+	Compress   bool          // Gzip rotated files
+	MaxBackups int           // Keep at most this many rotated files
+	MaxAge     time.Duration // Delete rotated files older than this
+}
+
+// RotatingFile is a log file that rotates itself by size and time. Rotated files are renamed
+// with a timestamp next to the original, then compressed and pruned in the background.
+type RotatingFile struct {
+	path string
+	opts RotationOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time // Zero without an interval
+
+	millMu sync.Mutex     // Serializes compression and pruning
+	mills  sync.WaitGroup // Background compression and pruning, waited for by Close
+}
+
+// OpenRotatingFile opens the file at path for appending, creating it and its directory if needed.
+// An existing file last written before the current interval started is rotated right away.
+func OpenRotatingFile(path string, opts RotationOptions) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+
+	r := &RotatingFile{path: path, opts: opts, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	info, err := r.file.Stat()
+	if err == nil && r.size > 0 && r.opts.Interval > 0 && info.ModTime().Before(r.rotateAt.Add(-r.opts.Interval)) {
+		err = r.rotate()
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p to the file, rotating first if p would take it past MaxSize or the interval has ended
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	sizeExceeded := r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize
+	intervalEnded := !r.rotateAt.IsZero() && !r.now().Before(r.rotateAt)
+	if sizeExceeded || intervalEnded {
+		if err := r.rotate(); err != nil {
+			// The log can't report its own failure; keep writing to the current file if possible
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			if r.file == nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Reopen closes and reopens the file at its path, so an external logrotate can move it away.
+// The application calls it on SIGHUP.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close flushes the file to disk, closes it and waits for background compression and pruning
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Sync()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file = nil
+	r.mills.Wait()
+	return err
+}
+
+// open opens or creates the file and schedules the next time-based rotation
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, LogFileMode)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	// Files created by older versions were world-readable
+	if info.Mode().Perm()&^LogFileMode != 0 {
+		if err := file.Chmod(LogFileMode); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	r.file, r.size = file, info.Size()
+	if r.opts.Interval > 0 {
+		r.rotateAt = r.now().UTC().Truncate(r.opts.Interval).Add(r.opts.Interval)
+	}
+	return nil
+}
+
+// rotate renames the current file to a timestamped backup and opens a new one.
+// The caller must hold r.mu.
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	rotatedAt := r.now()
+	backup := r.backupName(rotatedAt)
+	if err := os.Rename(r.path, backup); err != nil {
+		if openErr := r.open(); openErr != nil {
+			return fmt.Errorf("rotate log file: %w", openErr)
+		}
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	r.mills.Add(1)
+	go func() {
+		defer r.mills.Done()
+		r.mill(backup, rotatedAt)
+	}()
+	return nil
+}
+
+// mill compresses a fresh backup and deletes backups beyond the retention policy.
+// Failures are reported on stderr, since the log itself may be what is failing.
+func (r *RotatingFile) mill(backup string, rotatedAt time.Time) {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+
+	if r.opts.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "compress rotated log %s: %v\n", backup, err)
+		}
+	}
+	if err := r.prune(rotatedAt); err != nil {
+		fmt.Fprintf(os.Stderr, "prune rotated logs: %v\n", err)
+	}
+}
+
+// backupName is the name a file rotated at t is renamed to
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir, base := filepath.Split(r.path)
+	ext := filepath.Ext(base)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", strings.TrimSuffix(base, ext), t.UTC().Format(logBackupTimeFormat), ext))
+}
+
+// logBackup is a rotated file found on disk
+type logBackup struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// backups lists the rotated files of this log, newest first
+func (r *RotatingFile) backups() ([]logBackup, error) {
+	dir, base := filepath.Split(r.path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []logBackup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		rotatedAt, err := time.Parse(logBackupTimeFormat, stamp)
+		if err != nil {
+			continue // Not one of ours
+		}
+		backups = append(backups, logBackup{path: filepath.Join(dir, name), rotatedAt: rotatedAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotatedAt.After(backups[j].rotatedAt) })
+	return backups, nil
+}
+
+// prune deletes the backups beyond MaxBackups and those older than MaxAge at now
+func (r *RotatingFile) prune(now time.Time) error {
+	if r.opts.MaxBackups <= 0 && r.opts.MaxAge <= 0 {
+		return nil
+	}
+
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-r.opts.MaxAge)
+	var errs error
+	for i, backup := range backups {
+		tooMany := r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups
+		tooOld := r.opts.MaxAge > 0 && backup.rotatedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// compressFile gzips path into path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, LogFileMode)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestRotatingFile opens dir/app.log with a controllable clock
+func openTestRotatingFile(t *testing.T, dir string, opts RotationOptions, clock *time.Time) *RotatingFile {
+	t.Helper()
+	path := filepath.Join(dir, "app.log")
+	r := &RotatingFile{path: path, opts: opts, now: func() time.Time { return *clock }}
+	require.NoError(t, r.open())
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// listDir returns the names in dir, sorted
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	r := openTestRotatingFile(t, dir, RotationOptions{MaxSize: 10}, &clock)
+
+	_, err := r.Write([]byte("12345678\n"))
+	require.NoError(t, err)
+	clock = clock.Add(time.Second)
+	_, err = r.Write([]byte("abc\n")) // 13 bytes would exceed the limit
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, []string{"app-20261017T090001.000.log", "app.log"}, listDir(t, dir))
+	rotated, err := os.ReadFile(filepath.Join(dir, "app-20261017T090001.000.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "12345678\n", string(rotated))
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc\n", string(current))
+
+	info, err := os.Stat(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode().Perm()&0007, "log files are not readable by other users")
+}
+
+func TestRotatingFile_RotatesByIntervalAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)
+	r := openTestRotatingFile(t, dir, RotationOptions{Interval: 24 * time.Hour, Compress: true}, &clock)
+
+	_, err := r.Write([]byte("before midnight\n"))
+	require.NoError(t, err)
+	clock = clock.Add(time.Minute)
+	_, err = r.Write([]byte("after midnight\n"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close()) // Waits for compression
+
+	assert.Equal(t, []string{"app-20261018T000000.000.log.gz", "app.log"}, listDir(t, dir))
+	file, err := os.Open(filepath.Join(dir, "app-20261018T000000.000.log.gz"))
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "before midnight\n", string(content))
+}
+
+func TestRotatingFile_Retention(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   RotationOptions
+		expect []string
+	}{
+		{
+			name:   "Max Backups",
+			opts:   RotationOptions{MaxSize: 1, MaxBackups: 2},
+			expect: []string{"app-20261017T120003.000.log", "app-20261017T120004.000.log", "app.log", "notes.txt"},
+		},
+		{
+			name:   "Max Age",
+			opts:   RotationOptions{MaxSize: 1, MaxAge: 1500 * time.Millisecond},
+			expect: []string{"app-20261017T120003.000.log", "app-20261017T120004.000.log", "app.log", "notes.txt"},
+		},
+		{
+			name: "Unlimited",
+			opts: RotationOptions{MaxSize: 1},
+			expect: []string{"app-20261017T120001.000.log", "app-20261017T120002.000.log", "app-20261017T120003.000.log",
+				"app-20261017T120004.000.log", "app.log", "notes.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a log"), 0600))
+
+			clock := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+			r := openTestRotatingFile(t, dir, tt.opts, &clock)
+			for i := 0; i < 5; i++ {
+				_, err := r.Write([]byte("line\n"))
+				require.NoError(t, err)
+				clock = clock.Add(time.Second)
+			}
+			require.NoError(t, r.Close())
+
+			assert.Equal(t, tt.expect, listDir(t, dir))
+		})
+	}
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Now()
+	r := openTestRotatingFile(t, dir, RotationOptions{}, &clock)
+
+	_, err := r.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	// What logrotate does before sending SIGHUP
+	require.NoError(t, os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1")))
+	require.NoError(t, r.Reopen())
+	_, err = r.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	moved, err := os.ReadFile(filepath.Join(dir, "app.log.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(moved))
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(current))
+}
+
+func TestOpenRotatingFile_TightensPermissionsAndRotatesStaleFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("yesterday\n"), 0666))
+	require.NoError(t, os.Chmod(path, 0666))
+	yesterday := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(path, yesterday, yesterday))
+
+	r, err := OpenRotatingFile(path, RotationOptions{Interval: 24 * time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	names := listDir(t, dir)
+	require.Len(t, names, 2, "the stale file is rotated on open")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, LogFileMode, info.Mode().Perm())
+	assert.Zero(t, info.Size())
+}
@@ -1,49 +1,198 @@
 package utils
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"time"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
-var (
-	infoLogger    *log.Logger
-	warningLogger *log.Logger
-	errorLogger   *log.Logger
+// Log output formats
+const (
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
 )
 
-// InitLogger initializes loggers
-func InitLogger() {
-	logFile := "app.log"
+// LogOptions configures the output of a Logger
+type LogOptions struct {
+	Format   string          // json (the default) or logfmt
+	Level    slog.Level      // Lines below this level are dropped; the zero value is info
+	Rotation RotationOptions // Applies when logging to a file
+}
+
+// Logger writes leveled, structured log lines to one destination. Every method takes the
+// logging context, a message and alternating key-value pairs, as in log/slog:
+//
+//	logger.Info(ctx, "Customer created", "customer_id", customer.ID)
+type Logger struct {
+	slog *slog.Logger
+	file *RotatingFile // Destination opened by the logger, closed by Close
+}
+
+// defaultLogger backs the package-level functions; it writes to stdout until SetLogger replaces it
+var defaultLogger atomic.Pointer[Logger]
+
+func init() {
+	defaultLogger.Store(NewLogger(os.Stdout, LogOptions{}))
+}
+
+// NewLogger creates a logger writing to w
+func NewLogger(w io.Writer, opts LogOptions) *Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	if opts.Format == LogFormatLogfmt {
+		handler = slog.NewTextHandler(w, handlerOpts)
+	} else {
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	}
+	return &Logger{slog: slog.New(contextHandler{handler})}
+}
+
+// OpenLogger creates a logger appending to the file at path, rotated per opts.Rotation,
+// or writing to stdout if path is empty
+func OpenLogger(path string, opts LogOptions) (*Logger, error) {
+	if path == "" {
+		return NewLogger(os.Stdout, opts), nil
+	}
 
-	// Open or create log file
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	file, err := OpenRotatingFile(path, opts.Rotation)
 	if err != nil {
-		log.Fatalf("Failed to open log file: %v", err)
+		return nil, err
 	}
+	logger := NewLogger(file, opts)
+	logger.file = file
+	return logger, nil
+}
+
+// ParseLogLevel parses debug, info, warn (or warning) or error
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", level)
+}
 
-	// Set up loggers
-	infoLogger = log.New(file, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	warningLogger = log.New(file, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	errorLogger = log.New(file, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+// SetLogger makes the package-level logging functions write to logger
+func SetLogger(logger *Logger) {
+	defaultLogger.Store(logger)
+}
+
+// Debug logs diagnostic messages
+func (l *Logger) Debug(ctx context.Context, message string, args ...any) {
+	l.slog.DebugContext(ctx, message, args...)
 }
 
 // Info logs informational messages
-func Info(message string) {
-	infoLogger.Println(formatLogMessage("INFO", message))
+func (l *Logger) Info(ctx context.Context, message string, args ...any) {
+	l.slog.InfoContext(ctx, message, args...)
 }
 
 // Warning logs warning messages
-func Warning(message string) {
-	warningLogger.Println(formatLogMessage("WARNING", message))
+func (l *Logger) Warning(ctx context.Context, message string, args ...any) {
+	l.slog.WarnContext(ctx, message, args...)
 }
 
 // Error logs error messages
-func Error(message string) {
-	errorLogger.Println(formatLogMessage("ERROR", message))
+func (l *Logger) Error(ctx context.Context, message string, args ...any) {
+	l.slog.ErrorContext(ctx, message, args...)
+}
+
+// Reopen reopens the log file at its path after an external tool has moved it, if the logger has one
+func (l *Logger) Reopen() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Reopen()
+}
+
+// Close flushes the log file the logger opened to disk and closes it, if any
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Info logs informational messages outside of a request
+func Info(message string, args ...any) {
+	defaultLogger.Load().Info(context.Background(), message, args...)
+}
+
+// Warning logs warning messages outside of a request
+func Warning(message string, args ...any) {
+	defaultLogger.Load().Warning(context.Background(), message, args...)
+}
+
+// Error logs error messages outside of a request
+func Error(message string, args ...any) {
+	defaultLogger.Load().Error(context.Background(), message, args...)
+}
+
+// DebugContext logs diagnostic messages, tagged with the request ID carried by ctx
+func DebugContext(ctx context.Context, message string, args ...any) {
+	defaultLogger.Load().Debug(ctx, message, args...)
+}
+
+// InfoContext logs informational messages, tagged with the request ID carried by ctx
+func InfoContext(ctx context.Context, message string, args ...any) {
+	defaultLogger.Load().Info(ctx, message, args...)
+}
+
+// WarningContext logs warning messages, tagged with the request ID carried by ctx
+func WarningContext(ctx context.Context, message string, args ...any) {
+	defaultLogger.Load().Warning(ctx, message, args...)
+}
+
+// ErrorContext logs error messages, tagged with the request ID carried by ctx
+func ErrorContext(ctx context.Context, message string, args ...any) {
+	defaultLogger.Load().Error(ctx, message, args...)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID, which is added to every line logged with it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request ID and trace of the logging context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
 }
 
-// formatLogMessage adds a timestamp to the log message
-func formatLogMessage(level, message string) string {
-	return time.Now().Format("2006-01-02 15:04:05") + " [" + level + "] " + message
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Permissions are written as "<resource>:<action>"
+const (
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermUsersDelete     = "users:delete"
+	PermCustomersRead   = "customers:read"
+	PermCustomersWrite  = "customers:write"
+	PermCustomersDelete = "customers:delete"
+	PermRolesManage     = "roles:manage"
+	PermMembersManage   = "members:manage"
+	PermMessagesRead    = "messages:read"
+	PermMessagesSend    = "messages:send"
+)
+
+// Built-in role names. "admin" is a platform-wide role held through User.Role;
+// "owner" and "user" are granted per organization through memberships.
+const (
+	RoleAdmin = "admin"
+	RoleOwner = "owner"
+	RoleUser  = "user"
+)
+
+// AllPermissions lists every permission known to the application
+var AllPermissions = []string{
+	PermUsersRead,
+	PermUsersWrite,
+	PermUsersDelete,
+	PermCustomersRead,
+	PermCustomersWrite,
+	PermCustomersDelete,
+	PermRolesManage,
+	PermMembersManage,
+	PermMessagesRead,
+	PermMessagesSend,
+}
+
+// DefaultRolePermissions are the roles seeded on first start
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: AllPermissions,
+	RoleOwner: {PermUsersRead, "customers:*", "messages:*", PermMembersManage},
+	RoleUser:  {PermCustomersRead, PermCustomersWrite, PermMessagesRead, PermMessagesSend},
+}
+
+// IsKnownPermission reports whether name is a permission the application checks,
+// or a wildcard ("*", "<resource>:*") covering some of them
+func IsKnownPermission(name string) bool {
+	if name == "*" {
+		return true
+	}
+	for _, p := range AllPermissions {
+		if p == name || (strings.HasSuffix(name, ":*") && strings.HasPrefix(p, strings.TrimSuffix(name, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSource loads the permissions granted to a role
+type PermissionSource interface {
+	PermissionsForRole(ctx context.Context, role string) ([]string, error)
+}
+
+// PolicyEngine decides whether a role holds a set of permissions.
+// Role permissions are cached for ttl so checks don't hit the database on every request.
+type PolicyEngine struct {
+	source PermissionSource
+	ttl    time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cachedPermissions
+}
+
+type cachedPermissions struct {
+	permissions map[string]struct{}
+	loadedAt    time.Time
+}
+
+// NewPolicyEngine creates a PolicyEngine backed by source
+func NewPolicyEngine(source PermissionSource, ttl time.Duration) *PolicyEngine {
+	return &PolicyEngine{
+		source: source,
+		ttl:    ttl,
+		cache:  make(map[string]cachedPermissions),
+	}
+}
+
+// Can reports whether role holds every one of the required permissions.
+// A granted "<resource>:*" covers all actions on that resource and "*" covers everything.
+func (p *PolicyEngine) Can(ctx context.Context, role string, required ...string) (bool, error) {
+	granted, err := p.permissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+
+	for _, perm := range required {
+		if !grants(granted, perm) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Invalidate drops the cached permissions of a role, e.g. after they were changed
+func (p *PolicyEngine) Invalidate(role string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.cache, role)
+}
+
+// permissions returns the cached permission set of a role, loading it when stale
+func (p *PolicyEngine) permissions(ctx context.Context, role string) (map[string]struct{}, error) {
+	p.mu.RLock()
+	entry, ok := p.cache[role]
+	p.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < p.ttl {
+		return entry.permissions, nil
+	}
+
+	names, err := p.source.PermissionsForRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+
+	p.mu.Lock()
+	p.cache[role] = cachedPermissions{permissions: set, loadedAt: time.Now()}
+	p.mu.Unlock()
+
+	return set, nil
+}
+
+// Grants reports whether a list of granted permissions covers perm, honouring wildcards
+func Grants(permissions []string, perm string) bool {
+	set := make(map[string]struct{}, len(permissions))
+	for _, name := range permissions {
+		set[name] = struct{}{}
+	}
+	return grants(set, perm)
+}
+
+// grants checks a permission against a granted set, honouring wildcards
+func grants(granted map[string]struct{}, perm string) bool {
+	if _, ok := granted["*"]; ok {
+		return true
+	}
+	if _, ok := granted[perm]; ok {
+		return true
+	}
+	if i := strings.Index(perm, ":"); i > 0 {
+		_, ok := granted[perm[:i]+":*"]
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePermissionSource serves fixed role permissions and counts the loads
+type fakePermissionSource struct {
+	roles map[string][]string
+	err   error
+	loads int
+}
+
+func (s *fakePermissionSource) PermissionsForRole(_ context.Context, role string) ([]string, error) {
+	s.loads++
+	return s.roles[role], s.err
+}
+
+func TestPolicyEngine_Can(t *testing.T) {
+	source := &fakePermissionSource{roles: map[string][]string{
+		"admin":   {"*"},
+		"owner":   {"customers:*", PermMembersManage},
+		"user":    {PermCustomersRead, PermCustomersWrite},
+		"partial": {"customers"}, // Not a wildcard: no ":*" suffix
+	}}
+	policy := NewPolicyEngine(source, time.Hour)
+
+	tests := []struct {
+		name     string
+		role     string
+		required []string
+		expect   bool
+	}{
+		{name: "Global Wildcard", role: "admin", required: []string{PermRolesManage, PermUsersDelete}, expect: true},
+		{name: "Resource Wildcard", role: "owner", required: []string{PermCustomersDelete}, expect: true},
+		{name: "Resource Wildcard Stays On Its Resource", role: "owner", required: []string{PermUsersWrite}, expect: false},
+		{name: "Exact Permission", role: "user", required: []string{PermCustomersRead}, expect: true},
+		{name: "Every Permission Required", role: "user", required: []string{PermCustomersRead, PermCustomersDelete}, expect: false},
+		{name: "Bare Resource Grants Nothing", role: "partial", required: []string{PermCustomersRead}, expect: false},
+		{name: "Unknown Role", role: "guest", required: []string{PermCustomersRead}, expect: false},
+		{name: "Nothing Required", role: "guest", expect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := policy.Can(context.Background(), tt.role, tt.required...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, allowed)
+		})
+	}
+}
+
+func TestPolicyEngine_Cache(t *testing.T) {
+	source := &fakePermissionSource{roles: map[string][]string{"user": {PermCustomersRead}}}
+	policy := NewPolicyEngine(source, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		allowed, err := policy.Can(context.Background(), "user", PermCustomersRead)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, 1, source.loads, "permissions are loaded once within the TTL")
+
+	// Changes apply after Invalidate
+	source.roles["user"] = nil
+	policy.Invalidate("user")
+	allowed, err := policy.Can(context.Background(), "user", PermCustomersRead)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 2, source.loads)
+
+	// ...or once the TTL has passed
+	expiring := NewPolicyEngine(source, time.Millisecond)
+	_, err = expiring.Can(context.Background(), "user")
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = expiring.Can(context.Background(), "user")
+	require.NoError(t, err)
+	assert.Equal(t, 4, source.loads)
+
+	// Load errors are returned and not cached
+	source.err = errors.New("connection refused")
+	failing := NewPolicyEngine(source, time.Hour)
+	_, err = failing.Can(context.Background(), "user")
+	assert.ErrorContains(t, err, "connection refused")
+	source.err = nil
+	_, err = failing.Can(context.Background(), "user")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// DefaultPhoneRegion is the ISO 3166 region assumed for phone numbers written without
+// a country code ("0812..."). Set it with SetDefaultPhoneRegion at startup.
+var DefaultPhoneRegion = "ID"
+
+// ErrInvalidPhone is returned for input that is not a valid phone number
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// IsKnownPhoneRegion reports whether region is an ISO 3166 region with a calling code
+func IsKnownPhoneRegion(region string) bool {
+	return phonenumbers.GetCountryCodeForRegion(strings.ToUpper(strings.TrimSpace(region))) != 0
+}
+
+// SetDefaultPhoneRegion changes DefaultPhoneRegion, rejecting regions without a calling code
+func SetDefaultPhoneRegion(region string) error {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if !IsKnownPhoneRegion(region) {
+		return fmt.Errorf("unknown phone region %q", region)
+	}
+	DefaultPhoneRegion = region
+	return nil
+}
+
+// NormalizePhone parses a phone number in DefaultPhoneRegion and returns it in E.164 form
+// ("0812-3456-7890", "+62 812 3456 7890" and "6281234567890" all become "+6281234567890")
+func NormalizePhone(raw string) (string, error) {
+	return NormalizePhoneInRegion(raw, DefaultPhoneRegion)
+}
+
+// NormalizePhoneInRegion is NormalizePhone with an explicit default region
+func NormalizePhoneInRegion(raw, region string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: phone is empty", ErrInvalidPhone)
+	}
+
+	number, err := phonenumbers.Parse(raw, region)
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		// Numbers stored without "+" but with the country code, as WhatsApp reports them
+		if !strings.HasPrefix(raw, "+") {
+			if withPlus, plusErr := phonenumbers.Parse("+"+raw, region); plusErr == nil && phonenumbers.IsValidNumber(withPlus) {
+				return phonenumbers.Format(withPlus, phonenumbers.E164), nil
+			}
+		}
+		return "", fmt.Errorf("%w: %q", ErrInvalidPhone, raw)
+	}
+
+	return phonenumbers.Format(number, phonenumbers.E164), nil
+}
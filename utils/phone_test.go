@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		input  string
+		region string
+		expect string
+	}{
+		{"0812-3456-7890", "ID", "+6281234567890"},
+		{"+62 812 3456 7890", "ID", "+6281234567890"},
+		{"6281234567890", "ID", "+6281234567890"},
+		{"(415) 555-2671", "US", "+14155552671"},
+		{"+62 812 3456 7890", "US", "+6281234567890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			normalized, err := NormalizePhoneInRegion(tt.input, tt.region)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, normalized)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error response formats
+const (
+	ErrorFormatEnvelope = "envelope" // The Response envelope
+	ErrorFormatProblem  = "problem"  // RFC 7807 problem details
+)
+
+// Media types error responses are negotiated between
+const (
+	MIMEProblemJSON = "application/problem+json"
+	mimeJSON        = "application/json"
+)
+
+// problemType is the type of every problem document; clients tell problems apart by error_code
+const problemType = "about:blank"
+
+// errorFormat is used when the Accept header asks for neither format
+var errorFormat = ErrorFormatEnvelope
+
+// SetErrorFormat sets the format of error responses to clients that don't ask for one
+func SetErrorFormat(format string) {
+	errorFormat = format
+}
+
+// Problem is an RFC 7807 problem details document. ErrorCode and Errors are extension
+// members carrying the stable error code and, for validation failures, what is wrong.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	ErrorCode string       `json:"error_code,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one failed validation: the JSON path of the field, the rule it broke (a
+// binding tag such as "required" or "phone") and a message in the client's language.
+// Field and Rule are empty when the error isn't about a single field.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// sendErrorResponse writes an error as a problem document or a Response envelope,
+// whichever the client prefers
+func sendErrorResponse(c *gin.Context, status int, errorCode, message string, details interface{}) {
+	c.Writer.Header().Add("Vary", "Accept, Accept-Language")
+
+	acceptLanguage := c.GetHeader("Accept-Language")
+	if !wantsProblem(c.GetHeader("Accept")) {
+		c.JSON(status, Response{
+			Success:   false,
+			Message:   message,
+			Code:      status,
+			ErrorCode: errorCode,
+			Errors:    validationDetails(details, acceptLanguage),
+		})
+		return
+	}
+
+	c.Header("Content-Type", MIMEProblemJSON)
+	c.JSON(status, Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    message,
+		Instance:  c.Request.URL.Path,
+		ErrorCode: errorCode,
+		Errors:    fieldErrors(details, acceptLanguage),
+	})
+}
+
+// wantsProblem reports whether an Accept header prefers problem documents to the envelope.
+// Only the two JSON media types are compared; wildcards leave the choice to the configured format.
+func wantsProblem(accept string) bool {
+	problem, plain := acceptQuality(accept, MIMEProblemJSON), acceptQuality(accept, mimeJSON)
+	switch {
+	case problem > 0 && problem >= plain:
+		return true
+	case plain > 0 || problem == 0:
+		return false
+	}
+	return errorFormat == ErrorFormatProblem
+}
+
+// acceptRange is one entry of an Accept or Accept-Language header
+type acceptRange struct {
+	value   string
+	quality float64
+}
+
+// parseAcceptHeader returns the entries of an Accept or Accept-Language header, most preferred first
+func parseAcceptHeader(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, entry := range strings.Split(header, ",") {
+		params := strings.Split(entry, ";")
+		value := strings.TrimSpace(params[0])
+		if value == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			name, q, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{value: value, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+	return ranges
+}
+
+// acceptQuality returns the q value an Accept header gives a media type, or -1 if it isn't listed
+func acceptQuality(accept, mediaType string) float64 {
+	for _, mediaRange := range parseAcceptHeader(accept) {
+		if strings.EqualFold(mediaRange.value, mediaType) {
+			return mediaRange.quality
+		}
+	}
+	return -1
+}
+
+// validationDetails returns the details of an error response: the failed fields of a
+// validator error, the message of any other error, and anything else as it is
+func validationDetails(details interface{}, acceptLanguage string) interface{} {
+	err, ok := details.(error)
+	if !ok {
+		return details
+	}
+	if fields := ValidationFieldErrors(err, acceptLanguage); fields != nil {
+		return fields
+	}
+	return err.Error()
+}
+
+// fieldErrors lists the details of a problem document as field errors; details that
+// aren't about fields become a single error without a field
+func fieldErrors(details interface{}, acceptLanguage string) []FieldError {
+	switch d := validationDetails(details, acceptLanguage).(type) {
+	case nil:
+		return nil
+	case []FieldError:
+		return d
+	case string:
+		return []FieldError{{Message: d}}
+	default:
+		return []FieldError{{Message: fmt.Sprint(d)}}
+	}
+}
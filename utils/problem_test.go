@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWantsProblem(t *testing.T) {
+	tests := []struct {
+		name          string
+		accept        string
+		defaultFormat string
+		expect        bool
+	}{
+		{name: "Problem Requested", accept: "application/problem+json", defaultFormat: ErrorFormatEnvelope, expect: true},
+		{name: "Problem Preferred", accept: "application/json;q=0.5, application/problem+json", defaultFormat: ErrorFormatEnvelope, expect: true},
+		{name: "JSON Preferred", accept: "application/problem+json;q=0.5, application/json", defaultFormat: ErrorFormatProblem, expect: false},
+		{name: "JSON Only", accept: "application/json", defaultFormat: ErrorFormatProblem, expect: false},
+		{name: "Problem Refused", accept: "application/problem+json;q=0", defaultFormat: ErrorFormatProblem, expect: false},
+		{name: "Wildcard Uses Default Envelope", accept: "*/*", defaultFormat: ErrorFormatEnvelope, expect: false},
+		{name: "Wildcard Uses Default Problem", accept: "*/*", defaultFormat: ErrorFormatProblem, expect: true},
+		{name: "No Header Uses Default", accept: "", defaultFormat: ErrorFormatProblem, expect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetErrorFormat(tt.defaultFormat)
+			defer SetErrorFormat(ErrorFormatEnvelope)
+
+			assert.Equal(t, tt.expect, wantsProblem(tt.accept))
+		})
+	}
+}
+
+func TestSendValidationError_Problem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		Email string `json:"email" binding:"required,email"`
+		Name  string `json:"name" binding:"required"`
+	}
+	invalid := binding.Validator.ValidateStruct(request{Email: "not-an-email"})
+	require.Error(t, invalid)
+
+	tests := []struct {
+		name         string
+		details      interface{}
+		expectFields []FieldError
+	}{
+		{
+			name:    "Validator Error",
+			details: invalid,
+			expectFields: []FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+				{Field: "name", Rule: "required", Message: "name is a required field"},
+			},
+		},
+		{
+			name:         "Other Error",
+			details:      errors.New("invalid dry_run"),
+			expectFields: []FieldError{{Message: "invalid dry_run"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/customer", nil)
+			c.Request.Header.Set("Accept", MIMEProblemJSON)
+
+			SendValidationError(c, "Invalid request data", tt.details)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept, Accept-Language", w.Header().Get("Vary"))
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, Problem{
+				Type:      "about:blank",
+				Title:     "Bad Request",
+				Status:    http.StatusBadRequest,
+				Detail:    "Invalid request data",
+				Instance:  "/api/customer",
+				ErrorCode: CodeValidation,
+				Errors:    tt.expectFields,
+			}, problem)
+		})
+	}
+}
+
+func TestSendError_Codes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		send       func(c *gin.Context)
+		expectCode string
+	}{
+		{name: "Bad Request", send: func(c *gin.Context) { SendBadRequest(c, "Failed to read request body") }, expectCode: CodeBadRequest},
+		{name: "Validation Error", send: func(c *gin.Context) { SendValidationError(c, "Invalid request data", nil) }, expectCode: CodeValidation},
+		{name: "Unauthorized", send: func(c *gin.Context) { SendUnauthorized(c, "Invalid signature") }, expectCode: CodeUnauthorized},
+		{name: "Other 4xx", send: func(c *gin.Context) { SendError(c, "Too large", http.StatusRequestEntityTooLarge) }, expectCode: CodeBadRequest},
+		{name: "Server Error", send: func(c *gin.Context) { SendError(c, "Failed", http.StatusGatewayTimeout) }, expectCode: CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/webhooks/whatsapp", nil)
+
+			tt.send(c)
+
+			var response Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectCode, response.ErrorCode)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token stays valid before the user must log in again
+var RefreshTokenTTL = 30 * 24 * time.Hour
+
+// GenerateRefreshToken creates a new opaque refresh token and returns it together with its hash.
+// Only the hash is meant to be stored; the plain token is handed to the client once.
+func GenerateRefreshToken() (string, string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the SHA-256 hex digest of a refresh token
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenFamilyID creates a random identifier for a refresh token family
+func NewTokenFamilyID() (string, error) {
+	return randomHex(16)
+}
+
+// NewTokenID creates a random identifier used as an access token's jti
+func NewTokenID() (string, error) {
+	return randomHex(16)
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// randomString returns n random bytes encoded as URL-safe base64
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
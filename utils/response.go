@@ -6,12 +6,24 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Response format structure
+// Response format structure. Error responses carry a stable ErrorCode (see the Code* constants)
+// and, for validation errors, the details of what is wrong. See Problem for the RFC 7807 format.
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Code    int         `json:"code"`
-	Data    interface{} `json:"data,omitempty"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Code      int         `json:"code"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Errors    interface{} `json:"errors,omitempty"`
+}
+
+// Page is the data of a list response. Offset-paginated lists carry the total count;
+// cursor-paginated lists carry opaque cursors for the neighbouring pages.
+type Page struct {
+	Data       interface{} `json:"data"`
+	TotalCount *int64      `json:"total_count,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
 }
 
 // SendSuccess sends a success response
@@ -34,66 +46,64 @@ func SendCreated(c *gin.Context, message string, data interface{}) {
 	})
 }
 
-// SendError sends an error response with a given status code
+// SendError sends an error response with a given status code, as a problem document
+// to clients that prefer application/problem+json
 func SendError(c *gin.Context, message string, statusCode int) {
-	c.JSON(statusCode, Response{
-		Success: false,
-		Message: message,
-		Code:    statusCode,
-	})
+	sendErrorResponse(c, statusCode, errorCodeForStatus(statusCode), message, nil)
 }
 
-// SendValidationError sends a validation error response
+// SendValidationError sends a validation error response. errors is usually the error of a
+// failed binding or parse; a validator error is sent as a list of FieldError, with messages
+// in the language of the Accept-Language header.
 func SendValidationError(c *gin.Context, message string, errors interface{}) {
-	c.JSON(http.StatusBadRequest, gin.H{
-		"success": false,
-		"message": message,
-		"code":    http.StatusBadRequest,
-		"errors":  errors,
-	})
+	sendErrorResponse(c, http.StatusBadRequest, CodeValidation, message, errors)
 }
 
 // SendUnauthorized sends a 401 Unauthorized response
 func SendUnauthorized(c *gin.Context, message string) {
-	c.JSON(http.StatusUnauthorized, Response{
-		Success: false,
-		Message: message,
-		Code:    http.StatusUnauthorized,
-	})
+	SendError(c, message, http.StatusUnauthorized)
 }
 
 // SendForbidden sends a 403 Forbidden response
 func SendForbidden(c *gin.Context, message string) {
-	c.JSON(http.StatusForbidden, Response{
-		Success: false,
-		Message: message,
-		Code:    http.StatusForbidden,
-	})
+	SendError(c, message, http.StatusForbidden)
 }
 
 // SendNotFound sends a 404 Not Found response
 func SendNotFound(c *gin.Context, message string) {
-	c.JSON(http.StatusNotFound, Response{
-		Success: false,
-		Message: message,
-		Code:    http.StatusNotFound,
-	})
+	SendError(c, message, http.StatusNotFound)
 }
 
 // SendInternalServerError sends a 500 Internal Server Error response
 func SendInternalServerError(c *gin.Context, message string) {
-	c.JSON(http.StatusInternalServerError, Response{
-		Success: false,
-		Message: message,
-		Code:    http.StatusInternalServerError,
-	})
+	SendError(c, message, http.StatusInternalServerError)
 }
 
 // SendBadRequest sends a 400 Bad Request response
 func SendBadRequest(c *gin.Context, message string) {
-	c.JSON(http.StatusBadRequest, Response{
-		Success: false,
-		Message: message,
-		Code:    http.StatusBadRequest,
-	})
+	SendError(c, message, http.StatusBadRequest)
+}
+
+// errorCodeForStatus is the error code of a response built from a bare HTTP status.
+// A bare 400 is bad_request: validation_failed is kept for SendValidationError and
+// validation errors, which carry the details of what failed.
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusBadGateway:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
 }
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationStore keeps track of access tokens that must no longer be accepted.
+// Single tokens are revoked by their jti; "logout everywhere" revokes every
+// token a user was issued before a point in time.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userID uint, revokedAt time.Time) error
+	UserTokensRevokedAt(ctx context.Context, userID uint) (time.Time, error) // Zero time if never revoked
+}
+
+// IsRevoked checks the token's jti and the user-wide revocation time against the store
+func IsRevoked(ctx context.Context, store RevocationStore, claims *Claims) (bool, error) {
+	if claims.ID != "" {
+		revoked, err := store.IsTokenRevoked(ctx, claims.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	revokedAt, err := store.UserTokensRevokedAt(ctx, claims.UserID)
+	if err != nil || revokedAt.IsZero() {
+		return false, err
+	}
+
+	// JWT timestamps have second precision, so compare against the revocation second
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Time.Before(revokedAt.Truncate(time.Second)), nil
+}
+
+// MemoryRevocationStore is an in-process RevocationStore. Entries are evicted
+// once the tokens they refer to would have expired anyway, so memory stays
+// bounded by the number of tokens revoked within one token lifetime.
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time // jti -> token expiry
+	users  map[uint]time.Time   // userID -> revoked at
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewMemoryRevocationStore creates a MemoryRevocationStore that evicts expired entries every cleanupInterval
+func NewMemoryRevocationStore(cleanupInterval time.Duration) *MemoryRevocationStore {
+	store := &MemoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uint]time.Time),
+		stop:   make(chan struct{}),
+	}
+
+	go store.cleanupLoop(cleanupInterval)
+	return store
+}
+
+// RevokeToken marks a single token as revoked until it expires
+func (s *MemoryRevocationStore) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+// IsTokenRevoked reports whether the token with the given jti was revoked
+func (s *MemoryRevocationStore) IsTokenRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.tokens[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// RevokeUserTokens revokes every token issued to the user before revokedAt
+func (s *MemoryRevocationStore) RevokeUserTokens(_ context.Context, userID uint, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = revokedAt
+	return nil
+}
+
+// UserTokensRevokedAt returns when the user's tokens were last revoked
+func (s *MemoryRevocationStore) UserTokensRevokedAt(_ context.Context, userID uint) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[userID], nil
+}
+
+// Close stops the background cleanup
+func (s *MemoryRevocationStore) Close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// cleanupLoop periodically evicts entries that can no longer match a valid token
+func (s *MemoryRevocationStore) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.evictExpired(time.Now())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// evictExpired removes revoked tokens past their expiry and user revocations older than a token lifetime
+func (s *MemoryRevocationStore) evictExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jti, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, revokedAt := range s.users {
+		if now.Sub(revokedAt) > AccessTokenTTL {
+			delete(s.users, userID)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// Languages validation messages are translated to; English is the fallback
+const (
+	LanguageEnglish    = "en"
+	LanguageIndonesian = "id"
+)
+
+// validationRule is a validation tag added to gin's validator, with its message in each
+// language; {0} in a message is the JSON name of the field
+type validationRule struct {
+	validate validator.Func
+	messages map[string]string
+}
+
+// validationRules are the policies request structs can name in their binding tags
+var validationRules = map[string]validationRule{
+	"phone": {
+		validate: func(fl validator.FieldLevel) bool {
+			_, err := NormalizePhone(fl.Field().String())
+			return err == nil
+		},
+		messages: map[string]string{
+			LanguageEnglish:    "{0} must be a valid phone number",
+			LanguageIndonesian: "{0} harus berupa nomor telepon yang valid",
+		},
+	},
+	"password": {
+		validate: func(fl validator.FieldLevel) bool {
+			return IsValidPassword(fl.Field().String())
+		},
+		messages: map[string]string{
+			LanguageEnglish:    "{0} must be at least 8 characters and include an uppercase letter and a number",
+			LanguageIndonesian: "{0} minimal 8 karakter dan harus memuat huruf besar dan angka",
+		},
+	},
+}
+
+// translators holds the validation messages of every language
+var translators *ut.UniversalTranslator
+
+// Registered on gin's validator at startup, so every binding, handler tests included, knows
+// the custom rules and reports fields by their JSON names
+func init() {
+	if err := registerValidation(binding.Validator.Engine().(*validator.Validate)); err != nil {
+		panic("register validation rules: " + err.Error())
+	}
+}
+
+// registerValidation adds the custom rules and the message catalogs to v
+func registerValidation(v *validator.Validate) error {
+	v.RegisterTagNameFunc(jsonFieldName)
+
+	english := en.New()
+	translators = ut.New(english, english, id.New())
+	catalogs := map[string]func(*validator.Validate, ut.Translator) error{
+		LanguageEnglish:    enTranslations.RegisterDefaultTranslations,
+		LanguageIndonesian: idTranslations.RegisterDefaultTranslations,
+	}
+	for language, register := range catalogs {
+		translator, _ := translators.GetTranslator(language)
+		if err := register(v, translator); err != nil {
+			return err
+		}
+	}
+
+	for tag, rule := range validationRules {
+		if err := v.RegisterValidation(tag, rule.validate); err != nil {
+			return err
+		}
+		for language, message := range rule.messages {
+			translator, _ := translators.GetTranslator(language)
+			addMessage := func(t ut.Translator) error { return t.Add(tag, message, false) }
+			if err := v.RegisterTranslation(tag, translator, addMessage, translateRule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFieldName names struct fields in validation errors as clients send them: by their
+// json tag, or their form tag for query parameters
+func jsonFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// translateRule renders the message of a custom rule
+func translateRule(t ut.Translator, fe validator.FieldError) string {
+	message, err := t.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
+
+// ValidationFieldErrors lists the fields a validator error rejects, with messages in the
+// language of an Accept-Language header. It returns nil for other errors.
+func ValidationFieldErrors(err error, acceptLanguage string) []FieldError {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+
+	translator := translatorFor(acceptLanguage)
+	fields := make([]FieldError, 0, len(invalid))
+	for _, fe := range invalid {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: fe.Translate(translator),
+		})
+	}
+	return fields
+}
+
+// fieldPath is the JSON path of a field without the request struct, e.g. "components[0].type"
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// translatorFor picks the most preferred supported language of an Accept-Language header,
+// matching on the primary subtag ("id-ID" is Indonesian)
+func translatorFor(acceptLanguage string) ut.Translator {
+	for _, language := range parseAcceptHeader(acceptLanguage) {
+		if language.quality <= 0 {
+			continue
+		}
+		base, _, _ := strings.Cut(strings.ToLower(language.value), "-")
+		if translator, found := translators.GetTranslator(base); found {
+			return translator
+		}
+	}
+	translator, _ := translators.GetTranslator(LanguageEnglish)
+	return translator
+}
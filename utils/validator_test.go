@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationFieldErrors(t *testing.T) {
+	type contact struct {
+		Phone string `json:"phone" binding:"required,phone"`
+	}
+	type request struct {
+		Email    string    `json:"email" binding:"required,email"`
+		Password string    `json:"password" binding:"required,password"`
+		Contacts []contact `json:"contacts" binding:"dive"`
+		Page     int       `form:"page" binding:"min=1"`
+	}
+	invalid := binding.Validator.ValidateStruct(request{
+		Password: "password",
+		Contacts: []contact{{Phone: "+6281234567890"}, {Phone: "call me"}},
+	})
+	require.Error(t, invalid)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expect         []FieldError
+	}{
+		{
+			name:           "English",
+			acceptLanguage: "en-US,en;q=0.9",
+			expect: []FieldError{
+				{Field: "email", Rule: "required", Message: "email is a required field"},
+				{Field: "password", Rule: "password", Message: "password must be at least 8 characters and include an uppercase letter and a number"},
+				{Field: "contacts[1].phone", Rule: "phone", Message: "phone must be a valid phone number"},
+				{Field: "page", Rule: "min", Message: "page must be 1 or greater"},
+			},
+		},
+		{
+			name:           "Indonesian",
+			acceptLanguage: "id-ID,id;q=0.9,en;q=0.8",
+			expect: []FieldError{
+				{Field: "email", Rule: "required", Message: "email wajib diisi"},
+				{Field: "password", Rule: "password", Message: "password minimal 8 karakter dan harus memuat huruf besar dan angka"},
+				{Field: "contacts[1].phone", Rule: "phone", Message: "phone harus berupa nomor telepon yang valid"},
+				{Field: "page", Rule: "min", Message: "page harus 1 atau lebih besar"},
+			},
+		},
+		{
+			name:           "Preferred Language Unsupported",
+			acceptLanguage: "fr-FR, id;q=0.5",
+			expect: []FieldError{
+				{Field: "email", Rule: "required", Message: "email wajib diisi"},
+			},
+		},
+		{
+			name:           "Fallback To English",
+			acceptLanguage: "fr-FR",
+			expect: []FieldError{
+				{Field: "email", Rule: "required", Message: "email is a required field"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := ValidationFieldErrors(invalid, tt.acceptLanguage)
+			require.GreaterOrEqual(t, len(fields), len(tt.expect))
+			assert.Equal(t, tt.expect, fields[:len(tt.expect)])
+		})
+	}
+
+	assert.Nil(t, ValidationFieldErrors(errors.New("unexpected EOF"), "en"))
+}